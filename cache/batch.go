@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBatchExecuted is returned when a batch is executed more than once.
+var ErrBatchExecuted = errors.New("batch has been executed")
+
+// Batcher is implemented by the drivers which can execute several operations in one round trip,
+// the redis driver maps a batch to a pipeline, and the memory driver executes the operations
+// sequentially under one lock.
+//
+// example:
+//
+//	batcher, ok := impl.(cache.Batcher)
+//	if !ok {
+//		return
+//	}
+//	batch := batcher.Batch(ctx)
+//	user, order := batch.Load("user:1"), batch.HGetValue("order:1", "status")
+//	batch.Delete("session:1")
+//	if err := batch.Exec(); err != nil {
+//		return err
+//	}
+//	fmt.Println(user.Exist, user.Value, order.Value)
+type Batcher interface {
+	// Batch 创建一个批量操作，操作在Exec时一次性执行
+	Batch(ctx context.Context) BatchBuilder
+
+	// TxBatch 创建一个事务性的批量操作，所有操作在Exec时原子地执行，在redis中对应MULTI/EXEC
+	TxBatch(ctx context.Context) BatchBuilder
+}
+
+// BatchBuilder queues the operations, the results returned by the queued operations are filled
+// after Exec returns.
+type BatchBuilder interface {
+	// Load 获取key对应的value
+	Load(key string) (result *BatchValueResult)
+
+	// Store 设置key对应的value
+	Store(key string, value string) (result *BatchResult)
+
+	// StoreEX 设置key对应的value，并设置过期时间
+	StoreEX(key string, value string, expiration time.Duration) (result *BatchResult)
+
+	// HGetValue 获取key对应的hash中field对应的value
+	HGetValue(key string, field string) (result *BatchValueResult)
+
+	// HSetValue 设置key对应的hash中field对应的value
+	HSetValue(key string, field string, value string) (result *BatchResult)
+
+	// Delete 删除key
+	Delete(key string) (result *BatchResult)
+
+	// Expire 设置key的过期时间
+	Expire(key string, expire time.Duration) (result *BatchResult)
+
+	// Exec 执行所有操作，返回第一个失败操作的错误；每个批量操作只能执行一次
+	Exec() (err error)
+}
+
+type BatchResult struct {
+	Err error
+}
+
+type BatchValueResult struct {
+	Exist bool
+	Value string
+	Err   error
+}
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// ZMember is a member of a sorted set with its score.
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
 type Cache interface {
 	DriverName() string
 	ExistKey(ctx context.Context, key string) (exist bool, err error)
@@ -42,4 +48,49 @@ type Cache interface {
 	HRemoveValue(ctx context.Context, key string, field string) (err error)
 	HRemoveValues(ctx context.Context, key string, fields ...string) (err error)
 	Expire(ctx context.Context, key string, expire time.Duration) (err error)
+
+	// Persist removes the expiration of key, it does nothing if the key does not exist.
+	Persist(ctx context.Context, key string) (err error)
+
+	// Type returns the type of the value of key, it is the Type of the memory driver or the type
+	// name reported by redis, such as string, hash, set, zset and list.
+	Type(ctx context.Context, key string) (exist bool, keyType string, err error)
+
+	// Scan iterates the keys matching the glob-style pattern, it starts with cursor 0 and returns
+	// the cursor of the next call, the iteration is finished when the returned cursor is 0. Count
+	// is a hint of the number of keys examined in one call, so the number of returned keys may be
+	// less or more than count, and a key may be returned more than once. Keys that exist during
+	// the whole iteration are always returned.
+	Scan(ctx context.Context, cursor uint64, pattern string, count int64) (keys []string, nextCursor uint64, err error)
+
+	// DeleteByPattern deletes every key matching the glob-style pattern, it is not atomic on redis.
+	DeleteByPattern(ctx context.Context, pattern string) (deleted int64, err error)
+	ZAdd(ctx context.Context, key string, members ...ZMember) (added int64, err error)
+	ZIncrBy(ctx context.Context, key string, member string, increment float64) (score float64, err error)
+	ZScore(ctx context.Context, key string, member string) (exist bool, score float64, err error)
+	ZRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error)
+	ZRevRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error)
+	ZRange(ctx context.Context, key string, start, stop int64) (members []ZMember, err error)
+	ZRevRange(ctx context.Context, key string, start, stop int64) (members []ZMember, err error)
+	ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) (members []ZMember, err error)
+	ZRem(ctx context.Context, key string, members ...string) (removed int64, err error)
+	ZCard(ctx context.Context, key string) (count int64, err error)
+	ZPopMin(ctx context.Context, key string, count int64) (members []ZMember, err error)
+	ZPopMax(ctx context.Context, key string, count int64) (members []ZMember, err error)
+	LPush(ctx context.Context, key string, values ...string) (length int64, err error)
+	RPush(ctx context.Context, key string, values ...string) (length int64, err error)
+	LPop(ctx context.Context, key string) (exist bool, value string, err error)
+	RPop(ctx context.Context, key string) (exist bool, value string, err error)
+	LRange(ctx context.Context, key string, start, stop int64) (values []string, err error)
+	LLen(ctx context.Context, key string) (length int64, err error)
+
+	// BLPop pops the first value of the first non-empty list in keys, it blocks until a value is
+	// pushed or ctx is done. When the deadline of ctx is exceeded, popped is false and err is nil.
+	BLPop(ctx context.Context, keys ...string) (popped bool, key string, value string, err error)
+
+	// Subscribe emits the events of the keys matching the glob-style pattern until ctx is done,
+	// then the channel is closed, it is also closed at once if the subscribing fails. The events
+	// are delivered on a best-effort basis, they are dropped when the receiver can not keep up, so
+	// they should not be used as a reliable log. The changes of the ttl alone emit no events.
+	Subscribe(ctx context.Context, pattern string) <-chan CacheEvent
 }
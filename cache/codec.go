@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	CodecJson     = "json"
+	CodecGzipJson = "gzip_json"
+)
+
+// ErrCodecNotFound is returned when a codec name is not registered.
+var ErrCodecNotFound = errors.New("codec is not registered")
+
+// Codec encodes the values of the *Json methods of a cache, the encoded bytes are stored as the
+// string value, so a codec producing binary data works with every driver. Codecs are selected by
+// name in the driver config, a codec such as msgpack can be registered by RegisterCodec.
+type Codec interface {
+	Name() string
+	Marshal(value any) (data []byte, err error)
+	Unmarshal(data []byte, receiverPtr any) (err error)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return CodecJson
+}
+
+func (jsonCodec) Marshal(value any) (data []byte, err error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Unmarshal(data []byte, receiverPtr any) (err error) {
+	return json.Unmarshal(data, receiverPtr)
+}
+
+// gzipJsonCodec compresses the json, it saves memory and bandwidth for large payloads at the
+// cost of cpu.
+type gzipJsonCodec struct{}
+
+func (gzipJsonCodec) Name() string {
+	return CodecGzipJson
+}
+
+func (gzipJsonCodec) Marshal(value any) (data []byte, err error) {
+	buffer := bytes.Buffer{}
+	writer := gzip.NewWriter(&buffer)
+	if encodeErr := json.NewEncoder(writer).Encode(value); encodeErr != nil {
+		return nil, encodeErr
+	}
+	if closeErr := writer.Close(); closeErr != nil {
+		return nil, closeErr
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (gzipJsonCodec) Unmarshal(data []byte, receiverPtr any) (err error) {
+	reader, openErr := gzip.NewReader(bytes.NewReader(data))
+	if openErr != nil {
+		return openErr
+	}
+	defer func() { _ = reader.Close() }()
+
+	decoded, readErr := io.ReadAll(reader)
+	if readErr != nil {
+		return readErr
+	}
+
+	return json.Unmarshal(decoded, receiverPtr)
+}
+
+var (
+	codecMtx = sync.RWMutex{}
+	codecs   = map[string]Codec{
+		CodecJson:     jsonCodec{},
+		CodecGzipJson: gzipJsonCodec{},
+	}
+)
+
+// RegisterCodec makes the codec selectable by its name, a registered codec with the same name is
+// replaced.
+func RegisterCodec(codec Codec) {
+	codecMtx.Lock()
+	defer codecMtx.Unlock()
+
+	codecs[codec.Name()] = codec
+}
+
+// GetCodec returns the codec registered with name, the json codec is returned when name is empty.
+func GetCodec(name string) (codec Codec, err error) {
+	if name == "" {
+		name = CodecJson
+	}
+
+	codecMtx.RLock()
+	defer codecMtx.RUnlock()
+
+	codec, exist := codecs[name]
+	if !exist {
+		return nil, fmt.Errorf("get codec %s: %w", name, ErrCodecNotFound)
+	}
+	return codec, nil
+}
+
+// Get loads the value of key and decodes it into a T with the codec of the cache.
+//
+// example:
+//
+//	exist, user, err := cache.Get[User](ctx, impl, "user:1")
+func Get[T any](ctx context.Context, c Cache, key string) (exist bool, value T, err error) {
+	exist, err = c.LoadJson(ctx, key, &value)
+	return exist, value, err
+}
+
+// Set encodes the value with the codec of the cache and stores it, the key never expires when
+// expiration is not positive.
+func Set[T any](ctx context.Context, c Cache, key string, value T, expiration time.Duration) (err error) {
+	if expiration <= 0 {
+		return c.StoreJson(ctx, key, &value)
+	}
+
+	return c.StoreJsonEX(ctx, key, &value, expiration)
+}
+
+// HGet loads the field of the hash key and decodes it into a T with the codec of the cache.
+func HGet[T any](ctx context.Context, c Cache, key string, field string) (exist bool, value T, err error) {
+	exist, err = c.HGetJson(ctx, key, field, &value)
+	return exist, value, err
+}
@@ -36,4 +36,21 @@ type Counter interface {
 
 	// ExpireImmediately 计数器立即过期，如果key不存在，则不会生效
 	ExpireImmediately(ctx context.Context, key string) (result CounterResultEnum)
+
+	// Decrease 将计数器的值减少delta，返回减少后的值；如果key不存在，则创建一个新的计数器，初始值为-delta
+	Decrease(ctx context.Context, key string, delta uint64) (value int64, result CounterResultEnum)
+
+	// Get 获取计数器的值；如果key不存在，返回0和CounterResultEnumNotEffective
+	Get(ctx context.Context, key string) (value int64, result CounterResultEnum)
+
+	// Set 将计数器的值设置为value，保留原有的过期时间；如果key不存在，则创建一个新的计数器
+	Set(ctx context.Context, key string, value int64) (newValue int64, result CounterResultEnum)
+
+	// IncreaseWithLimit 将计数器的值增加delta，如果增加后的值会超过limit，则不会生效，返回当前值和CounterResultEnumNotEffective；
+	// 如果key不存在，视为初始值为0
+	IncreaseWithLimit(ctx context.Context, key string, delta uint64, limit int64) (value int64, result CounterResultEnum)
+
+	// CompareAndSwap 如果计数器的值等于old，则将其设置为new并保留原有的过期时间，返回new；否则不会生效，返回当前值和CounterResultEnumNotEffective；
+	// 如果key不存在，视为当前值为0
+	CompareAndSwap(ctx context.Context, key string, old, new int64) (value int64, result CounterResultEnum)
 }
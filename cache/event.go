@@ -0,0 +1,20 @@
+package cache
+
+type CacheEventType string
+
+const (
+	// CacheEventSet is emitted when the value of a key is created or changed.
+	CacheEventSet CacheEventType = "set"
+
+	// CacheEventDelete is emitted when a key is deleted or evicted.
+	CacheEventDelete CacheEventType = "delete"
+
+	// CacheEventExpire is emitted when a key is removed because it is expired.
+	CacheEventExpire CacheEventType = "expire"
+)
+
+// CacheEvent is a change of a key emitted by Cache.Subscribe.
+type CacheEvent struct {
+	Type CacheEventType `json:"type"`
+	Key  string         `json:"key"`
+}
@@ -0,0 +1,386 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+type instrumentedCache struct {
+	impl    cache.Cache
+	options *options
+}
+
+// NewCache wraps impl, the latency and result of every operation are recorded into the metrics,
+// the reads reporting whether the key exists are counted as hits and misses. The returned cache
+// implements cache.Locker and cache.Batcher when impl does, the lock operations are recorded as
+// well, while the batches are forwarded to impl without being recorded.
+func NewCache(impl cache.Cache, opts ...Option) cache.Cache {
+	ic := &instrumentedCache{impl: impl, options: newOptions(impl.DriverName(), opts)}
+	locker, isLocker := impl.(cache.Locker)
+	batcher, isBatcher := impl.(cache.Batcher)
+
+	// 按impl实现的接口组合返回值，使调用方的类型断言结果与impl一致
+	switch {
+	case isLocker && isBatcher:
+		return &struct {
+			*instrumentedCache
+			*instrumentedLocker
+			cache.Batcher
+		}{ic, &instrumentedLocker{impl: locker, options: ic.options}, batcher}
+	case isLocker:
+		return &struct {
+			*instrumentedCache
+			*instrumentedLocker
+		}{ic, &instrumentedLocker{impl: locker, options: ic.options}}
+	case isBatcher:
+		return &struct {
+			*instrumentedCache
+			cache.Batcher
+		}{ic, batcher}
+	default:
+		return ic
+	}
+}
+
+func (ic *instrumentedCache) DriverName() string {
+	return ic.impl.DriverName()
+}
+
+func (ic *instrumentedCache) ExistKey(ctx context.Context, key string) (exist bool, err error) {
+	defer ic.options.observe(ctx, "ExistKey", key, time.Now(), &exist, &err)
+	return ic.impl.ExistKey(ctx, key)
+}
+
+func (ic *instrumentedCache) GetExpiredTime(ctx context.Context, key string) (exist bool, expiredAt time.Time, err error) {
+	defer ic.options.observe(ctx, "GetExpiredTime", key, time.Now(), &exist, &err)
+	return ic.impl.GetExpiredTime(ctx, key)
+}
+
+func (ic *instrumentedCache) Load(ctx context.Context, key string) (exist bool, value string, err error) {
+	defer ic.options.observe(ctx, "Load", key, time.Now(), &exist, &err)
+	return ic.impl.Load(ctx, key)
+}
+
+func (ic *instrumentedCache) LoadWithEX(ctx context.Context, key string) (loaded bool, expiredTime time.Duration, value string, err error) {
+	defer ic.options.observe(ctx, "LoadWithEX", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadWithEX(ctx, key)
+}
+
+func (ic *instrumentedCache) LoadJson(ctx context.Context, key string, receiverPtr any) (exist bool, err error) {
+	defer ic.options.observe(ctx, "LoadJson", key, time.Now(), &exist, &err)
+	return ic.impl.LoadJson(ctx, key, receiverPtr)
+}
+
+func (ic *instrumentedCache) LoadJsonWithEX(ctx context.Context, key string, receiverPtr any) (exist bool, expiredTime time.Duration, err error) {
+	defer ic.options.observe(ctx, "LoadJsonWithEX", key, time.Now(), &exist, &err)
+	return ic.impl.LoadJsonWithEX(ctx, key, receiverPtr)
+}
+
+func (ic *instrumentedCache) Store(ctx context.Context, key string, value string) (err error) {
+	defer ic.options.observe(ctx, "Store", key, time.Now(), nil, &err)
+	return ic.impl.Store(ctx, key, value)
+}
+
+func (ic *instrumentedCache) StoreEX(ctx context.Context, key string, value string, expiration time.Duration) (err error) {
+	defer ic.options.observe(ctx, "StoreEX", key, time.Now(), nil, &err)
+	return ic.impl.StoreEX(ctx, key, value, expiration)
+}
+
+func (ic *instrumentedCache) StoreJson(ctx context.Context, key string, senderPtr any) (err error) {
+	defer ic.options.observe(ctx, "StoreJson", key, time.Now(), nil, &err)
+	return ic.impl.StoreJson(ctx, key, senderPtr)
+}
+
+func (ic *instrumentedCache) StoreJsonEX(ctx context.Context, key string, senderPtr any, expiration time.Duration) (err error) {
+	defer ic.options.observe(ctx, "StoreJsonEX", key, time.Now(), nil, &err)
+	return ic.impl.StoreJsonEX(ctx, key, senderPtr, expiration)
+}
+
+func (ic *instrumentedCache) Delete(ctx context.Context, key string) (err error) {
+	defer ic.options.observe(ctx, "Delete", key, time.Now(), nil, &err)
+	return ic.impl.Delete(ctx, key)
+}
+
+func (ic *instrumentedCache) LoadAndDelete(ctx context.Context, key string) (loaded bool, value string, err error) {
+	defer ic.options.observe(ctx, "LoadAndDelete", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadAndDelete(ctx, key)
+}
+
+func (ic *instrumentedCache) LoadAndDeleteJson(ctx context.Context, key string, receivePtr any) (loaded bool, err error) {
+	defer ic.options.observe(ctx, "LoadAndDeleteJson", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadAndDeleteJson(ctx, key, receivePtr)
+}
+
+func (ic *instrumentedCache) LoadOrStore(ctx context.Context, key string, storeValue string) (loaded bool, value string, err error) {
+	defer ic.options.observe(ctx, "LoadOrStore", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadOrStore(ctx, key, storeValue)
+}
+
+func (ic *instrumentedCache) LoadOrStoreEX(ctx context.Context, key string, storeValue string, expiration time.Duration) (loaded bool, value string, err error) {
+	defer ic.options.observe(ctx, "LoadOrStoreEX", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadOrStoreEX(ctx, key, storeValue, expiration)
+}
+
+func (ic *instrumentedCache) LoadOrStoreJson(ctx context.Context, key string, senderPtr any, receiverPtr any) (loaded bool, err error) {
+	defer ic.options.observe(ctx, "LoadOrStoreJson", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadOrStoreJson(ctx, key, senderPtr, receiverPtr)
+}
+
+func (ic *instrumentedCache) LoadOrStoreJsonEX(ctx context.Context, key string, senderPtr any, receiverPtr any, expiration time.Duration) (loaded bool, err error) {
+	defer ic.options.observe(ctx, "LoadOrStoreJsonEX", key, time.Now(), &loaded, &err)
+	return ic.impl.LoadOrStoreJsonEX(ctx, key, senderPtr, receiverPtr, expiration)
+}
+
+func (ic *instrumentedCache) IsMember(ctx context.Context, key string, member string) (isMember bool, err error) {
+	defer ic.options.observe(ctx, "IsMember", key, time.Now(), nil, &err)
+	return ic.impl.IsMember(ctx, key, member)
+}
+
+func (ic *instrumentedCache) IsMembers(ctx context.Context, key string, members ...string) (isMembers bool, err error) {
+	defer ic.options.observe(ctx, "IsMembers", key, time.Now(), nil, &err)
+	return ic.impl.IsMembers(ctx, key, members...)
+}
+
+func (ic *instrumentedCache) AddMember(ctx context.Context, key string, member string) (err error) {
+	defer ic.options.observe(ctx, "AddMember", key, time.Now(), nil, &err)
+	return ic.impl.AddMember(ctx, key, member)
+}
+
+func (ic *instrumentedCache) AddMembers(ctx context.Context, key string, members ...string) (err error) {
+	defer ic.options.observe(ctx, "AddMembers", key, time.Now(), nil, &err)
+	return ic.impl.AddMembers(ctx, key, members...)
+}
+
+func (ic *instrumentedCache) RemoveMember(ctx context.Context, key string, member string) (err error) {
+	defer ic.options.observe(ctx, "RemoveMember", key, time.Now(), nil, &err)
+	return ic.impl.RemoveMember(ctx, key, member)
+}
+
+func (ic *instrumentedCache) GetMembers(ctx context.Context, key string) (members []string, err error) {
+	defer ic.options.observe(ctx, "GetMembers", key, time.Now(), nil, &err)
+	return ic.impl.GetMembers(ctx, key)
+}
+
+func (ic *instrumentedCache) GetRandomMember(ctx context.Context, key string) (member string, err error) {
+	defer ic.options.observe(ctx, "GetRandomMember", key, time.Now(), nil, &err)
+	return ic.impl.GetRandomMember(ctx, key)
+}
+
+func (ic *instrumentedCache) GetRandomMembers(ctx context.Context, key string, count int64) (members []string, err error) {
+	defer ic.options.observe(ctx, "GetRandomMembers", key, time.Now(), nil, &err)
+	return ic.impl.GetRandomMembers(ctx, key, count)
+}
+
+func (ic *instrumentedCache) HGetValue(ctx context.Context, key string, field string) (exist bool, value string, err error) {
+	defer ic.options.observe(ctx, "HGetValue", key, time.Now(), &exist, &err)
+	return ic.impl.HGetValue(ctx, key, field)
+}
+
+func (ic *instrumentedCache) HGetValues(ctx context.Context, key string, fields ...string) (resultMap map[string]string, err error) {
+	defer ic.options.observe(ctx, "HGetValues", key, time.Now(), nil, &err)
+	return ic.impl.HGetValues(ctx, key, fields...)
+}
+
+func (ic *instrumentedCache) HGetJson(ctx context.Context, key string, field string, receiverPtr any) (exist bool, err error) {
+	defer ic.options.observe(ctx, "HGetJson", key, time.Now(), &exist, &err)
+	return ic.impl.HGetJson(ctx, key, field, receiverPtr)
+}
+
+func (ic *instrumentedCache) HGetAll(ctx context.Context, key string) (resultMap map[string]string, err error) {
+	defer ic.options.observe(ctx, "HGetAll", key, time.Now(), nil, &err)
+	return ic.impl.HGetAll(ctx, key)
+}
+
+func (ic *instrumentedCache) HGetAllJson(ctx context.Context, key string, receiverPtr any) (err error) {
+	defer ic.options.observe(ctx, "HGetAllJson", key, time.Now(), nil, &err)
+	return ic.impl.HGetAllJson(ctx, key, receiverPtr)
+}
+
+func (ic *instrumentedCache) HSetValue(ctx context.Context, key string, field string, value string) (err error) {
+	defer ic.options.observe(ctx, "HSetValue", key, time.Now(), nil, &err)
+	return ic.impl.HSetValue(ctx, key, field, value)
+}
+
+func (ic *instrumentedCache) HSetValues(ctx context.Context, key string, values map[string]string) (err error) {
+	defer ic.options.observe(ctx, "HSetValues", key, time.Now(), nil, &err)
+	return ic.impl.HSetValues(ctx, key, values)
+}
+
+func (ic *instrumentedCache) HRemoveValue(ctx context.Context, key string, field string) (err error) {
+	defer ic.options.observe(ctx, "HRemoveValue", key, time.Now(), nil, &err)
+	return ic.impl.HRemoveValue(ctx, key, field)
+}
+
+func (ic *instrumentedCache) HRemoveValues(ctx context.Context, key string, fields ...string) (err error) {
+	defer ic.options.observe(ctx, "HRemoveValues", key, time.Now(), nil, &err)
+	return ic.impl.HRemoveValues(ctx, key, fields...)
+}
+
+func (ic *instrumentedCache) Expire(ctx context.Context, key string, expire time.Duration) (err error) {
+	defer ic.options.observe(ctx, "Expire", key, time.Now(), nil, &err)
+	return ic.impl.Expire(ctx, key, expire)
+}
+
+func (ic *instrumentedCache) Persist(ctx context.Context, key string) (err error) {
+	defer ic.options.observe(ctx, "Persist", key, time.Now(), nil, &err)
+	return ic.impl.Persist(ctx, key)
+}
+
+func (ic *instrumentedCache) Type(ctx context.Context, key string) (exist bool, keyType string, err error) {
+	defer ic.options.observe(ctx, "Type", key, time.Now(), &exist, &err)
+	return ic.impl.Type(ctx, key)
+}
+
+func (ic *instrumentedCache) Scan(ctx context.Context, cursor uint64, pattern string, count int64) (keys []string, nextCursor uint64, err error) {
+	defer ic.options.observe(ctx, "Scan", "", time.Now(), nil, &err)
+	return ic.impl.Scan(ctx, cursor, pattern, count)
+}
+
+func (ic *instrumentedCache) DeleteByPattern(ctx context.Context, pattern string) (deleted int64, err error) {
+	defer ic.options.observe(ctx, "DeleteByPattern", "", time.Now(), nil, &err)
+	return ic.impl.DeleteByPattern(ctx, pattern)
+}
+
+func (ic *instrumentedCache) ZAdd(ctx context.Context, key string, members ...cache.ZMember) (added int64, err error) {
+	defer ic.options.observe(ctx, "ZAdd", key, time.Now(), nil, &err)
+	return ic.impl.ZAdd(ctx, key, members...)
+}
+
+func (ic *instrumentedCache) ZIncrBy(ctx context.Context, key string, member string, increment float64) (score float64, err error) {
+	defer ic.options.observe(ctx, "ZIncrBy", key, time.Now(), nil, &err)
+	return ic.impl.ZIncrBy(ctx, key, member, increment)
+}
+
+func (ic *instrumentedCache) ZScore(ctx context.Context, key string, member string) (exist bool, score float64, err error) {
+	defer ic.options.observe(ctx, "ZScore", key, time.Now(), &exist, &err)
+	return ic.impl.ZScore(ctx, key, member)
+}
+
+func (ic *instrumentedCache) ZRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error) {
+	defer ic.options.observe(ctx, "ZRank", key, time.Now(), &exist, &err)
+	return ic.impl.ZRank(ctx, key, member)
+}
+
+func (ic *instrumentedCache) ZRevRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error) {
+	defer ic.options.observe(ctx, "ZRevRank", key, time.Now(), &exist, &err)
+	return ic.impl.ZRevRank(ctx, key, member)
+}
+
+func (ic *instrumentedCache) ZRange(ctx context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	defer ic.options.observe(ctx, "ZRange", key, time.Now(), nil, &err)
+	return ic.impl.ZRange(ctx, key, start, stop)
+}
+
+func (ic *instrumentedCache) ZRevRange(ctx context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	defer ic.options.observe(ctx, "ZRevRange", key, time.Now(), nil, &err)
+	return ic.impl.ZRevRange(ctx, key, start, stop)
+}
+
+func (ic *instrumentedCache) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) (members []cache.ZMember, err error) {
+	defer ic.options.observe(ctx, "ZRangeByScore", key, time.Now(), nil, &err)
+	return ic.impl.ZRangeByScore(ctx, key, min, max, offset, count)
+}
+
+func (ic *instrumentedCache) ZRem(ctx context.Context, key string, members ...string) (removed int64, err error) {
+	defer ic.options.observe(ctx, "ZRem", key, time.Now(), nil, &err)
+	return ic.impl.ZRem(ctx, key, members...)
+}
+
+func (ic *instrumentedCache) ZCard(ctx context.Context, key string) (count int64, err error) {
+	defer ic.options.observe(ctx, "ZCard", key, time.Now(), nil, &err)
+	return ic.impl.ZCard(ctx, key)
+}
+
+func (ic *instrumentedCache) ZPopMin(ctx context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	defer ic.options.observe(ctx, "ZPopMin", key, time.Now(), nil, &err)
+	return ic.impl.ZPopMin(ctx, key, count)
+}
+
+func (ic *instrumentedCache) ZPopMax(ctx context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	defer ic.options.observe(ctx, "ZPopMax", key, time.Now(), nil, &err)
+	return ic.impl.ZPopMax(ctx, key, count)
+}
+
+func (ic *instrumentedCache) LPush(ctx context.Context, key string, values ...string) (length int64, err error) {
+	defer ic.options.observe(ctx, "LPush", key, time.Now(), nil, &err)
+	return ic.impl.LPush(ctx, key, values...)
+}
+
+func (ic *instrumentedCache) RPush(ctx context.Context, key string, values ...string) (length int64, err error) {
+	defer ic.options.observe(ctx, "RPush", key, time.Now(), nil, &err)
+	return ic.impl.RPush(ctx, key, values...)
+}
+
+func (ic *instrumentedCache) LPop(ctx context.Context, key string) (exist bool, value string, err error) {
+	defer ic.options.observe(ctx, "LPop", key, time.Now(), &exist, &err)
+	return ic.impl.LPop(ctx, key)
+}
+
+func (ic *instrumentedCache) RPop(ctx context.Context, key string) (exist bool, value string, err error) {
+	defer ic.options.observe(ctx, "RPop", key, time.Now(), &exist, &err)
+	return ic.impl.RPop(ctx, key)
+}
+
+func (ic *instrumentedCache) LRange(ctx context.Context, key string, start, stop int64) (values []string, err error) {
+	defer ic.options.observe(ctx, "LRange", key, time.Now(), nil, &err)
+	return ic.impl.LRange(ctx, key, start, stop)
+}
+
+func (ic *instrumentedCache) LLen(ctx context.Context, key string) (length int64, err error) {
+	defer ic.options.observe(ctx, "LLen", key, time.Now(), nil, &err)
+	return ic.impl.LLen(ctx, key)
+}
+
+// BLPop is not logged as a slow operation since it blocks by design, and its keys are not tracked.
+func (ic *instrumentedCache) BLPop(ctx context.Context, keys ...string) (popped bool, key string, value string, err error) {
+	startedAt := time.Now()
+	popped, key, value, err = ic.impl.BLPop(ctx, keys...)
+
+	result := outcomeHit
+	if err != nil {
+		result = outcomeError
+	} else if !popped {
+		result = outcomeMiss
+	}
+	ic.options.metrics.record(ic.options.name, "BLPop", "", "", time.Since(startedAt), result)
+	return popped, key, value, err
+}
+
+func (ic *instrumentedCache) Subscribe(ctx context.Context, pattern string) <-chan cache.CacheEvent {
+	return ic.impl.Subscribe(ctx, pattern)
+}
+
+type instrumentedLocker struct {
+	impl    cache.Locker
+	options *options
+}
+
+func (il *instrumentedLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (acquired bool, token string, err error) {
+	defer il.options.observe(ctx, "TryLock", key, time.Now(), &acquired, &err)
+	return il.impl.TryLock(ctx, key, ttl)
+}
+
+// Lock is not logged as a slow operation since it blocks until the lock is released by the holder.
+func (il *instrumentedLocker) Lock(ctx context.Context, key string, ttl time.Duration) (token string, err error) {
+	startedAt := time.Now()
+	token, err = il.impl.Lock(ctx, key, ttl)
+
+	result := outcomeOK
+	if err != nil {
+		result = outcomeError
+	}
+	il.options.metrics.record(il.options.name, "Lock", il.options.prefixOf(key), key, time.Since(startedAt), result)
+	return token, err
+}
+
+func (il *instrumentedLocker) Unlock(ctx context.Context, key string, token string) (err error) {
+	defer il.options.observe(ctx, "Unlock", key, time.Now(), nil, &err)
+	return il.impl.Unlock(ctx, key, token)
+}
+
+func (il *instrumentedLocker) Extend(ctx context.Context, key string, token string, ttl time.Duration) (err error) {
+	defer il.options.observe(ctx, "Extend", key, time.Now(), nil, &err)
+	return il.impl.Extend(ctx, key, token, ttl)
+}
@@ -0,0 +1,63 @@
+package instrument
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	// cardinalityPrecision is the number of hash bits selecting a register, a sketch has
+	// 1<<cardinalityPrecision registers of one byte, and the standard error is about 3.25%.
+	cardinalityPrecision = 10
+	cardinalityRegisters = 1 << cardinalityPrecision
+)
+
+// cardinalitySketch estimates the number of distinct keys with a HyperLogLog, so that the memory
+// used by a prefix is fixed no matter how many keys are seen. Small counts are estimated with the
+// linear counting, which is exact as long as the keys fall into different registers.
+type cardinalitySketch struct {
+	registers [cardinalityRegisters]uint8
+}
+
+// hashKey hashes the key for the sketch, it does not need the lock of the metrics.
+func hashKey(key string) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+
+	// fnv的高位分布较差，使用murmur3的fmix64打散
+	hash := hasher.Sum64()
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
+
+func (s *cardinalitySketch) add(hash uint64) {
+	index := hash >> (64 - cardinalityPrecision)
+	// 补一个哨兵位，避免剩余位全为0时rank溢出
+	rank := uint8(bits.LeadingZeros64(hash<<cardinalityPrecision|1<<(cardinalityPrecision-1)) + 1)
+	if rank > s.registers[index] {
+		s.registers[index] = rank
+	}
+}
+
+func (s *cardinalitySketch) estimate() uint64 {
+	sum, zeros := 0.0, 0
+	for _, register := range s.registers {
+		sum += math.Ldexp(1, -int(register))
+		if register == 0 {
+			zeros++
+		}
+	}
+
+	m := float64(cardinalityRegisters)
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(math.Round(estimate))
+}
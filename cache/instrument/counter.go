@@ -0,0 +1,83 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+type instrumentedCounter struct {
+	impl    cache.Counter
+	options *options
+}
+
+// NewCounter wraps impl like NewCache, the name of the counter is "counter" by default. Get is
+// counted as a hit when the counter exists, the failed operations are counted as errors.
+func NewCounter(impl cache.Counter, opts ...Option) cache.Counter {
+	return &instrumentedCounter{impl: impl, options: newOptions("counter", opts)}
+}
+
+func (ic *instrumentedCounter) observe(ctx context.Context, operation, key string, startedAt time.Time, result *cache.CounterResultEnum) {
+	counted := outcomeOK
+	switch {
+	case *result == cache.CounterResultEnumFailed:
+		counted = outcomeError
+	case operation == "Get" && *result == cache.CounterResultEnumSuccess:
+		counted = outcomeHit
+	case operation == "Get":
+		counted = outcomeMiss
+	}
+
+	ic.options.record(ctx, operation, key, time.Since(startedAt), counted)
+}
+
+func (ic *instrumentedCounter) Increase(ctx context.Context, key string, delta uint64) (result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "Increase", key, time.Now(), &result)
+	return ic.impl.Increase(ctx, key, delta)
+}
+
+func (ic *instrumentedCounter) IncreaseWithExpireWhenNotExist(ctx context.Context, key string, delta uint64, expire time.Duration) (result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "IncreaseWithExpireWhenNotExist", key, time.Now(), &result)
+	return ic.impl.IncreaseWithExpireWhenNotExist(ctx, key, delta, expire)
+}
+
+func (ic *instrumentedCounter) SetExpire(ctx context.Context, key string, expire time.Duration) (result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "SetExpire", key, time.Now(), &result)
+	return ic.impl.SetExpire(ctx, key, expire)
+}
+
+func (ic *instrumentedCounter) SetExpireWhenNotSet(ctx context.Context, key string, expire time.Duration) (result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "SetExpireWhenNotSet", key, time.Now(), &result)
+	return ic.impl.SetExpireWhenNotSet(ctx, key, expire)
+}
+
+func (ic *instrumentedCounter) ExpireImmediately(ctx context.Context, key string) (result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "ExpireImmediately", key, time.Now(), &result)
+	return ic.impl.ExpireImmediately(ctx, key)
+}
+
+func (ic *instrumentedCounter) Decrease(ctx context.Context, key string, delta uint64) (value int64, result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "Decrease", key, time.Now(), &result)
+	return ic.impl.Decrease(ctx, key, delta)
+}
+
+func (ic *instrumentedCounter) Get(ctx context.Context, key string) (value int64, result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "Get", key, time.Now(), &result)
+	return ic.impl.Get(ctx, key)
+}
+
+func (ic *instrumentedCounter) Set(ctx context.Context, key string, value int64) (newValue int64, result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "Set", key, time.Now(), &result)
+	return ic.impl.Set(ctx, key, value)
+}
+
+func (ic *instrumentedCounter) IncreaseWithLimit(ctx context.Context, key string, delta uint64, limit int64) (value int64, result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "IncreaseWithLimit", key, time.Now(), &result)
+	return ic.impl.IncreaseWithLimit(ctx, key, delta, limit)
+}
+
+func (ic *instrumentedCounter) CompareAndSwap(ctx context.Context, key string, old, new int64) (value int64, result cache.CounterResultEnum) {
+	defer ic.observe(ctx, "CompareAndSwap", key, time.Now(), &result)
+	return ic.impl.CompareAndSwap(ctx, key, old, new)
+}
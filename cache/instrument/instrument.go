@@ -0,0 +1,117 @@
+package instrument
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/alioth-center/infrastructure/logger"
+)
+
+const (
+	defaultSlowThreshold = time.Millisecond * 100
+	defaultKeySeparator  = ":"
+)
+
+type options struct {
+	name          string
+	metrics       *Metrics
+	logger        logger.Logger
+	slowThreshold time.Duration
+	keySeparator  string
+}
+
+type Option func(*options)
+
+// WithNameOpts sets the value of the cache label, it is the driver name of the cache by default.
+func WithNameOpts(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithMetricsOpts records the statistics into metrics instead of DefaultMetrics.
+func WithMetricsOpts(metrics *Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithLoggerOpts logs the operations slower than the slow threshold, nothing is logged without
+// a logger.
+func WithLoggerOpts(logger logger.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSlowThresholdOpts sets the threshold of the slow operations, it is 100ms by default.
+func WithSlowThresholdOpts(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithKeySeparatorOpts sets the separator of the key prefix, the prefix of a key is the part
+// before the first separator, it is ":" by default.
+func WithKeySeparatorOpts(separator string) Option {
+	return func(o *options) {
+		o.keySeparator = separator
+	}
+}
+
+func newOptions(name string, opts []Option) *options {
+	o := &options{
+		name:          name,
+		metrics:       DefaultMetrics(),
+		slowThreshold: defaultSlowThreshold,
+		keySeparator:  defaultKeySeparator,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
+
+func (o *options) prefixOf(key string) string {
+	if index := strings.Index(key, o.keySeparator); index >= 0 && o.keySeparator != "" {
+		return key[:index]
+	}
+
+	return ""
+}
+
+// observe records an operation started at startedAt, it is deferred by the instrumented methods
+// so that the named results are read after the operation returns. A nil hit means the operation
+// does not report hits and misses.
+func (o *options) observe(ctx context.Context, operation, key string, startedAt time.Time, hit *bool, err *error) {
+	duration, result := time.Since(startedAt), outcomeOK
+	switch {
+	case err != nil && *err != nil:
+		result = outcomeError
+	case hit != nil && *hit:
+		result = outcomeHit
+	case hit != nil:
+		result = outcomeMiss
+	}
+
+	o.record(ctx, operation, key, duration, result)
+}
+
+func (o *options) record(ctx context.Context, operation, key string, duration time.Duration, result outcome) {
+	o.metrics.record(o.name, operation, o.prefixOf(key), key, duration, result)
+	if o.logger == nil || duration < o.slowThreshold {
+		return
+	}
+
+	// 日志字段从ctx中获取trace id，与调用方的日志关联
+	o.logger.Warn(logger.NewFields(ctx).WithMessage("slow cache operation").WithData(map[string]any{
+		"cache":     o.name,
+		"operation": operation,
+		"key":       key,
+		"duration":  duration.String(),
+	}))
+}
@@ -0,0 +1,210 @@
+package instrument
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTrackedPrefixes limits the number of prefixes tracked by a Metrics, the keys of the other
+	// prefixes are counted under the prefix otherPrefix. Every prefix holds a cardinalitySketch of
+	// 1KB, so the cardinality uses at most about 1MB.
+	maxTrackedPrefixes = 1000
+
+	otherPrefix = "_other"
+)
+
+// durationBuckets are the upper bounds of the latency histogram in seconds.
+var durationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+type outcome int
+
+const (
+	outcomeOK outcome = iota
+	outcomeHit
+	outcomeMiss
+	outcomeError
+)
+
+type operationLabels struct {
+	name      string
+	operation string
+}
+
+type operationStats struct {
+	ok, hits, misses, errors uint64
+	buckets                  []uint64
+	count                    uint64
+	sum                      float64
+}
+
+type prefixLabels struct {
+	name   string
+	prefix string
+}
+
+// Metrics collects the statistics of the instrumented caches, it serves them in the prometheus
+// text format, so it can be mounted on any http server.
+//
+// example:
+//
+//	engine.AddEndPoints(http.NewRawEndPoint(http.GET, http.NewRouter("/metrics"), instrument.DefaultMetrics()))
+type Metrics struct {
+	mtx        sync.Mutex
+	operations map[operationLabels]*operationStats
+	prefixes   map[prefixLabels]*cardinalitySketch
+}
+
+var defaultMetrics = NewMetrics()
+
+// DefaultMetrics returns the metrics used by the instrumented caches created without WithMetricsOpts.
+func DefaultMetrics() *Metrics {
+	return defaultMetrics
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		operations: map[operationLabels]*operationStats{},
+		prefixes:   map[prefixLabels]*cardinalitySketch{},
+	}
+}
+
+func (m *Metrics) record(name, operation, prefix, key string, duration time.Duration, result outcome) {
+	var hash uint64
+	if key != "" {
+		hash = hashKey(key)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	labels := operationLabels{name: name, operation: operation}
+	stats, exist := m.operations[labels]
+	if !exist {
+		stats = &operationStats{buckets: make([]uint64, len(durationBuckets))}
+		m.operations[labels] = stats
+	}
+
+	switch result {
+	case outcomeHit:
+		stats.hits++
+	case outcomeMiss:
+		stats.misses++
+	case outcomeError:
+		stats.errors++
+	default:
+		stats.ok++
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+	stats.count++
+	stats.sum += seconds
+
+	if key != "" {
+		m.trackKey(name, prefix, hash)
+	}
+}
+
+// trackKey adds the hash of a key to the sketch of its prefix, the caller must hold the lock.
+func (m *Metrics) trackKey(name, prefix string, hash uint64) {
+	labels := prefixLabels{name: name, prefix: prefix}
+	sketch, exist := m.prefixes[labels]
+	if !exist {
+		if len(m.prefixes) >= maxTrackedPrefixes {
+			labels.prefix = otherPrefix
+			sketch, exist = m.prefixes[labels]
+		}
+		if !exist {
+			sketch = &cardinalitySketch{}
+			m.prefixes[labels] = sketch
+		}
+	}
+
+	sketch.add(hash)
+}
+
+// Write writes the metrics in the prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) (err error) {
+	m.mtx.Lock()
+	operations := make([]operationLabels, 0, len(m.operations))
+	for labels := range m.operations {
+		operations = append(operations, labels)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].name != operations[j].name {
+			return operations[i].name < operations[j].name
+		}
+		return operations[i].operation < operations[j].operation
+	})
+	prefixes := make([]prefixLabels, 0, len(m.prefixes))
+	for labels := range m.prefixes {
+		prefixes = append(prefixes, labels)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if prefixes[i].name != prefixes[j].name {
+			return prefixes[i].name < prefixes[j].name
+		}
+		return prefixes[i].prefix < prefixes[j].prefix
+	})
+
+	// 先写入缓冲区，避免持有锁时等待网络写入
+	writer := &bytes.Buffer{}
+	_, _ = writer.WriteString("# HELP cache_operations_total Number of cache operations by result.\n")
+	_, _ = writer.WriteString("# TYPE cache_operations_total counter\n")
+	for _, labels := range operations {
+		stats := m.operations[labels]
+		for _, counted := range []struct {
+			result string
+			value  uint64
+		}{{"ok", stats.ok}, {"hit", stats.hits}, {"miss", stats.misses}, {"error", stats.errors}} {
+			if counted.value > 0 {
+				_, _ = fmt.Fprintf(writer, "cache_operations_total{cache=%s,operation=%s,result=%q} %d\n", quote(labels.name), quote(labels.operation), counted.result, counted.value)
+			}
+		}
+	}
+
+	_, _ = writer.WriteString("# HELP cache_operation_duration_seconds Latency of cache operations.\n")
+	_, _ = writer.WriteString("# TYPE cache_operation_duration_seconds histogram\n")
+	for _, labels := range operations {
+		stats, name, operation := m.operations[labels], quote(labels.name), quote(labels.operation)
+		for i, bound := range durationBuckets {
+			_, _ = fmt.Fprintf(writer, "cache_operation_duration_seconds_bucket{cache=%s,operation=%s,le=%q} %d\n", name, operation, strconv.FormatFloat(bound, 'g', -1, 64), stats.buckets[i])
+		}
+		_, _ = fmt.Fprintf(writer, "cache_operation_duration_seconds_bucket{cache=%s,operation=%s,le=\"+Inf\"} %d\n", name, operation, stats.count)
+		_, _ = fmt.Fprintf(writer, "cache_operation_duration_seconds_sum{cache=%s,operation=%s} %s\n", name, operation, strconv.FormatFloat(stats.sum, 'g', -1, 64))
+		_, _ = fmt.Fprintf(writer, "cache_operation_duration_seconds_count{cache=%s,operation=%s} %d\n", name, operation, stats.count)
+	}
+
+	_, _ = writer.WriteString("# HELP cache_key_prefix_cardinality Estimated number of distinct keys seen per key prefix.\n")
+	_, _ = writer.WriteString("# TYPE cache_key_prefix_cardinality gauge\n")
+	for _, labels := range prefixes {
+		_, _ = fmt.Fprintf(writer, "cache_key_prefix_cardinality{cache=%s,prefix=%s} %d\n", quote(labels.name), quote(labels.prefix), m.prefixes[labels].estimate())
+	}
+	m.mtx.Unlock()
+
+	_, err = writer.WriteTo(w)
+	return err
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.Write(w)
+}
+
+// quote quotes a label value, only the backslash, double quote and line feed are escaped in the
+// prometheus text format.
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,169 @@
+package instrument
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/memory"
+	"github.com/alioth-center/infrastructure/logger"
+	"github.com/alioth-center/infrastructure/trace"
+)
+
+func TestInstrumentedCache(t *testing.T) {
+	ctx, metrics := trace.NewContext(), NewMetrics()
+	impl := NewCache(memory.NewMemoryCache(memory.Config{}), WithNameOpts("users"), WithMetricsOpts(metrics))
+
+	_ = impl.Store(ctx, "user:1", "alioth")
+	_ = impl.Store(ctx, "user:2", "infrastructure")
+	_, _, _ = impl.Load(ctx, "user:1")
+	_, _, _ = impl.Load(ctx, "user:1")
+	_, _, _ = impl.Load(ctx, "user:3")
+	_, _, _ = impl.HGetValue(ctx, "user:1", "name")
+
+	buffer := &bytes.Buffer{}
+	if err := metrics.Write(buffer); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`cache_operations_total{cache="users",operation="Store",result="ok"} 2`,
+		`cache_operations_total{cache="users",operation="Load",result="hit"} 2`,
+		`cache_operations_total{cache="users",operation="Load",result="miss"} 1`,
+		`cache_operations_total{cache="users",operation="HGetValue",result="error"} 1`,
+		`cache_operation_duration_seconds_count{cache="users",operation="Load"} 3`,
+		`cache_operation_duration_seconds_bucket{cache="users",operation="Load",le="+Inf"} 3`,
+		`cache_key_prefix_cardinality{cache="users",prefix="user"} 3`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("metrics do not contain %s:\n%s", line, buffer.String())
+		}
+	}
+}
+
+func TestInstrumentedCounter(t *testing.T) {
+	ctx, metrics := context.Background(), NewMetrics()
+	impl := NewCounter(memory.NewMemoryCounter(memory.Config{}), WithMetricsOpts(metrics))
+
+	impl.Get(ctx, "visits:1")
+	impl.Increase(ctx, "visits:1", 1)
+	impl.Get(ctx, "visits:1")
+
+	buffer := &bytes.Buffer{}
+	_ = metrics.Write(buffer)
+	for _, line := range []string{
+		`cache_operations_total{cache="counter",operation="Get",result="hit"} 1`,
+		`cache_operations_total{cache="counter",operation="Get",result="miss"} 1`,
+		`cache_operations_total{cache="counter",operation="Increase",result="ok"} 1`,
+		`cache_key_prefix_cardinality{cache="counter",prefix="visits"} 1`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("metrics do not contain %s:\n%s", line, buffer.String())
+		}
+	}
+}
+
+// captureLogger records the warnings, only Warn is used by the instrumented caches.
+type captureLogger struct {
+	logger.Logger
+	logged chan *logger.Entry
+}
+
+func (l captureLogger) Warn(fields logger.Fields) {
+	l.logged <- fields.Export()
+}
+
+func TestSlowOperationLog(t *testing.T) {
+	logged := make(chan *logger.Entry, 10)
+	log := captureLogger{logged: logged}
+
+	ctx := trace.NewContext()
+	impl := NewCache(memory.NewMemoryCache(memory.Config{}), WithMetricsOpts(NewMetrics()), WithLoggerOpts(log), WithSlowThresholdOpts(0))
+	_ = impl.StoreEX(ctx, "slow", "value", time.Minute)
+
+	select {
+	case entry := <-logged:
+		if entry.TraceID != trace.GetTid(ctx) {
+			t.Errorf("expected trace id %s, got %s", trace.GetTid(ctx), entry.TraceID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow operation is not logged")
+	}
+
+	// 没有超过阈值的操作不会记录日志
+	quiet := NewCache(memory.NewMemoryCache(memory.Config{}), WithMetricsOpts(NewMetrics()), WithLoggerOpts(log), WithSlowThresholdOpts(time.Hour))
+	_ = quiet.Store(ctx, "quiet", "value")
+	select {
+	case <-logged:
+		t.Error("fast operation is logged")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.record("quoted\"name", "Load", "", "", time.Millisecond, outcomeHit)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type %s", recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(recorder.Body.String(), `cache_operations_total{cache="quoted\"name",operation="Load",result="hit"} 1`) {
+		t.Errorf("unexpected body:\n%s", recorder.Body.String())
+	}
+	if !strings.Contains(recorder.Body.String(), `cache_operation_duration_seconds_bucket{cache="quoted\"name",operation="Load",le="0.001"} 1`) {
+		t.Errorf("unexpected body:\n%s", recorder.Body.String())
+	}
+}
+
+func TestInstrumentedLocker(t *testing.T) {
+	ctx, metrics := context.Background(), NewMetrics()
+	impl := NewCache(memory.NewMemoryCache(memory.Config{}), WithNameOpts("locks"), WithMetricsOpts(metrics))
+
+	locker, ok := impl.(cache.Locker)
+	if !ok {
+		t.Fatal("instrumented memory cache does not implement cache.Locker")
+	}
+	if _, ok = impl.(cache.Batcher); !ok {
+		t.Fatal("instrumented memory cache does not implement cache.Batcher")
+	}
+
+	_, token, _ := locker.TryLock(ctx, "lock:1", time.Minute)
+	_, _, _ = locker.TryLock(ctx, "lock:1", time.Minute)
+	_ = locker.Unlock(ctx, "lock:1", token)
+
+	buffer := &bytes.Buffer{}
+	_ = metrics.Write(buffer)
+	for _, line := range []string{
+		`cache_operations_total{cache="locks",operation="TryLock",result="hit"} 1`,
+		`cache_operations_total{cache="locks",operation="TryLock",result="miss"} 1`,
+		`cache_operations_total{cache="locks",operation="Unlock",result="ok"} 1`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("metrics do not contain %s:\n%s", line, buffer.String())
+		}
+	}
+}
+
+func TestCardinalityEstimate(t *testing.T) {
+	for _, count := range []int{1, 10, 100, 1000, 100000} {
+		sketch := &cardinalitySketch{}
+		for i := 0; i < count; i++ {
+			// 重复添加的key不会增加基数
+			sketch.add(hashKey("user:" + strconv.Itoa(i)))
+			sketch.add(hashKey("user:" + strconv.Itoa(i)))
+		}
+
+		// 标准误差约为3.25%，允许三倍的误差
+		if estimate := float64(sketch.estimate()); math.Abs(estimate-float64(count)) > float64(count)*0.1 {
+			t.Errorf("expected about %d distinct keys, got %v", count, estimate)
+		}
+	}
+}
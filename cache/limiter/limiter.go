@@ -0,0 +1,147 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Algorithm string
+
+const (
+	// AlgorithmFixedWindow 固定窗口，每个窗口内最多允许Limit个请求，窗口在第一个请求到达时开始
+	AlgorithmFixedWindow Algorithm = "fixed_window"
+
+	// AlgorithmSlidingLog 滑动日志，记录每个请求的时间，任意Window时长内最多允许Limit个请求
+	AlgorithmSlidingLog Algorithm = "sliding_log"
+
+	// AlgorithmSlidingWindow 滑动窗口计数，按时间比例加权上一个窗口的计数，近似任意Window时长内最多允许Limit个请求
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+
+	// AlgorithmTokenBucket 令牌桶，桶容量为Limit，每经过Window补满整个桶
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+)
+
+var (
+	// ErrInvalidRule is returned when the rule has an unknown algorithm, a non-positive limit or
+	// a window shorter than a millisecond.
+	ErrInvalidRule = errors.New("invalid rate limit rule")
+
+	// ErrExceedsLimit is returned when more quota is requested at once than the limit of the rule,
+	// such a request can never be allowed.
+	ErrExceedsLimit = errors.New("requested quota exceeds the limit")
+)
+
+type Rule struct {
+	Algorithm Algorithm     `json:"algorithm" yaml:"algorithm" xml:"algorithm"`
+	Limit     int64         `json:"limit" yaml:"limit" xml:"limit"`
+	Window    time.Duration `json:"window" yaml:"window" xml:"window"`
+}
+
+func (r Rule) Validate() error {
+	switch r.Algorithm {
+	case AlgorithmFixedWindow, AlgorithmSlidingLog, AlgorithmSlidingWindow, AlgorithmTokenBucket:
+	default:
+		return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidRule, r.Algorithm)
+	}
+	if r.Limit <= 0 {
+		return fmt.Errorf("%w: limit must be positive", ErrInvalidRule)
+	}
+	if r.Window < time.Millisecond {
+		return fmt.Errorf("%w: window must be at least one millisecond", ErrInvalidRule)
+	}
+
+	return nil
+}
+
+type Result struct {
+	// Allowed 请求是否被允许，不被允许的请求不会消耗配额
+	Allowed bool `json:"allowed"`
+
+	// Remaining 本次请求后剩余的配额
+	Remaining int64 `json:"remaining"`
+
+	// RetryAfter 请求不被允许时，需要等待多久才可能被允许；请求被允许时为0
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+// Storage keeps the state of rate limits, the memory and redis cache drivers implement it so that
+// the limits can be shared within a process or across processes.
+type Storage interface {
+	// Take 原子地检查key对应的配额，如果剩余配额不少于n，则消耗n个配额
+	Take(ctx context.Context, key string, rule Rule, n int64) (result Result, err error)
+}
+
+// Limiter applies one rule to every key.
+//
+// example:
+//
+//	rl, _ := limiter.NewLimiter(memory.NewMemoryLimiterStorage(memory.Config{}), limiter.Rule{
+//		Algorithm: limiter.AlgorithmSlidingWindow,
+//		Limit:     100,
+//		Window:    time.Minute,
+//	})
+//	result, err := rl.Allow(ctx, "user:1")
+type Limiter struct {
+	storage Storage
+	rule    Rule
+}
+
+func NewLimiter(storage Storage, rule Rule) (limiter *Limiter, err error) {
+	if validateErr := rule.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+
+	return &Limiter{storage: storage, rule: rule}, nil
+}
+
+func (l *Limiter) Rule() Rule {
+	return l.rule
+}
+
+// buildKey separates the states of algorithms, so that changing the algorithm of a rule does
+// not read the state written by another algorithm.
+func (l *Limiter) buildKey(key string) string {
+	return "ratelimit:" + string(l.rule.Algorithm) + ":" + key
+}
+
+func (l *Limiter) Allow(ctx context.Context, key string) (result Result, err error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *Limiter) AllowN(ctx context.Context, key string, n int64) (result Result, err error) {
+	if n <= 0 {
+		return Result{}, fmt.Errorf("allow %d requests of key %s: n must be positive", n, key)
+	}
+	if n > l.rule.Limit {
+		return Result{}, fmt.Errorf("allow %d requests of key %s: %w", n, key, ErrExceedsLimit)
+	}
+
+	return l.storage.Take(ctx, l.buildKey(key), l.rule, n)
+}
+
+func (l *Limiter) Wait(ctx context.Context, key string) (err error) {
+	return l.WaitN(ctx, key, 1)
+}
+
+// WaitN blocks until n requests of key are allowed or ctx is done.
+func (l *Limiter) WaitN(ctx context.Context, key string, n int64) (err error) {
+	for {
+		result, allowErr := l.AllowN(ctx, key, n)
+		if allowErr != nil {
+			return allowErr
+		}
+		if result.Allowed {
+			return nil
+		}
+
+		timer := time.NewTimer(result.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("wait %d requests of key %s: %w", n, key, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRule(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  Rule
+		valid bool
+	}{
+		{name: "Valid", rule: Rule{Algorithm: AlgorithmTokenBucket, Limit: 1, Window: time.Second}, valid: true},
+		{name: "UnknownAlgorithm", rule: Rule{Algorithm: "leaky_bucket", Limit: 1, Window: time.Second}},
+		{name: "ZeroLimit", rule: Rule{Algorithm: AlgorithmFixedWindow, Window: time.Second}},
+		{name: "ShortWindow", rule: Rule{Algorithm: AlgorithmSlidingLog, Limit: 1, Window: time.Microsecond}},
+	}
+
+	for _, c := range cases {
+		if err := c.rule.Validate(); (err == nil) != c.valid || (err != nil && !errors.Is(err, ErrInvalidRule)) {
+			t.Errorf("Rule:%s case failed: validate err %v", c.name, err)
+		}
+		if _, err := NewLimiter(nil, c.rule); (err == nil) != c.valid {
+			t.Errorf("Rule:%s case failed: new limiter err %v", c.name, err)
+		}
+	}
+}
+
+func TestLimiterArguments(t *testing.T) {
+	rl, _ := NewLimiter(nil, Rule{Algorithm: AlgorithmFixedWindow, Limit: 2, Window: time.Second})
+	if _, err := rl.AllowN(context.Background(), "key", 3); !errors.Is(err, ErrExceedsLimit) {
+		t.Errorf("Limiter:ExceedsLimit case failed: err %v", err)
+	}
+	if _, err := rl.AllowN(context.Background(), "key", 0); err == nil {
+		t.Errorf("Limiter:NonPositive case failed: no error returned")
+	}
+	if key := rl.buildKey("key"); key != "ratelimit:fixed_window:key" {
+		t.Errorf("Limiter:BuildKey case failed: key %s", key)
+	}
+}
@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a loader when the value does not exist in the source, the result is
+// cached for the negative ttl if negative caching is enabled, so that the source is not queried
+// again and again for a missing value.
+var ErrNotFound = errors.New("value is not found")
+
+// loadedValue is the stored form of a value written by Loader, it records how long the loader
+// took and when the value expires, which are needed by the early refresh.
+type loadedValue struct {
+	Value     string `json:"value,omitempty"`
+	Missing   bool   `json:"missing,omitempty"`
+	Delta     int64  `json:"delta,omitempty"`
+	ExpiredAt int64  `json:"expired_at,omitempty"`
+}
+
+// shouldRefresh implements the probabilistic early expiration of XFetch, the value is recomputed
+// before it expires with a probability growing when the expiration is coming, and a value which
+// takes longer to compute is recomputed earlier.
+func (lv loadedValue) shouldRefresh(beta float64) bool {
+	if beta <= 0 || lv.ExpiredAt == 0 {
+		return false
+	}
+
+	// 1-rand.Float64()的取值范围为(0,1]，避免对0取对数
+	gap := -float64(lv.Delta) * beta * math.Log(1-rand.Float64())
+	return float64(time.Now().UnixMilli())+gap >= float64(lv.ExpiredAt)
+}
+
+type flight struct {
+	done  chan struct{}
+	value string
+	err   error
+}
+
+// flightGroup collapses the concurrent calls with the same key into one call, the callers
+// arriving while the call is running share its result.
+type flightGroup struct {
+	mtx     sync.Mutex
+	flights map[string]*flight
+}
+
+// do runs fn once for the concurrent calls with the same key. The call runs with the values but
+// not the cancellation of the ctx of the first caller, so that a caller giving up does not fail
+// the others, and every caller returns the error of its own ctx when it is done before the call.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (string, error)) (value string, err error) {
+	g.mtx.Lock()
+	current, exist := g.flights[key]
+	if !exist {
+		current = &flight{done: make(chan struct{})}
+		g.flights[key] = current
+		go g.run(context.WithoutCancel(ctx), key, current, fn)
+	}
+	g.mtx.Unlock()
+
+	select {
+	case <-current.done:
+		return current.value, current.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, current *flight, fn func(ctx context.Context) (string, error)) {
+	defer func() {
+		// 加载在后台协程中运行，panic无法被调用方捕获，转换为错误返回给所有调用方
+		if recovered := recover(); recovered != nil {
+			current.value, current.err = "", fmt.Errorf("loader of %s panicked: %v", key, recovered)
+		}
+
+		g.mtx.Lock()
+		delete(g.flights, key)
+		g.mtx.Unlock()
+		close(current.done)
+	}()
+
+	current.value, current.err = fn(ctx)
+}
+
+type LoaderOption func(*Loader)
+
+// WithLockerOpts makes the loader hold a lock of the key while loading, so that only one process
+// loads a missing key. A process waits for the lock at most ttl, then it loads the key itself.
+func WithLockerOpts(locker Locker, ttl time.Duration) LoaderOption {
+	return func(l *Loader) {
+		l.locker, l.lockTTL = locker, ttl
+	}
+}
+
+// WithNegativeCacheOpts caches the ErrNotFound returned by the loader for ttl.
+func WithNegativeCacheOpts(ttl time.Duration) LoaderOption {
+	return func(l *Loader) {
+		l.negativeTTL = ttl
+	}
+}
+
+// WithEarlyRefreshOpts enables the XFetch early refresh, beta is usually 1, a greater beta makes
+// the refresh earlier.
+func WithEarlyRefreshOpts(beta float64) LoaderOption {
+	return func(l *Loader) {
+		l.beta = beta
+	}
+}
+
+// Loader reads values through a cache, a missing value is loaded by the loader and stored.
+// Concurrent misses of the same key in one process share one loader call. The values are stored
+// with the metadata of the loader, so the keys written by Loader should only be read by Loader.
+//
+// example:
+//
+//	loader := cache.NewLoader(impl, cache.WithNegativeCacheOpts(time.Second*10))
+//	name, err := loader.GetOrLoad(ctx, "user:1:name", time.Minute, func() (string, error) {
+//		return queryUserName(1)
+//	})
+type Loader struct {
+	cache       Cache
+	group       flightGroup
+	locker      Locker
+	lockTTL     time.Duration
+	negativeTTL time.Duration
+	beta        float64
+}
+
+func NewLoader(cache Cache, options ...LoaderOption) *Loader {
+	l := &Loader{cache: cache, group: flightGroup{flights: map[string]*flight{}}}
+	for _, option := range options {
+		if option != nil {
+			option(l)
+		}
+	}
+
+	return l
+}
+
+// GetOrLoad returns the cached value of key, or loads, stores it with ttl and returns it, the
+// value never expires if ttl is not positive. If the loader returns ErrNotFound, GetOrLoad returns
+// ErrNotFound. The loading is not cancelled when ctx is done, it goes on for the other callers
+// waiting for the same key and stores the value, while this call returns the error of ctx.
+func (l *Loader) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader func() (string, error)) (value string, err error) {
+	cached, exist, readErr := l.read(ctx, key)
+	if readErr != nil {
+		return "", readErr
+	}
+	if exist && !cached.shouldRefresh(l.beta) {
+		return cached.result()
+	}
+
+	value, err = l.group.do(ctx, key, func(ctx context.Context) (string, error) {
+		if exist {
+			return l.load(ctx, key, ttl, &cached, loader)
+		}
+		return l.load(ctx, key, ttl, nil, loader)
+	})
+	if err != nil && exist && !errors.Is(err, ErrNotFound) {
+		// 提前刷新失败时，缓存的值仍然有效
+		return cached.result()
+	}
+
+	return value, err
+}
+
+// GetOrLoadJson is the json version of GetOrLoad, the value returned by loader is marshaled to
+// json to store, and the cached value is unmarshalled into receiverPtr.
+func (l *Loader) GetOrLoadJson(ctx context.Context, key string, ttl time.Duration, receiverPtr any, loader func() (any, error)) (err error) {
+	value, loadErr := l.GetOrLoad(ctx, key, ttl, func() (string, error) {
+		loaded, loaderErr := loader()
+		if loaderErr != nil {
+			return "", loaderErr
+		}
+
+		payload, marshalErr := json.Marshal(loaded)
+		if marshalErr != nil {
+			return "", fmt.Errorf("marshal loaded value of %s: %w", key, marshalErr)
+		}
+		return string(payload), nil
+	})
+	if loadErr != nil {
+		return loadErr
+	}
+
+	if unmarshalErr := json.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+		return fmt.Errorf("unmarshal loaded value of %s: %w", key, unmarshalErr)
+	}
+	return nil
+}
+
+func (lv loadedValue) result() (value string, err error) {
+	if lv.Missing {
+		return "", ErrNotFound
+	}
+
+	return lv.Value, nil
+}
+
+func (l *Loader) read(ctx context.Context, key string) (cached loadedValue, exist bool, err error) {
+	exist, loadErr := l.cache.LoadJson(ctx, key, &cached)
+	if loadErr != nil {
+		return loadedValue{}, false, loadErr
+	}
+
+	return cached, exist, nil
+}
+
+// load runs in the flight of key, previous is the cached value being refreshed early. The cache
+// is read again after the lock is acquired, since another process may have loaded the key while
+// this process was waiting.
+func (l *Loader) load(ctx context.Context, key string, ttl time.Duration, previous *loadedValue, loader func() (string, error)) (value string, err error) {
+	if l.locker != nil {
+		lockCtx, cancel := context.WithTimeout(ctx, l.lockTTL)
+		token, lockErr := l.locker.Lock(lockCtx, "loader:"+key, l.lockTTL)
+		cancel()
+		if lockErr == nil {
+			defer func() { _ = l.locker.Unlock(context.Background(), "loader:"+key, token) }()
+
+			cached, exist, readErr := l.read(ctx, key)
+			if readErr == nil && exist && (previous == nil || cached.ExpiredAt != previous.ExpiredAt) {
+				return cached.result()
+			}
+		}
+	}
+
+	startedAt := time.Now()
+	value, err = loader()
+	stored := loadedValue{Value: value, Delta: time.Since(startedAt).Milliseconds()}
+	switch {
+	case errors.Is(err, ErrNotFound) && l.negativeTTL > 0:
+		stored, ttl = loadedValue{Missing: true}, l.negativeTTL
+	case err != nil:
+		return "", err
+	}
+	// 不同驱动对非正数过期时间的处理不同，统一视为永不过期
+	var storeErr error
+	if ttl > 0 {
+		stored.ExpiredAt = time.Now().Add(ttl).UnixMilli()
+		storeErr = l.cache.StoreJsonEX(ctx, key, &stored, ttl)
+	} else {
+		storeErr = l.cache.StoreJson(ctx, key, &stored)
+	}
+	if storeErr != nil {
+		return "", storeErr
+	}
+	return stored.result()
+}
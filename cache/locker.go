@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLockNotHeld is returned when unlocking or extending a lock which is expired or held by
+	// another owner.
+	ErrLockNotHeld = errors.New("lock is not held by the token")
+
+	// ErrInvalidLockTTL is returned when locking or extending a lock with a ttl shorter than
+	// MinLockTTL, the drivers reject it instead of creating a lock expired at once or never.
+	ErrInvalidLockTTL = errors.New("ttl of the lock is too short")
+
+	// ErrInvalidLeaseTTL is returned when acquiring a lease with a ttl shorter than MinLockTTL.
+	ErrInvalidLeaseTTL = errors.New("ttl of the lease is too short")
+)
+
+// MinLockTTL is the shortest ttl of a lock, which is the precision of the lock expiration.
+const MinLockTTL = time.Millisecond
+
+type Locker interface {
+	// TryLock 尝试获取一次key对应的锁，锁在ttl后自动释放；如果锁已被持有，acquired为false
+	// ttl短于MinLockTTL时返回ErrInvalidLockTTL，Lock和Extend同样如此
+	TryLock(ctx context.Context, key string, ttl time.Duration) (acquired bool, token string, err error)
+
+	// Lock 获取key对应的锁，锁在ttl后自动释放；如果锁已被持有，则阻塞直到获取成功或ctx结束
+	Lock(ctx context.Context, key string, ttl time.Duration) (token string, err error)
+
+	// Unlock 使用获取锁时得到的token释放锁；如果锁已过期或被其他token持有，返回ErrLockNotHeld
+	Unlock(ctx context.Context, key string, token string) (err error)
+
+	// Extend 使用获取锁时得到的token将锁的过期时间重置为ttl；如果锁已过期或被其他token持有，返回ErrLockNotHeld
+	Extend(ctx context.Context, key string, token string, ttl time.Duration) (err error)
+}
+
+// Lease is an acquired lock which is renewed in background until it is released, so that the
+// owner does not need to estimate how long the critical section takes.
+//
+// example:
+//
+//	lease, err := cache.AcquireLease(ctx, locker, "lock:order:1", time.Second*10)
+//	if err != nil {
+//		return err
+//	}
+//	defer lease.Release(context.Background())
+type Lease struct {
+	locker Locker
+	key    string
+	token  string
+	ttl    time.Duration
+	lost   chan struct{}
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// AcquireLease blocks until the lock of key is acquired or ctx is done, then renews the lock
+// every third of ttl. If the lock can not be renewed before it expires, the channel returned
+// by Lost is closed. A ttl shorter than a millisecond is rejected with ErrInvalidLeaseTTL.
+func AcquireLease(ctx context.Context, locker Locker, key string, ttl time.Duration) (lease *Lease, err error) {
+	if ttl < MinLockTTL {
+		return nil, ErrInvalidLeaseTTL
+	}
+
+	token, lockErr := locker.Lock(ctx, key, ttl)
+	if lockErr != nil {
+		return nil, lockErr
+	}
+
+	lease = &Lease{
+		locker: locker,
+		key:    key,
+		token:  token,
+		ttl:    ttl,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+	go lease.renew()
+
+	return lease, nil
+}
+
+func (l *Lease) renew() {
+	interval := l.ttl / 3
+	ticker, renewedAt := time.NewTicker(interval), time.Now()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			extendErr := l.locker.Extend(ctx, l.key, l.token, l.ttl)
+			cancel()
+
+			if extendErr == nil {
+				renewedAt = time.Now()
+				continue
+			}
+			if errors.Is(extendErr, ErrLockNotHeld) || time.Since(renewedAt) >= l.ttl {
+				// 锁已经被其他人持有，或者在过期前都没有续期成功
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// Token returns the token of the lock, it can be used as the owner identity of the lock.
+func (l *Lease) Token() string {
+	return l.token
+}
+
+// Lost returns a channel which is closed when the lock is lost before it is released.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release stops the renewal and unlocks the lock.
+func (l *Lease) Release(ctx context.Context) (err error) {
+	l.once.Do(func() { close(l.stop) })
+	return l.locker.Unlock(ctx, l.key, l.token)
+}
@@ -3,14 +3,18 @@ package memory
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/alioth-center/infrastructure/cache"
 	"github.com/alioth-center/infrastructure/utils/values"
+	"github.com/google/uuid"
 )
 
 func getEntryWithType[T any](mp *accessor, wantType Type, key string) (result T, exist bool, expireTime time.Duration) {
@@ -35,14 +39,32 @@ func getEntryWithType[T any](mp *accessor, wantType Type, key string) (result T,
 }
 
 type accessor struct {
-	mtx sync.RWMutex
-	db  map[string]entry
-	ec  chan struct{}
+	mtx       sync.RWMutex
+	db        map[string]entry
+	ec        chan struct{}
+	limit     limitation
+	used      int64
+	evictions evictionCounter
+	codec     cache.Codec
+	subs      subscriptions
+
+	// listCond wakes up the blocking pops when values are pushed into any list.
+	listMtx  sync.Mutex
+	listCond *sync.Cond
+
+	// scanIndex is the keyspace sorted by the scan positions, see scanIndexOf.
+	scanMtx   sync.Mutex
+	scanIndex []scanCandidate
+}
+
+type scanCandidate struct {
+	key      string
+	position uint64
 }
 
 func (ca *accessor) delete(key string) {
 	ca.mtx.Lock()
-	delete(ca.db, key)
+	ca.remove(key)
 	ca.mtx.Unlock()
 }
 
@@ -55,7 +77,7 @@ func (ca *accessor) get(key string) (result entry, exist bool) {
 
 func (ca *accessor) create(key string, value entry) {
 	ca.mtx.Lock()
-	ca.db[key] = value
+	ca.put(key, value)
 	ca.mtx.Unlock()
 }
 
@@ -66,7 +88,7 @@ func (ca *accessor) update(key string, value entry) {
 	}
 
 	ca.mtx.Lock()
-	ca.db[key] = value
+	ca.put(key, value)
 	ca.mtx.Unlock()
 }
 
@@ -76,9 +98,26 @@ func (ca *accessor) getEntry(key string) (result entry, exist bool) {
 		return nil, false
 	}
 	if result.IsExpired() {
-		ca.delete(key)
+		ca.mtx.Lock()
+		ca.removeExpired(key)
+		ca.mtx.Unlock()
+		return nil, false
+	}
+	result.Touch()
+	return result, true
+}
+
+// lookup returns the entry of key and removes it if it is expired, the write lock must be held.
+func (ca *accessor) lookup(key string) (result entry, exist bool) {
+	result, exist = ca.db[key]
+	if !exist {
+		return nil, false
+	}
+	if result.IsExpired() {
+		ca.removeExpired(key)
 		return nil, false
 	}
+
 	return result, true
 }
 
@@ -98,6 +137,20 @@ func (ca *accessor) getHashEntry(key string) (result *hashEntry, exist bool, exp
 	return getEntryWithType[*hashEntry](ca, Hash, key)
 }
 
+func (ca *accessor) getZSetEntry(key string) (result *zsetEntry, exist bool, expireTime time.Duration) {
+	return getEntryWithType[*zsetEntry](ca, ZSet, key)
+}
+
+func (ca *accessor) getListEntry(key string) (result *listEntry, exist bool, expireTime time.Duration) {
+	return getEntryWithType[*listEntry](ca, List, key)
+}
+
+func (ca *accessor) notifyPushed() {
+	ca.listMtx.Lock()
+	ca.listCond.Broadcast()
+	ca.listMtx.Unlock()
+}
+
 func (ca *accessor) copySenderToReceiver(senderPtr, receiverPtr any) error {
 	rv := reflect.ValueOf(receiverPtr)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
@@ -203,6 +256,119 @@ func (ca *accessor) ExpireImmediately(_ context.Context, key string) (result cac
 	return cache.CounterResultEnumSuccess
 }
 
+// lookupCounter returns the counter of key, matched is false if the key holds another type of
+// value, the write lock must be held.
+func (ca *accessor) lookupCounter(key string) (counter *counterEntry, exist bool, matched bool) {
+	existEntry, exist := ca.lookup(key)
+	if !exist {
+		return nil, false, true
+	}
+
+	counter, matched = existEntry.(*counterEntry)
+	return counter, true, matched
+}
+
+func (ca *accessor) Decrease(_ context.Context, key string, delta uint64) (value int64, result cache.CounterResultEnum) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	counter, exist, matched := ca.lookupCounter(key)
+	if !matched {
+		// 计数器类型不匹配，返回错误
+		return 0, cache.CounterResultEnumFailed
+	}
+	if !exist {
+		// 如果不存在，创建一个新的计数器
+		counter = newCounterEntry(0).(*counterEntry)
+	}
+
+	counter.Sub(int64(delta))
+	ca.put(key, counter)
+	return counter.Value(), cache.CounterResultEnumSuccess
+}
+
+func (ca *accessor) Get(_ context.Context, key string) (value int64, result cache.CounterResultEnum) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	counter, exist, matched := ca.lookupCounter(key)
+	if !matched {
+		// 计数器类型不匹配，返回错误
+		return 0, cache.CounterResultEnumFailed
+	}
+	if !exist {
+		return 0, cache.CounterResultEnumNotEffective
+	}
+
+	counter.Touch()
+	return counter.Value(), cache.CounterResultEnumSuccess
+}
+
+func (ca *accessor) Set(_ context.Context, key string, value int64) (newValue int64, result cache.CounterResultEnum) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	counter, exist, matched := ca.lookupCounter(key)
+	if !matched {
+		// 计数器类型不匹配，返回错误
+		return 0, cache.CounterResultEnumFailed
+	}
+	if !exist {
+		counter = newCounterEntry(0).(*counterEntry)
+	}
+
+	// 直接修改原有的计数器，保留过期时间
+	counter.Set(value)
+	ca.put(key, counter)
+	return value, cache.CounterResultEnumSuccess
+}
+
+func (ca *accessor) IncreaseWithLimit(_ context.Context, key string, delta uint64, limit int64) (value int64, result cache.CounterResultEnum) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	counter, exist, matched := ca.lookupCounter(key)
+	if !matched {
+		// 计数器类型不匹配，返回错误
+		return 0, cache.CounterResultEnumFailed
+	}
+	if !exist {
+		counter = newCounterEntry(0).(*counterEntry)
+	}
+
+	if current := counter.Value(); current+int64(delta) > limit {
+		// 超过上限，不增加计数器
+		return current, cache.CounterResultEnumNotEffective
+	}
+
+	counter.Add(int64(delta))
+	ca.put(key, counter)
+	return counter.Value(), cache.CounterResultEnumSuccess
+}
+
+func (ca *accessor) CompareAndSwap(_ context.Context, key string, old, new int64) (value int64, result cache.CounterResultEnum) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	counter, exist, matched := ca.lookupCounter(key)
+	if !matched {
+		// 计数器类型不匹配，返回错误
+		return 0, cache.CounterResultEnumFailed
+	}
+	if !exist {
+		counter = newCounterEntry(0).(*counterEntry)
+	}
+
+	if current := counter.Value(); current != old {
+		// 当前值与期望值不一致，不修改计数器
+		return current, cache.CounterResultEnumNotEffective
+	}
+
+	counter.Set(new)
+	ca.put(key, counter)
+	return new, cache.CounterResultEnumSuccess
+}
+
 func (ca *accessor) ExistKey(_ context.Context, key string) (exist bool, err error) {
 	_, ext := ca.getEntry(key)
 	if !ext {
@@ -221,7 +387,7 @@ func (ca *accessor) GetExpiredTime(_ context.Context, key string) (exist bool, e
 	}
 	if entry.IsExpired() {
 		ca.mtx.Lock()
-		delete(ca.db, key)
+		ca.removeExpired(key)
 		ca.mtx.Unlock()
 		return false, time.Time{}, nil
 	}
@@ -260,7 +426,7 @@ func (ca *accessor) LoadJsonWithEX(_ context.Context, key string, receiverPtr an
 		return false, 0, nil
 	}
 
-	unmarshalErr := json.Unmarshal([]byte(value), receiverPtr)
+	unmarshalErr := ca.codec.Unmarshal([]byte(value), receiverPtr)
 	if unmarshalErr != nil {
 		return true, exTime, fmt.Errorf("load json failed for key %s: %w", key, unmarshalErr)
 	}
@@ -307,7 +473,7 @@ func (ca *accessor) StoreEX(_ context.Context, key string, value string, expirat
 }
 
 func (ca *accessor) StoreJson(_ context.Context, key string, senderPtr any) (err error) {
-	marshaled, marshalErr := json.Marshal(senderPtr)
+	marshaled, marshalErr := ca.codec.Marshal(senderPtr)
 	if marshalErr != nil {
 		return fmt.Errorf("marshal json failed for key %s: %w", key, marshalErr)
 	}
@@ -316,7 +482,7 @@ func (ca *accessor) StoreJson(_ context.Context, key string, senderPtr any) (err
 }
 
 func (ca *accessor) StoreJsonEX(_ context.Context, key string, senderPtr any, expiration time.Duration) (err error) {
-	marshaled, marshalErr := json.Marshal(senderPtr)
+	marshaled, marshalErr := ca.codec.Marshal(senderPtr)
 	if marshalErr != nil {
 		return fmt.Errorf("marshal json failed for key %s: %w", key, marshalErr)
 	}
@@ -599,7 +765,7 @@ func (ca *accessor) HGetJson(_ context.Context, key string, field string, receiv
 		return true, getValueErr
 	}
 
-	if unmarshalErr := json.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+	if unmarshalErr := ca.codec.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
 		return true, fmt.Errorf("unmarshal hash key %s field %s error: %w", key, field, unmarshalErr)
 	}
 
@@ -702,7 +868,9 @@ func (ca *accessor) HRemoveValues(_ context.Context, key string, fields ...strin
 	}
 
 	if entry.IsExpired() {
-		_ = ca.Delete(context.Background(), key)
+		ca.mtx.Lock()
+		ca.removeExpired(key)
+		ca.mtx.Unlock()
 		return nil
 	}
 
@@ -715,13 +883,550 @@ func (ca *accessor) HRemoveValues(_ context.Context, key string, fields ...strin
 }
 
 func (ca *accessor) Expire(_ context.Context, key string, expire time.Duration) (err error) {
-	entry, exist := ca.getEntry(key)
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	// 只修改过期时间，不经过put，与redis一致不产生set事件
+	if existEntry, exist := ca.lookup(key); exist {
+		existEntry.SetExpireTime(expire)
+		existEntry.Touch()
+	}
+
+	return nil
+}
+
+func (ca *accessor) Persist(_ context.Context, key string) (err error) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	if existEntry, exist := ca.lookup(key); exist {
+		existEntry.SetExpireTime(-1)
+	}
+
+	return nil
+}
+
+func (ca *accessor) Type(_ context.Context, key string) (exist bool, keyType string, err error) {
+	resultEntry, exist := ca.getEntry(key)
 	if !exist {
-		return nil
+		return false, "", nil
 	}
 
-	entry.SetExpireTime(expire)
-	ca.update(key, entry)
+	return true, string(resultEntry.Type()), nil
+}
+
+// scanPositionOf returns the position of key in the scan order, keys are scanned in the order of
+// their hashes so that the cursor stays valid when other keys are added or removed.
+func scanPositionOf(key string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	return hash.Sum64()
+}
+
+func (ca *accessor) Scan(_ context.Context, cursor uint64, pattern string, count int64) (keys []string, nextCursor uint64, err error) {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	index := ca.scanIndexOf(cursor)
+	start := sort.Search(len(index), func(i int) bool { return index[i].position >= cursor })
+
+	ca.mtx.RLock()
+	defer ca.mtx.RUnlock()
+
+	keys = make([]string, 0, count)
+	for i := start; i < len(index); i++ {
+		c := index[i]
+		if int64(i-start) >= count && c.position != index[i-1].position {
+			// 哈希值相同的key必须在同一次调用中返回，否则游标无法区分它们
+			return keys, c.position, nil
+		}
+
+		// 快照之后被删除或过期的key不再返回
+		if value, exist := ca.db[c.key]; exist && !value.IsExpired() && matchPattern(pattern, c.key) {
+			keys = append(keys, c.key)
+		}
+	}
+
+	return keys, 0, nil
+}
+
+// scanIndexOf returns the keys sorted by their scan positions. The index is rebuilt when a scan
+// starts with cursor 0, and the following pages of the scan reuse it, so that the keyspace is
+// sorted once per scan instead of once per page. The keys added after the index is built are not
+// returned by the scan, which is allowed since only the keys existing during the whole scan are
+// guaranteed to be returned.
+func (ca *accessor) scanIndexOf(cursor uint64) []scanCandidate {
+	ca.scanMtx.Lock()
+	defer ca.scanMtx.Unlock()
+
+	if cursor != 0 && ca.scanIndex != nil {
+		return ca.scanIndex
+	}
+
+	ca.mtx.RLock()
+	index := make([]scanCandidate, 0, len(ca.db))
+	for key := range ca.db {
+		index = append(index, scanCandidate{key: key, position: scanPositionOf(key)})
+	}
+	ca.mtx.RUnlock()
+
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].position != index[j].position {
+			return index[i].position < index[j].position
+		}
+		return index[i].key < index[j].key
+	})
+
+	// 索引构建后不再修改，只会被整体替换，所以可以在锁外读取
+	ca.scanIndex = index
+	return index
+}
+
+func (ca *accessor) DeleteByPattern(_ context.Context, pattern string) (deleted int64, err error) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	for key, value := range ca.db {
+		if !matchPattern(pattern, key) {
+			continue
+		}
+		if !value.IsExpired() {
+			deleted++
+		}
+		ca.remove(key)
+	}
+
+	return deleted, nil
+}
+
+func (ca *accessor) ZAdd(_ context.Context, key string, members ...cache.ZMember) (added int64, err error) {
+	if len(members) == 0 {
+		// 如果没有元素，直接返回
+		return 0, nil
+	}
+
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，创建后添加
+		resultEntry = newZSetEntry()
+		added = resultEntry.AddMembers(members...)
+		ca.create(key, resultEntry)
+		return added, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	added = resultEntry.AddMembers(members...)
+	ca.update(key, resultEntry)
+	return added, nil
+}
+
+func (ca *accessor) ZIncrBy(_ context.Context, key string, member string, increment float64) (score float64, err error) {
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，创建后添加
+		resultEntry = newZSetEntry()
+		score = resultEntry.IncrBy(member, increment)
+		ca.create(key, resultEntry)
+		return score, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	score = resultEntry.IncrBy(member, increment)
+	ca.update(key, resultEntry)
+	return score, nil
+}
+
+func (ca *accessor) ZScore(_ context.Context, key string, member string) (exist bool, score float64, err error) {
+	resultEntry, isExist, _ := ca.getZSetEntry(key)
+	if !isExist {
+		// 如果不存在，直接返回
+		return false, 0, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return false, 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	score, exist = resultEntry.Score(member)
+	return exist, score, nil
+}
+
+func (ca *accessor) ZRank(_ context.Context, key string, member string) (exist bool, rank int64, err error) {
+	resultEntry, isExist, _ := ca.getZSetEntry(key)
+	if !isExist {
+		// 如果不存在，直接返回
+		return false, 0, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return false, 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	rank, exist = resultEntry.Rank(member, false)
+	return exist, rank, nil
+}
+
+func (ca *accessor) ZRevRank(_ context.Context, key string, member string) (exist bool, rank int64, err error) {
+	resultEntry, isExist, _ := ca.getZSetEntry(key)
+	if !isExist {
+		// 如果不存在，直接返回
+		return false, 0, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return false, 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	rank, exist = resultEntry.Rank(member, true)
+	return exist, rank, nil
+}
+
+func (ca *accessor) ZRange(_ context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return []cache.ZMember{}, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return []cache.ZMember{}, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	return resultEntry.Range(start, stop, false), nil
+}
+
+func (ca *accessor) ZRevRange(_ context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return []cache.ZMember{}, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return []cache.ZMember{}, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	return resultEntry.Range(start, stop, true), nil
+}
+
+func (ca *accessor) ZRangeByScore(_ context.Context, key string, min, max float64, offset, count int64) (members []cache.ZMember, err error) {
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return []cache.ZMember{}, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return []cache.ZMember{}, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	return resultEntry.RangeByScore(min, max, offset, count), nil
+}
+
+func (ca *accessor) ZRem(_ context.Context, key string, members ...string) (removed int64, err error) {
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return 0, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	removed = resultEntry.RemoveMembers(members...)
+	if resultEntry.Card() == 0 {
+		// 与redis保持一致，有序集合为空时删除key
+		ca.delete(key)
+		return removed, nil
+	}
+
+	ca.update(key, resultEntry)
+	return removed, nil
+}
+
+func (ca *accessor) ZCard(_ context.Context, key string) (count int64, err error) {
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return 0, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return 0, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	return resultEntry.Card(), nil
+}
+
+func (ca *accessor) ZPopMin(_ context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	return ca.zPop(key, count, false)
+}
+
+func (ca *accessor) ZPopMax(_ context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	return ca.zPop(key, count, true)
+}
+
+func (ca *accessor) zPop(key string, count int64, max bool) (members []cache.ZMember, err error) {
+	if count <= 0 {
+		// 如果需要的元素个数小于等于0，直接返回
+		return []cache.ZMember{}, nil
+	}
+
+	resultEntry, exist, _ := ca.getZSetEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return []cache.ZMember{}, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return []cache.ZMember{}, NewValueTypeNotMatchError(ZSet, resultEntry.Type())
+	}
+
+	members = resultEntry.Pop(count, max)
+	if resultEntry.Card() == 0 {
+		// 与redis保持一致，有序集合为空时删除key
+		ca.delete(key)
+		return members, nil
+	}
+
+	ca.update(key, resultEntry)
+	return members, nil
+}
+
+func (ca *accessor) LPush(_ context.Context, key string, values ...string) (length int64, err error) {
+	return ca.push(key, values, false)
+}
+
+func (ca *accessor) RPush(_ context.Context, key string, values ...string) (length int64, err error) {
+	return ca.push(key, values, true)
+}
+
+func (ca *accessor) push(key string, values []string, back bool) (length int64, err error) {
+	if len(values) == 0 {
+		// 如果没有元素，返回当前长度
+		return ca.LLen(context.Background(), key)
+	}
+
+	resultEntry, exist, _ := ca.getListEntry(key)
+	if exist && resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return 0, NewValueTypeNotMatchError(List, resultEntry.Type())
+	}
+	if !exist {
+		resultEntry = newListEntry()
+	}
+
+	if back {
+		length = resultEntry.PushBack(values...)
+	} else {
+		length = resultEntry.PushFront(values...)
+	}
+	if exist {
+		ca.update(key, resultEntry)
+	} else {
+		ca.create(key, resultEntry)
+	}
+
+	// 唤醒阻塞等待的弹出操作
+	ca.notifyPushed()
+	return length, nil
+}
+
+func (ca *accessor) LPop(_ context.Context, key string) (exist bool, value string, err error) {
+	return ca.pop(key, false)
+}
+
+func (ca *accessor) RPop(_ context.Context, key string) (exist bool, value string, err error) {
+	return ca.pop(key, true)
+}
+
+func (ca *accessor) pop(key string, back bool) (exist bool, value string, err error) {
+	resultEntry, isExist, _ := ca.getListEntry(key)
+	if !isExist {
+		// 如果不存在，直接返回
+		return false, "", nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return false, "", NewValueTypeNotMatchError(List, resultEntry.Type())
+	}
+
+	if back {
+		value, exist = resultEntry.PopBack()
+	} else {
+		value, exist = resultEntry.PopFront()
+	}
+	if resultEntry.Len() == 0 {
+		// 与redis保持一致，列表为空时删除key
+		ca.delete(key)
+		return exist, value, nil
+	}
+
+	ca.update(key, resultEntry)
+	return exist, value, nil
+}
+
+func (ca *accessor) LRange(_ context.Context, key string, start, stop int64) (values []string, err error) {
+	resultEntry, exist, _ := ca.getListEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return []string{}, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return []string{}, NewValueTypeNotMatchError(List, resultEntry.Type())
+	}
+
+	return resultEntry.Range(start, stop), nil
+}
+
+func (ca *accessor) LLen(_ context.Context, key string) (length int64, err error) {
+	resultEntry, exist, _ := ca.getListEntry(key)
+	if !exist {
+		// 如果不存在，直接返回
+		return 0, nil
+	}
+	if resultEntry == nil {
+		// 如果类型不匹配，返回错误
+		return 0, NewValueTypeNotMatchError(List, resultEntry.Type())
+	}
+
+	return resultEntry.Len(), nil
+}
+
+func (ca *accessor) BLPop(ctx context.Context, keys ...string) (popped bool, key string, value string, err error) {
+	if len(keys) == 0 {
+		// 如果没有key，直接返回
+		return false, "", "", nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 上下文结束时唤醒等待，使循环能够检查到上下文的状态
+	stop := context.AfterFunc(ctx, ca.notifyPushed)
+	defer stop()
+
+	ca.listMtx.Lock()
+	defer ca.listMtx.Unlock()
+	for {
+		for _, key = range keys {
+			exist, value, popErr := ca.LPop(ctx, key)
+			if popErr != nil {
+				return false, key, "", popErr
+			}
+			if exist {
+				return true, key, value, nil
+			}
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if errors.Is(ctxErr, context.DeadlineExceeded) {
+				// 超时与redis的行为保持一致，不返回错误
+				return false, "", "", nil
+			}
+
+			return false, "", "", ctxErr
+		}
+
+		ca.listCond.Wait()
+	}
+}
+
+func (ca *accessor) TryLock(_ context.Context, key string, ttl time.Duration) (acquired bool, token string, err error) {
+	if ttl < cache.MinLockTTL {
+		return false, "", fmt.Errorf("try lock key %s: %w", key, cache.ErrInvalidLockTTL)
+	}
+
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	if existEntry, exist := ca.db[key]; exist && !existEntry.IsExpired() {
+		// 锁已被持有
+		return false, "", nil
+	}
+
+	token = uuid.NewString()
+	lockEntry := newStringEntry(token)
+	lockEntry.SetExpireTime(ttl)
+	ca.put(key, lockEntry)
+	return true, token, nil
+}
+
+func (ca *accessor) Lock(ctx context.Context, key string, ttl time.Duration) (token string, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		acquired, acquiredToken, lockErr := ca.TryLock(ctx, key, ttl)
+		if lockErr != nil {
+			return "", lockErr
+		}
+		if acquired {
+			return acquiredToken, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("lock key %s: %w", key, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// lockEntryOf returns the entry of the lock if it is held by the token, the write lock must be held.
+func (ca *accessor) lockEntryOf(key string, token string) (result entry, held bool) {
+	existEntry, exist := ca.db[key]
+	if !exist || existEntry.IsExpired() {
+		return nil, false
+	}
+
+	lockEntry, isString := existEntry.(*stringEntry)
+	if !isString || lockEntry.Value() != token {
+		return nil, false
+	}
+
+	return existEntry, true
+}
+
+func (ca *accessor) Unlock(_ context.Context, key string, token string) (err error) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	if _, held := ca.lockEntryOf(key, token); !held {
+		return fmt.Errorf("unlock key %s: %w", key, cache.ErrLockNotHeld)
+	}
+
+	ca.remove(key)
+	return nil
+}
+
+func (ca *accessor) Extend(_ context.Context, key string, token string, ttl time.Duration) (err error) {
+	if ttl < cache.MinLockTTL {
+		return fmt.Errorf("extend key %s: %w", key, cache.ErrInvalidLockTTL)
+	}
+
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	lockEntry, held := ca.lockEntryOf(key, token)
+	if !held {
+		return fmt.Errorf("extend key %s: %w", key, cache.ErrLockNotHeld)
+	}
+
+	lockEntry.SetExpireTime(ttl)
 	return nil
 }
 
@@ -763,7 +1468,7 @@ func (ca *accessor) cleanCache(interval time.Duration, maxExecutionTime time.Dur
 				// 执行删除任务
 				ca.mtx.Lock()
 				for _, k := range deleteList {
-					delete(ca.db, k)
+					ca.removeExpired(k)
 				}
 				ca.mtx.Unlock()
 
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+// batch executes the queued operations sequentially while holding the write lock of the accessor,
+// so a batch is always atomic in memory.
+type batch struct {
+	ca         *accessor
+	operations []func() error
+	executed   bool
+}
+
+func (ca *accessor) Batch(_ context.Context) cache.BatchBuilder {
+	return &batch{ca: ca}
+}
+
+func (ca *accessor) TxBatch(ctx context.Context) cache.BatchBuilder {
+	return ca.Batch(ctx)
+}
+
+func (b *batch) Load(key string) (result *cache.BatchValueResult) {
+	result = &cache.BatchValueResult{}
+	b.operations = append(b.operations, func() error {
+		existEntry, exist := b.ca.lookup(key)
+		if !exist {
+			return nil
+		}
+
+		stringEntry, isString := existEntry.(*stringEntry)
+		if !isString {
+			result.Err = NewValueTypeNotMatchError(String, existEntry.Type())
+			return result.Err
+		}
+
+		stringEntry.Touch()
+		result.Exist, result.Value = true, stringEntry.Value()
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) Store(key string, value string) (result *cache.BatchResult) {
+	return b.store(key, value, 0)
+}
+
+func (b *batch) StoreEX(key string, value string, expiration time.Duration) (result *cache.BatchResult) {
+	return b.store(key, value, expiration)
+}
+
+func (b *batch) store(key string, value string, expiration time.Duration) (result *cache.BatchResult) {
+	result = &cache.BatchResult{}
+	b.operations = append(b.operations, func() error {
+		if existEntry, exist := b.ca.lookup(key); exist && existEntry.Type() != String {
+			result.Err = NewValueTypeNotMatchError(String, existEntry.Type())
+			return result.Err
+		}
+
+		newEntry := newStringEntry(value)
+		if expiration > 0 {
+			newEntry.SetExpireTime(expiration)
+		}
+		b.ca.put(key, newEntry)
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) HGetValue(key string, field string) (result *cache.BatchValueResult) {
+	result = &cache.BatchValueResult{}
+	b.operations = append(b.operations, func() error {
+		existEntry, exist := b.ca.lookup(key)
+		if !exist {
+			return nil
+		}
+
+		hash, isHash := existEntry.(*hashEntry)
+		if !isHash {
+			result.Err = NewValueTypeNotMatchError(Hash, existEntry.Type())
+			return result.Err
+		}
+
+		hash.Touch()
+		result.Value, result.Exist = hash.GetField(field)
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) HSetValue(key string, field string, value string) (result *cache.BatchResult) {
+	result = &cache.BatchResult{}
+	b.operations = append(b.operations, func() error {
+		hash := newHashEntry()
+		if existEntry, exist := b.ca.lookup(key); exist {
+			existHash, isHash := existEntry.(*hashEntry)
+			if !isHash {
+				result.Err = NewValueTypeNotMatchError(Hash, existEntry.Type())
+				return result.Err
+			}
+			hash = existHash
+		}
+
+		hash.AddField(field, value)
+		b.ca.put(key, hash)
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) Delete(key string) (result *cache.BatchResult) {
+	result = &cache.BatchResult{}
+	b.operations = append(b.operations, func() error {
+		b.ca.remove(key)
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) Expire(key string, expire time.Duration) (result *cache.BatchResult) {
+	result = &cache.BatchResult{}
+	b.operations = append(b.operations, func() error {
+		if existEntry, exist := b.ca.lookup(key); exist {
+			existEntry.SetExpireTime(expire)
+		}
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) Exec() (err error) {
+	if b.executed {
+		return cache.ErrBatchExecuted
+	}
+	b.executed = true
+
+	b.ca.mtx.Lock()
+	defer b.ca.mtx.Unlock()
+
+	// 与redis的pipeline一致，某个操作失败不会影响后续操作的执行
+	for _, operation := range b.operations {
+		if operationErr := operation(); operationErr != nil && err == nil {
+			err = operationErr
+		}
+	}
+
+	return err
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -153,6 +155,54 @@ var (
 			CaseName:     "HRemoveValues",
 			TestFunction: HRemoveValuesFunction,
 		},
+		{
+			CaseName:     "ZAdd",
+			TestFunction: ZAddFunction,
+		},
+		{
+			CaseName:     "ZIncrBy",
+			TestFunction: ZIncrByFunction,
+		},
+		{
+			CaseName:     "ZRank",
+			TestFunction: ZRankFunction,
+		},
+		{
+			CaseName:     "ZRange",
+			TestFunction: ZRangeFunction,
+		},
+		{
+			CaseName:     "ZRem",
+			TestFunction: ZRemFunction,
+		},
+		{
+			CaseName:     "ZPop",
+			TestFunction: ZPopFunction,
+		},
+		{
+			CaseName:     "LPush",
+			TestFunction: LPushFunction,
+		},
+		{
+			CaseName:     "LPop",
+			TestFunction: LPopFunction,
+		},
+		{
+			CaseName:     "BLPop",
+			TestFunction: BLPopFunction,
+		},
+		{
+			CaseName:     "Persist",
+			TestFunction: PersistFunction,
+		},
+		{
+			CaseName:     "Type",
+			TestFunction: TypeFunction,
+		},
+		{
+			CaseName:     "Scan",
+			TestFunction: ScanFunction,
+		},
 	}
 )
 
@@ -3409,6 +3459,463 @@ func HRemoveValuesFunction(impl cache.Cache) func(t *testing.T) {
 	}
 }
 
+func ZAddFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 添加新成员和更新已有成员的样例
+		t.Run("ZAdd:AddAndUpdate", func(t *testing.T) {
+			key := "ZAdd:AddAndUpdate"
+			added, addErr := impl.ZAdd(context.Background(), key, cache.ZMember{Member: "a", Score: 1}, cache.ZMember{Member: "b", Score: 2})
+			if addErr != nil {
+				t.Errorf("ZAdd:AddAndUpdate case failed when adding members: %v", addErr.Error())
+			}
+			if added != 2 {
+				t.Errorf("ZAdd:AddAndUpdate case failed: added %d, want 2", added)
+			}
+
+			added, addErr = impl.ZAdd(context.Background(), key, cache.ZMember{Member: "a", Score: 3}, cache.ZMember{Member: "c", Score: 0})
+			if addErr != nil {
+				t.Errorf("ZAdd:AddAndUpdate case failed when updating members: %v", addErr.Error())
+			}
+			if added != 1 {
+				t.Errorf("ZAdd:AddAndUpdate case failed: added %d, want 1", added)
+			}
+
+			exist, score, scoreErr := impl.ZScore(context.Background(), key, "a")
+			if scoreErr != nil || !exist || score != 3 {
+				t.Errorf("ZAdd:AddAndUpdate case failed: exist %v, score %v, error %v", exist, score, scoreErr)
+			}
+			if count, _ := impl.ZCard(context.Background(), key); count != 3 {
+				t.Errorf("ZAdd:AddAndUpdate case failed: count %d, want 3", count)
+			}
+		})
+
+		// 获取不存在成员分数的样例
+		t.Run("ZAdd:ScoreNotExist", func(t *testing.T) {
+			exist, score, scoreErr := impl.ZScore(context.Background(), "ZAdd:ScoreNotExist", "NotExist")
+			if scoreErr != nil || exist || score != 0 {
+				t.Errorf("ZAdd:ScoreNotExist case failed: exist %v, score %v, error %v", exist, score, scoreErr)
+			}
+		})
+
+		// 错误类型的样例
+		t.Run("ZAdd:WrongType", func(t *testing.T) {
+			key := "ZAdd:WrongType"
+			storeErr := impl.Store(context.Background(), key, "WrongType")
+			if storeErr != nil {
+				t.Errorf("ZAdd:WrongType case failed when storing key: %v", storeErr.Error())
+			}
+
+			_, addErr := impl.ZAdd(context.Background(), key, cache.ZMember{Member: "a", Score: 1})
+			if addErr == nil {
+				t.Errorf("ZAdd:WrongType case failed: no error")
+			}
+			wantError := NewValueTypeNotMatchError(ZSet, String)
+			if !errors.As(addErr, &wantError) {
+				t.Errorf("ZAdd:WrongType case failed: incorrect error")
+			}
+		})
+	}
+}
+
+func ZIncrByFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 增加不存在的成员和已存在的成员的样例
+		t.Run("ZIncrBy:Increase", func(t *testing.T) {
+			key := "ZIncrBy:Increase"
+			score, incrErr := impl.ZIncrBy(context.Background(), key, "a", 1.5)
+			if incrErr != nil || score != 1.5 {
+				t.Errorf("ZIncrBy:Increase case failed: score %v, error %v", score, incrErr)
+			}
+
+			score, incrErr = impl.ZIncrBy(context.Background(), key, "a", -0.5)
+			if incrErr != nil || score != 1 {
+				t.Errorf("ZIncrBy:Increase case failed: score %v, error %v", score, incrErr)
+			}
+		})
+	}
+}
+
+func ZRankFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 获取正序和逆序排名的样例
+		t.Run("ZRank:Rank", func(t *testing.T) {
+			key := "ZRank:Rank"
+			_, addErr := impl.ZAdd(context.Background(), key, cache.ZMember{Member: "a", Score: 1}, cache.ZMember{Member: "b", Score: 2}, cache.ZMember{Member: "c", Score: 3})
+			if addErr != nil {
+				t.Errorf("ZRank:Rank case failed when adding members: %v", addErr.Error())
+			}
+
+			exist, rank, rankErr := impl.ZRank(context.Background(), key, "b")
+			if rankErr != nil || !exist || rank != 1 {
+				t.Errorf("ZRank:Rank case failed: exist %v, rank %d, error %v", exist, rank, rankErr)
+			}
+			exist, rank, rankErr = impl.ZRevRank(context.Background(), key, "a")
+			if rankErr != nil || !exist || rank != 2 {
+				t.Errorf("ZRank:Rank case failed: exist %v, reverse rank %d, error %v", exist, rank, rankErr)
+			}
+		})
+
+		// 获取不存在成员排名的样例
+		t.Run("ZRank:NotExist", func(t *testing.T) {
+			exist, _, rankErr := impl.ZRank(context.Background(), "ZRank:NotExist", "NotExist")
+			if rankErr != nil || exist {
+				t.Errorf("ZRank:NotExist case failed: exist %v, error %v", exist, rankErr)
+			}
+		})
+	}
+}
+
+func ZRangeFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		key := "ZRange:Range"
+		_, addErr := impl.ZAdd(context.Background(), key, cache.ZMember{Member: "c", Score: 3}, cache.ZMember{Member: "a", Score: 1}, cache.ZMember{Member: "b", Score: 2}, cache.ZMember{Member: "d", Score: 4})
+		if addErr != nil {
+			t.Errorf("ZRange case failed when adding members: %v", addErr.Error())
+		}
+
+		// 按排名正序获取的样例
+		t.Run("ZRange:Range", func(t *testing.T) {
+			members, rangeErr := impl.ZRange(context.Background(), key, 1, -1)
+			if rangeErr != nil {
+				t.Errorf("ZRange:Range case failed when ranging: %v", rangeErr.Error())
+			}
+			if !reflect.DeepEqual(members, []cache.ZMember{{Member: "b", Score: 2}, {Member: "c", Score: 3}, {Member: "d", Score: 4}}) {
+				t.Errorf("ZRange:Range case failed: incorrect members %v", members)
+			}
+		})
+
+		// 按排名逆序获取的样例
+		t.Run("ZRange:RevRange", func(t *testing.T) {
+			members, rangeErr := impl.ZRevRange(context.Background(), key, 0, 1)
+			if rangeErr != nil {
+				t.Errorf("ZRange:RevRange case failed when ranging: %v", rangeErr.Error())
+			}
+			if !reflect.DeepEqual(members, []cache.ZMember{{Member: "d", Score: 4}, {Member: "c", Score: 3}}) {
+				t.Errorf("ZRange:RevRange case failed: incorrect members %v", members)
+			}
+		})
+
+		// 按分数获取的样例
+		t.Run("ZRange:ByScore", func(t *testing.T) {
+			members, rangeErr := impl.ZRangeByScore(context.Background(), key, 2, math.Inf(1), 1, 0)
+			if rangeErr != nil {
+				t.Errorf("ZRange:ByScore case failed when ranging: %v", rangeErr.Error())
+			}
+			if !reflect.DeepEqual(members, []cache.ZMember{{Member: "c", Score: 3}, {Member: "d", Score: 4}}) {
+				t.Errorf("ZRange:ByScore case failed: incorrect members %v", members)
+			}
+
+			members, rangeErr = impl.ZRangeByScore(context.Background(), key, math.Inf(-1), 3, 0, 2)
+			if rangeErr != nil {
+				t.Errorf("ZRange:ByScore case failed when ranging: %v", rangeErr.Error())
+			}
+			if !reflect.DeepEqual(members, []cache.ZMember{{Member: "a", Score: 1}, {Member: "b", Score: 2}}) {
+				t.Errorf("ZRange:ByScore case failed: incorrect members %v", members)
+			}
+		})
+
+		// 范围超出的样例
+		t.Run("ZRange:OutOfRange", func(t *testing.T) {
+			members, rangeErr := impl.ZRange(context.Background(), key, 10, 20)
+			if rangeErr != nil || len(members) != 0 {
+				t.Errorf("ZRange:OutOfRange case failed: members %v, error %v", members, rangeErr)
+			}
+		})
+	}
+}
+
+func ZRemFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 删除成员的样例，删除全部成员后key不存在
+		t.Run("ZRem:Remove", func(t *testing.T) {
+			key := "ZRem:Remove"
+			_, addErr := impl.ZAdd(context.Background(), key, cache.ZMember{Member: "a", Score: 1}, cache.ZMember{Member: "b", Score: 2})
+			if addErr != nil {
+				t.Errorf("ZRem:Remove case failed when adding members: %v", addErr.Error())
+			}
+
+			removed, removeErr := impl.ZRem(context.Background(), key, "a", "NotExist")
+			if removeErr != nil || removed != 1 {
+				t.Errorf("ZRem:Remove case failed: removed %d, error %v", removed, removeErr)
+			}
+			removed, removeErr = impl.ZRem(context.Background(), key, "b")
+			if removeErr != nil || removed != 1 {
+				t.Errorf("ZRem:Remove case failed: removed %d, error %v", removed, removeErr)
+			}
+			if exist, _ := impl.ExistKey(context.Background(), key); exist {
+				t.Errorf("ZRem:Remove case failed: key exist after removing all members")
+			}
+		})
+	}
+}
+
+func ZPopFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 弹出最小和最大成员的样例
+		t.Run("ZPop:Pop", func(t *testing.T) {
+			key := "ZPop:Pop"
+			_, addErr := impl.ZAdd(context.Background(), key, cache.ZMember{Member: "a", Score: 1}, cache.ZMember{Member: "b", Score: 2}, cache.ZMember{Member: "c", Score: 3})
+			if addErr != nil {
+				t.Errorf("ZPop:Pop case failed when adding members: %v", addErr.Error())
+			}
+
+			members, popErr := impl.ZPopMin(context.Background(), key, 2)
+			if popErr != nil || !reflect.DeepEqual(members, []cache.ZMember{{Member: "a", Score: 1}, {Member: "b", Score: 2}}) {
+				t.Errorf("ZPop:Pop case failed: members %v, error %v", members, popErr)
+			}
+			members, popErr = impl.ZPopMax(context.Background(), key, 2)
+			if popErr != nil || !reflect.DeepEqual(members, []cache.ZMember{{Member: "c", Score: 3}}) {
+				t.Errorf("ZPop:Pop case failed: members %v, error %v", members, popErr)
+			}
+			if count, _ := impl.ZCard(context.Background(), key); count != 0 {
+				t.Errorf("ZPop:Pop case failed: count %d, want 0", count)
+			}
+		})
+	}
+}
+
+func LPushFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 从两端写入列表的样例
+		t.Run("LPush:Push", func(t *testing.T) {
+			key := "LPush:Push"
+			length, pushErr := impl.RPush(context.Background(), key, "b", "c")
+			if pushErr != nil || length != 2 {
+				t.Errorf("LPush:Push case failed: length %d, error %v", length, pushErr)
+			}
+			length, pushErr = impl.LPush(context.Background(), key, "a", "0")
+			if pushErr != nil || length != 4 {
+				t.Errorf("LPush:Push case failed: length %d, error %v", length, pushErr)
+			}
+
+			values, rangeErr := impl.LRange(context.Background(), key, 0, -1)
+			if rangeErr != nil || !reflect.DeepEqual(values, []string{"0", "a", "b", "c"}) {
+				t.Errorf("LPush:Push case failed: values %v, error %v", values, rangeErr)
+			}
+			values, rangeErr = impl.LRange(context.Background(), key, -2, 10)
+			if rangeErr != nil || !reflect.DeepEqual(values, []string{"b", "c"}) {
+				t.Errorf("LPush:Push case failed: values %v, error %v", values, rangeErr)
+			}
+			if length, _ = impl.LLen(context.Background(), key); length != 4 {
+				t.Errorf("LPush:Push case failed: length %d, want 4", length)
+			}
+		})
+
+		// 错误类型的样例
+		t.Run("LPush:WrongType", func(t *testing.T) {
+			key := "LPush:WrongType"
+			storeErr := impl.Store(context.Background(), key, "WrongType")
+			if storeErr != nil {
+				t.Errorf("LPush:WrongType case failed when storing key: %v", storeErr.Error())
+			}
+
+			_, pushErr := impl.LPush(context.Background(), key, "a")
+			if pushErr == nil {
+				t.Errorf("LPush:WrongType case failed: no error")
+			}
+			wantError := NewValueTypeNotMatchError(List, String)
+			if !errors.As(pushErr, &wantError) {
+				t.Errorf("LPush:WrongType case failed: incorrect error")
+			}
+		})
+	}
+}
+
+func LPopFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 从两端弹出的样例，弹出全部元素后key不存在
+		t.Run("LPop:Pop", func(t *testing.T) {
+			key := "LPop:Pop"
+			_, pushErr := impl.RPush(context.Background(), key, "a", "b")
+			if pushErr != nil {
+				t.Errorf("LPop:Pop case failed when pushing: %v", pushErr.Error())
+			}
+
+			exist, value, popErr := impl.RPop(context.Background(), key)
+			if popErr != nil || !exist || value != "b" {
+				t.Errorf("LPop:Pop case failed: exist %v, value %s, error %v", exist, value, popErr)
+			}
+			exist, value, popErr = impl.LPop(context.Background(), key)
+			if popErr != nil || !exist || value != "a" {
+				t.Errorf("LPop:Pop case failed: exist %v, value %s, error %v", exist, value, popErr)
+			}
+			exist, value, popErr = impl.LPop(context.Background(), key)
+			if popErr != nil || exist || value != "" {
+				t.Errorf("LPop:Pop case failed: exist %v, value %s, error %v", exist, value, popErr)
+			}
+			if existKey, _ := impl.ExistKey(context.Background(), key); existKey {
+				t.Errorf("LPop:Pop case failed: key exist after popping all values")
+			}
+		})
+	}
+}
+
+func BLPopFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 阻塞等待其他协程写入的样例
+		t.Run("BLPop:Pushed", func(t *testing.T) {
+			key := "BLPop:Pushed"
+			go func() {
+				time.Sleep(time.Millisecond * 100)
+				_, _ = impl.RPush(context.Background(), key, "value")
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+			popped, poppedKey, value, popErr := impl.BLPop(ctx, "BLPop:Other", key)
+			if popErr != nil || !popped || poppedKey != key || value != "value" {
+				t.Errorf("BLPop:Pushed case failed: popped %v, key %s, value %s, error %v", popped, poppedKey, value, popErr)
+			}
+		})
+
+		// 超时的样例
+		t.Run("BLPop:Timeout", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+			defer cancel()
+			popped, _, _, popErr := impl.BLPop(ctx, "BLPop:Timeout")
+			if popErr != nil || popped {
+				t.Errorf("BLPop:Timeout case failed: popped %v, error %v", popped, popErr)
+			}
+		})
+	}
+}
+
+func PersistFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 移除过期时间的样例
+		t.Run("Persist:Exists", func(t *testing.T) {
+			key := "Persist:Exists"
+			_ = impl.StoreEX(context.Background(), key, "value", time.Second)
+			if persistErr := impl.Persist(context.Background(), key); persistErr != nil {
+				t.Fatalf("Persist:Exists case failed: %v", persistErr)
+			}
+
+			exist, expiredAt, _ := impl.GetExpiredTime(context.Background(), key)
+			if !exist || !expiredAt.IsZero() {
+				t.Errorf("Persist:Exists case failed: exist %v, expired at %v", exist, expiredAt)
+			}
+		})
+
+		// 不存在的key
+		t.Run("Persist:NotExists", func(t *testing.T) {
+			if persistErr := impl.Persist(context.Background(), "Persist:NotExists"); persistErr != nil {
+				t.Errorf("Persist:NotExists case failed: %v", persistErr)
+			}
+		})
+	}
+}
+
+func TypeFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Run("Type:Values", func(t *testing.T) {
+			_ = impl.Store(context.Background(), "Type:String", "value")
+			_ = impl.HSetValue(context.Background(), "Type:Hash", "field", "value")
+			_, _ = impl.RPush(context.Background(), "Type:List", "value")
+			_ = impl.Delete(context.Background(), "Type:NotExists")
+
+			for key, want := range map[string]string{"Type:String": "string", "Type:Hash": "hash", "Type:List": "list", "Type:NotExists": ""} {
+				exist, keyType, typeErr := impl.Type(context.Background(), key)
+				if typeErr != nil || exist != (want != "") || keyType != want {
+					t.Errorf("Type:Values case failed: key %s exist %v, type %s, want %s, error %v", key, exist, keyType, want, typeErr)
+				}
+			}
+		})
+	}
+}
+
+func ScanFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 分多次扫描出所有匹配的key
+		t.Run("Scan:Pattern", func(t *testing.T) {
+			want := map[string]bool{}
+			for i := 0; i < 25; i++ {
+				key := "Scan:Pattern:" + strconv.Itoa(i)
+				_ = impl.Store(context.Background(), key, "value")
+				want[key] = true
+			}
+			_ = impl.Store(context.Background(), "Scan:Other", "value")
+
+			found, cursor, calls := map[string]bool{}, uint64(0), 0
+			for {
+				keys, nextCursor, scanErr := impl.Scan(context.Background(), cursor, "Scan:Pattern:*", 5)
+				if scanErr != nil {
+					t.Fatalf("Scan:Pattern case failed: %v", scanErr)
+				}
+				for _, key := range keys {
+					found[key] = true
+				}
+
+				calls++
+				if cursor = nextCursor; cursor == 0 {
+					break
+				}
+			}
+
+			if !reflect.DeepEqual(found, want) {
+				t.Errorf("Scan:Pattern case failed: found %v", found)
+			}
+			if calls < 2 {
+				t.Errorf("Scan:Pattern case failed: scan finished in %d call", calls)
+			}
+		})
+
+		// 扫描过程中删除和新增key，删除的key不会返回，一直存在的key都会返回
+		t.Run("Scan:Modified", func(t *testing.T) {
+			want := map[string]bool{}
+			for i := 0; i < 25; i++ {
+				key := "Scan:Modified:" + strconv.Itoa(i)
+				_ = impl.Store(context.Background(), key, "value")
+				want[key] = true
+			}
+
+			found, deletedFound, cursor := map[string]bool{}, false, uint64(0)
+			for calls := 0; ; calls++ {
+				keys, nextCursor, scanErr := impl.Scan(context.Background(), cursor, "Scan:Modified:*", 5)
+				if scanErr != nil {
+					t.Fatalf("Scan:Modified case failed: %v", scanErr)
+				}
+				for _, key := range keys {
+					found[key] = true
+					deletedFound = deletedFound || (calls > 0 && key == "Scan:Modified:0")
+				}
+
+				if calls == 0 {
+					_ = impl.Delete(context.Background(), "Scan:Modified:0")
+					_ = impl.Store(context.Background(), "Scan:Modified:added", "value")
+				}
+				if cursor = nextCursor; cursor == 0 {
+					break
+				}
+			}
+
+			for key := range want {
+				if !found[key] && key != "Scan:Modified:0" {
+					t.Errorf("Scan:Modified case failed: %s is not found", key)
+				}
+			}
+			if deletedFound {
+				t.Errorf("Scan:Modified case failed: deleted key is returned")
+			}
+		})
+
+		// 按模式删除
+		t.Run("Scan:DeleteByPattern", func(t *testing.T) {
+			for i := 0; i < 5; i++ {
+				_ = impl.Store(context.Background(), "Scan:DeleteByPattern:"+strconv.Itoa(i), "value")
+			}
+			_ = impl.Store(context.Background(), "Scan:DeleteByPatternKept", "value")
+
+			deleted, deleteErr := impl.DeleteByPattern(context.Background(), "Scan:DeleteByPattern:*")
+			if deleteErr != nil || deleted != 5 {
+				t.Errorf("Scan:DeleteByPattern case failed: deleted %d, error %v", deleted, deleteErr)
+			}
+			if exist, _ := impl.ExistKey(context.Background(), "Scan:DeleteByPatternKept"); !exist {
+				t.Errorf("Scan:DeleteByPattern case failed: unmatched key is deleted")
+			}
+		})
+	}
+}
+
 func RunCacheTestCases(t *testing.T, impl cache.Cache) {
 	for _, i := range BaseCacheUnitTestCaseList {
 		t.Run(i.CaseName, i.TestFunction(impl))
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+type codecUser struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+func TestMemoryCodec(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GzipJson", func(t *testing.T) {
+		impl := NewMemoryCache(Config{Codec: cache.CodecGzipJson})
+		sender := codecUser{Name: "alioth", Tags: []string{"a", "b"}}
+		if err := impl.StoreJson(ctx, "user", &sender); err != nil {
+			t.Fatal(err)
+		}
+
+		// 存储的是gzip压缩后的数据
+		_, raw, _ := impl.Load(ctx, "user")
+		if len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
+			t.Errorf("stored value is not gzip: %q", raw)
+		}
+
+		receiver := codecUser{}
+		if exist, err := impl.LoadJson(ctx, "user", &receiver); !exist || err != nil || receiver.Name != "alioth" || len(receiver.Tags) != 2 {
+			t.Errorf("load gzip json failed: %v, %v, %+v", exist, err, receiver)
+		}
+	})
+
+	t.Run("UnknownCodec", func(t *testing.T) {
+		// 未注册的编码无法通过校验，构造函数回退到json
+		if err := (Config{Codec: "unknown"}).Validate(); !errors.Is(err, cache.ErrCodecNotFound) {
+			t.Errorf("expected codec not found, got %v", err)
+		}
+		if err := (Config{Codec: cache.CodecGzipJson}).Validate(); err != nil {
+			t.Errorf("expected registered codec valid, got %v", err)
+		}
+
+		impl := NewMemoryCache(Config{Codec: "unknown"})
+		if err := impl.StoreJson(ctx, "user", &codecUser{Name: "alioth"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, raw, _ := impl.Load(ctx, "user"); raw != `{"name":"alioth","tags":null}` {
+			t.Errorf("unexpected value %s", raw)
+		}
+	})
+
+	t.Run("GenericHelpers", func(t *testing.T) {
+		impl := NewMemoryCache(Config{Codec: cache.CodecGzipJson})
+		if err := cache.Set(ctx, impl, "generic", codecUser{Name: "alioth"}, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+
+		exist, user, err := cache.Get[codecUser](ctx, impl, "generic")
+		if !exist || err != nil || user.Name != "alioth" {
+			t.Errorf("get failed: %v, %v, %+v", exist, err, user)
+		}
+		if exist, _, _ = cache.Get[codecUser](ctx, impl, "missing"); exist {
+			t.Error("missing key should not exist")
+		}
+
+		// 使用json编码的字段
+		jsonImpl := NewMemoryCache(Config{})
+		_ = jsonImpl.HSetValue(ctx, "hash", "count", "42")
+		if exist, count, hgetErr := cache.HGet[int](ctx, jsonImpl, "hash", "count"); !exist || hgetErr != nil || count != 42 {
+			t.Errorf("hget failed: %v, %v, %d", exist, hgetErr, count)
+		}
+	})
+}
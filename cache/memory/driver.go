@@ -7,24 +7,69 @@ import (
 	"time"
 
 	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/limiter"
 	"github.com/alioth-center/infrastructure/exit"
 )
 
-const DriverName = "memory"
+const (
+	DriverName = "memory"
+
+	// lockRetryInterval is the interval of retrying to acquire a lock held by others.
+	lockRetryInterval = time.Millisecond * 10
+
+	// defaultScanCount is the number of keys examined by one scan when count is not specified,
+	// the same as redis.
+	defaultScanCount = 10
+)
 
 type Config struct {
 	EnableInitiativeClean bool `json:"enable_initiative_clean,omitempty" yaml:"enable_initiative_clean,omitempty" xml:"enable_initiative_clean,omitempty"`
 	CleanIntervalSecond   int  `json:"clean_interval_second,omitempty" yaml:"clean_interval_second,omitempty" xml:"clean_interval_second,omitempty"`
 	MaxCleanMicroSecond   int  `json:"max_clean_micro_second,omitempty" yaml:"max_clean_micro_second,omitempty" xml:"max_clean_micro_second,omitempty"`
 	MaxCleanPercentage    int  `json:"max_clean_percentage,omitempty" yaml:"max_clean_percentage,omitempty" xml:"max_clean_percentage,omitempty"`
+
+	// MaxEntries and MaxMemoryBytes limit the number of keys and the approximate memory usage,
+	// zero means unlimited. Keys are evicted by EvictionPolicy when a write exceeds the limits.
+	MaxEntries      int            `json:"max_entries,omitempty" yaml:"max_entries,omitempty" xml:"max_entries,omitempty"`
+	MaxMemoryBytes  int64          `json:"max_memory_bytes,omitempty" yaml:"max_memory_bytes,omitempty" xml:"max_memory_bytes,omitempty"`
+	EvictionPolicy  EvictionPolicy `json:"eviction_policy,omitempty" yaml:"eviction_policy,omitempty" xml:"eviction_policy,omitempty"`
+	EvictionSamples int            `json:"eviction_samples,omitempty" yaml:"eviction_samples,omitempty" xml:"eviction_samples,omitempty"`
+
+	// SnapshotPath enables persistence, the keys are loaded from the file when the cache is created
+	// and saved to the file on exit, and also every SnapshotIntervalSecond seconds if it is positive.
+	SnapshotPath           string `json:"snapshot_path,omitempty" yaml:"snapshot_path,omitempty" xml:"snapshot_path,omitempty"`
+	SnapshotIntervalSecond int    `json:"snapshot_interval_second,omitempty" yaml:"snapshot_interval_second,omitempty" xml:"snapshot_interval_second,omitempty"`
+
+	// Codec is the name of the cache.Codec used by the *Json methods, it is json when empty.
+	Codec string `json:"codec,omitempty" yaml:"codec,omitempty" xml:"codec,omitempty"`
+}
+
+// Validate reports the unregistered codec, which the redis and tiered drivers reject. The
+// constructors can not return an error, so they fall back to json for it with a warning.
+func (cfg Config) Validate() (err error) {
+	if _, codecErr := cache.GetCodec(cfg.Codec); codecErr != nil {
+		return fmt.Errorf("invalid memory cache codec %s: %w", cfg.Codec, codecErr)
+	}
+
+	return nil
 }
 
 func newCache(cfg Config) *accessor {
 	memoryCache := &accessor{
-		mtx: sync.RWMutex{},
-		db:  map[string]entry{},
-		ec:  make(chan struct{}, 1),
+		mtx:   sync.RWMutex{},
+		db:    map[string]entry{},
+		ec:    make(chan struct{}, 1),
+		limit: newLimitation(cfg),
 	}
+	memoryCache.listCond = sync.NewCond(&memoryCache.listMtx)
+
+	codec, codecErr := cache.GetCodec(cfg.Codec)
+	if codecErr != nil {
+		// 构造函数无法返回错误，使用默认的json编码，需要通过Config.Validate提前检查
+		fmt.Println("failed to select memory cache codec, fallback to json:", codecErr)
+		codec, _ = cache.GetCodec(cache.CodecJson)
+	}
+	memoryCache.codec = codec
 
 	if cfg.EnableInitiativeClean {
 		interval, maxExec := time.Second*time.Duration(cfg.CleanIntervalSecond), time.Microsecond*time.Duration(cfg.MaxCleanMicroSecond)
@@ -37,9 +82,37 @@ func newCache(cfg Config) *accessor {
 		}, "CLEAN_MEMORY_CACHE")
 	}
 
+	if cfg.SnapshotPath != "" {
+		memoryCache.enableSnapshot(cfg.SnapshotPath, time.Second*time.Duration(cfg.SnapshotIntervalSecond))
+	}
+
 	return memoryCache
 }
 
+func (ca *accessor) enableSnapshot(path string, interval time.Duration) {
+	if loaded, loadErr := ca.loadSnapshot(path); loadErr != nil {
+		// 快照无法加载时以空缓存启动，退出时的快照会覆盖无法加载的文件
+		fmt.Println("failed to load memory cache snapshot:", loadErr)
+	} else if loaded > 0 {
+		fmt.Println("loaded", loaded, "keys from memory cache snapshot", path)
+	}
+
+	stop := make(chan struct{})
+	if interval > 0 {
+		go ca.snapshotPeriodically(path, interval, stop)
+	}
+
+	// 不同路径的快照需要注册不同的退出事件
+	exit.RegisterExitEvent(func(_ os.Signal) {
+		close(stop)
+		if saveErr := ca.saveSnapshot(path); saveErr != nil {
+			fmt.Println("failed to save memory cache snapshot:", saveErr)
+			return
+		}
+		fmt.Println("saved memory cache snapshot", path)
+	}, "SNAPSHOT_MEMORY_CACHE:"+path)
+}
+
 func NewMemoryCache(cfg Config) (mc cache.Cache) {
 	return newCache(cfg)
 }
@@ -47,3 +120,11 @@ func NewMemoryCache(cfg Config) (mc cache.Cache) {
 func NewMemoryCounter(cfg Config) (mc cache.Counter) {
 	return newCache(cfg)
 }
+
+func NewMemoryLocker(cfg Config) (mc cache.Locker) {
+	return newCache(cfg)
+}
+
+func NewMemoryLimiterStorage(cfg Config) (mc limiter.Storage) {
+	return newCache(cfg)
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+// subscriptionBufferSize is the capacity of the channel of a subscription, the events are
+// dropped when it is full, so that a slow receiver never blocks the writes.
+const subscriptionBufferSize = 256
+
+type subscription struct {
+	pattern string
+	events  chan cache.CacheEvent
+}
+
+// subscriptions holds the subscribers of an accessor. The events are emitted while the write
+// lock of the accessor is held, so the lock order is always the accessor first.
+type subscriptions struct {
+	mtx         sync.RWMutex
+	subscribers map[*subscription]struct{}
+	count       atomic.Int64
+}
+
+func (ca *accessor) Subscribe(ctx context.Context, pattern string) <-chan cache.CacheEvent {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	subscriber := &subscription{pattern: pattern, events: make(chan cache.CacheEvent, subscriptionBufferSize)}
+	ca.subs.mtx.Lock()
+	if ca.subs.subscribers == nil {
+		ca.subs.subscribers = map[*subscription]struct{}{}
+	}
+	ca.subs.subscribers[subscriber] = struct{}{}
+	ca.subs.count.Add(1)
+	ca.subs.mtx.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		ca.subs.mtx.Lock()
+		delete(ca.subs.subscribers, subscriber)
+		ca.subs.count.Add(-1)
+		close(subscriber.events)
+		ca.subs.mtx.Unlock()
+	})
+
+	return subscriber.events
+}
+
+// emit delivers the event to the subscribers whose pattern matches the key.
+func (ca *accessor) emit(eventType cache.CacheEventType, key string) {
+	if ca.subs.count.Load() == 0 {
+		return
+	}
+
+	ca.subs.mtx.RLock()
+	defer ca.subs.mtx.RUnlock()
+	for subscriber := range ca.subs.subscribers {
+		if !matchPattern(subscriber.pattern, key) {
+			continue
+		}
+
+		select {
+		case subscriber.events <- cache.CacheEvent{Type: eventType, Key: key}:
+		default:
+			// 订阅者处理不及时，丢弃事件
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+var BaseEventUnitTestCaseList = []TestCase[cache.Cache]{
+	{
+		CaseName:     "Subscribe",
+		TestFunction: SubscribeFunction,
+	},
+	{
+		CaseName:     "SubscribeExpire",
+		TestFunction: SubscribeExpireFunction,
+	},
+	{
+		CaseName:     "SubscribeTTL",
+		TestFunction: SubscribeTTLFunction,
+	},
+}
+
+// receiveEvent waits for the next event, it returns false if no event arrives in time.
+func receiveEvent(events <-chan cache.CacheEvent, timeout time.Duration) (event cache.CacheEvent, received bool) {
+	select {
+	case event, received = <-events:
+		return event, received
+	case <-time.After(timeout):
+		return cache.CacheEvent{}, false
+	}
+}
+
+func SubscribeFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		events := impl.Subscribe(ctx, "SubscribeFunction:session:*")
+		// 等待订阅生效
+		time.Sleep(time.Millisecond * 100)
+
+		_ = impl.Store(context.Background(), "SubscribeFunction:other", "value")
+		_ = impl.Store(context.Background(), "SubscribeFunction:session:1", "value")
+		_ = impl.Delete(context.Background(), "SubscribeFunction:session:1")
+
+		// 不匹配的key不会产生事件
+		expected := []cache.CacheEvent{
+			{Type: cache.CacheEventSet, Key: "SubscribeFunction:session:1"},
+			{Type: cache.CacheEventDelete, Key: "SubscribeFunction:session:1"},
+		}
+		for _, want := range expected {
+			if event, received := receiveEvent(events, time.Second); !received || event != want {
+				t.Fatalf("SubscribeFunction case failed: expected %+v, got %+v, %v", want, event, received)
+			}
+		}
+
+		// 取消后通道关闭
+		cancel()
+		for {
+			if _, received := receiveEvent(events, time.Second); !received {
+				break
+			}
+		}
+	}
+}
+
+func SubscribeExpireFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := impl.Subscribe(ctx, "SubscribeExpireFunction:*")
+		time.Sleep(time.Millisecond * 100)
+
+		key := "SubscribeExpireFunction:session"
+		_ = impl.StoreEX(context.Background(), key, "value", time.Millisecond*100)
+		if event, received := receiveEvent(events, time.Second); !received || event.Type != cache.CacheEventSet {
+			t.Fatalf("SubscribeExpireFunction case failed: expected set event, got %+v, %v", event, received)
+		}
+
+		// 过期后读取，触发惰性删除
+		time.Sleep(time.Millisecond * 200)
+		_, _, _ = impl.Load(context.Background(), key)
+		if event, received := receiveEvent(events, time.Second); !received || event != (cache.CacheEvent{Type: cache.CacheEventExpire, Key: key}) {
+			t.Errorf("SubscribeExpireFunction case failed: expected expire event, got %+v, %v", event, received)
+		}
+	}
+}
+
+func SubscribeTTLFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := impl.Subscribe(ctx, "SubscribeTTLFunction:*")
+		time.Sleep(time.Millisecond * 100)
+
+		key := "SubscribeTTLFunction:session"
+		_ = impl.Store(context.Background(), key, "value")
+		_ = impl.Expire(context.Background(), key, time.Minute)
+		_ = impl.Persist(context.Background(), key)
+		_ = impl.Delete(context.Background(), key)
+
+		// 只修改过期时间不会产生事件，set之后紧接着就是delete
+		expected := []cache.CacheEvent{
+			{Type: cache.CacheEventSet, Key: key},
+			{Type: cache.CacheEventDelete, Key: key},
+		}
+		for _, want := range expected {
+			if event, received := receiveEvent(events, time.Second); !received || event != want {
+				t.Fatalf("SubscribeTTLFunction case failed: expected %+v, got %+v, %v", want, event, received)
+			}
+		}
+	}
+}
+
+func RunEventTestCases(t *testing.T, impl cache.Cache) {
+	for _, v := range BaseEventUnitTestCaseList {
+		t.Run(v.CaseName, v.TestFunction(impl))
+	}
+}
@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"sync/atomic"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+// EvictionPolicy decides which key is evicted when the memory cache exceeds its limits, the
+// policies behave like the maxmemory-policy of redis and are approximated by sampling keys.
+type EvictionPolicy string
+
+const (
+	// EvictionPolicyLRU evicts the least recently used key, it is the default policy.
+	EvictionPolicyLRU EvictionPolicy = "lru"
+
+	// EvictionPolicyLFU evicts the least frequently used key.
+	EvictionPolicyLFU EvictionPolicy = "lfu"
+
+	// EvictionPolicyRandom evicts a random key.
+	EvictionPolicyRandom EvictionPolicy = "random"
+
+	// EvictionPolicyVolatileTTL evicts the key with the nearest expiration among keys with an
+	// expiration, if no sampled key has an expiration, it falls back to EvictionPolicyLRU.
+	EvictionPolicyVolatileTTL EvictionPolicy = "volatile-ttl"
+
+	defaultEvictionSamples = 5
+)
+
+// EvictionStatistics is a snapshot of the usage and eviction counters of a memory cache.
+type EvictionStatistics struct {
+	Entries          int    `json:"entries"`
+	UsedBytes        int64  `json:"used_bytes"`
+	Evicted          uint64 `json:"evicted"`
+	EvictedByEntries uint64 `json:"evicted_by_entries"`
+	EvictedByBytes   uint64 `json:"evicted_by_bytes"`
+}
+
+// EvictionReporter is implemented by the caches and counters created by this package.
+//
+// example:
+//
+//	if reporter, ok := memoryCache.(memory.EvictionReporter); ok {
+//		fmt.Println(reporter.EvictionStatistics().Evicted)
+//	}
+type EvictionReporter interface {
+	EvictionStatistics() EvictionStatistics
+}
+
+type limitation struct {
+	maxEntries int
+	maxBytes   int64
+	policy     EvictionPolicy
+	samples    int
+}
+
+func newLimitation(cfg Config) limitation {
+	limit := limitation{
+		maxEntries: cfg.MaxEntries,
+		maxBytes:   cfg.MaxMemoryBytes,
+		policy:     cfg.EvictionPolicy,
+		samples:    cfg.EvictionSamples,
+	}
+
+	switch limit.policy {
+	case EvictionPolicyLRU, EvictionPolicyLFU, EvictionPolicyRandom, EvictionPolicyVolatileTTL:
+	default:
+		limit.policy = EvictionPolicyLRU
+	}
+	if limit.samples <= 0 {
+		limit.samples = defaultEvictionSamples
+	}
+
+	return limit
+}
+
+func (l limitation) enabled() bool {
+	return l.maxEntries > 0 || l.maxBytes > 0
+}
+
+// prefer reports whether candidate should be evicted instead of current, current is nil when
+// no key has been chosen yet.
+func (l limitation) prefer(candidate, current entry) bool {
+	switch l.policy {
+	case EvictionPolicyLFU:
+		if current == nil || candidate.GetHits() < current.GetHits() {
+			return true
+		}
+
+		return candidate.GetHits() == current.GetHits() && candidate.GetAccessedAt() < current.GetAccessedAt()
+	case EvictionPolicyRandom:
+		return current == nil
+	case EvictionPolicyVolatileTTL:
+		if candidate.GetExpiredAt().IsZero() {
+			return false
+		}
+
+		return current == nil || candidate.GetExpiredAt().Before(current.GetExpiredAt())
+	default:
+		return current == nil || candidate.GetAccessedAt() < current.GetAccessedAt()
+	}
+}
+
+type evictionCounter struct {
+	byEntries atomic.Uint64
+	byBytes   atomic.Uint64
+}
+
+// remove deletes the key and its accounted size, the write lock must be held.
+func (ca *accessor) remove(key string) {
+	if ca.drop(key) {
+		ca.emit(cache.CacheEventDelete, key)
+	}
+}
+
+// removeExpired deletes the key if it is still expired, the write lock must be held. The key is
+// checked again since it may be written after it is found expired without the write lock.
+func (ca *accessor) removeExpired(key string) {
+	if value, exist := ca.db[key]; exist && value.IsExpired() && ca.drop(key) {
+		ca.emit(cache.CacheEventExpire, key)
+	}
+}
+
+func (ca *accessor) drop(key string) (dropped bool) {
+	value, exist := ca.db[key]
+	if !exist {
+		return false
+	}
+
+	ca.used -= value.getAccountedSize()
+	delete(ca.db, key)
+	return true
+}
+
+// put stores the key and evicts other keys if the limits are exceeded, the write lock must be held.
+func (ca *accessor) put(key string, value entry) {
+	if old, exist := ca.db[key]; exist {
+		ca.used -= old.getAccountedSize()
+	}
+
+	size := int64(len(key)) + entryOverhead + value.Size()
+	value.setAccountedSize(size)
+	value.Touch()
+	ca.used += size
+	ca.db[key] = value
+	ca.emit(cache.CacheEventSet, key)
+
+	ca.evict(key)
+}
+
+// evict removes keys until the limits are satisfied, the key just written is never evicted.
+func (ca *accessor) evict(except string) {
+	if !ca.limit.enabled() {
+		return
+	}
+
+	for len(ca.db) > 1 {
+		overEntries := ca.limit.maxEntries > 0 && len(ca.db) > ca.limit.maxEntries
+		overBytes := ca.limit.maxBytes > 0 && ca.used > ca.limit.maxBytes
+		if !overEntries && !overBytes {
+			return
+		}
+
+		victim, expired := ca.pickVictim(except)
+		if victim == "" {
+			return
+		}
+
+		if expired {
+			// 过期的key本来就应该被删除，不计入淘汰次数
+			ca.removeExpired(victim)
+			continue
+		}
+		ca.remove(victim)
+		if overEntries {
+			ca.evictions.byEntries.Add(1)
+		} else {
+			ca.evictions.byBytes.Add(1)
+		}
+	}
+}
+
+// pickVictim samples some keys and returns the one to be evicted according to the policy, an
+// expired key is returned immediately if it is sampled.
+func (ca *accessor) pickVictim(except string) (victim string, expired bool) {
+	var chosen, fallback entry
+	chosenKey, fallbackKey, sampled := "", "", 0
+
+	// map的遍历起点是随机的，取前几个key作为样本
+	for key, value := range ca.db {
+		if key == except {
+			continue
+		}
+		if value.IsExpired() {
+			return key, true
+		}
+
+		if fallback == nil || value.GetAccessedAt() < fallback.GetAccessedAt() {
+			fallbackKey, fallback = key, value
+		}
+		if ca.limit.prefer(value, chosen) {
+			chosenKey, chosen = key, value
+		}
+
+		sampled++
+		if sampled >= ca.limit.samples {
+			break
+		}
+	}
+
+	if chosen == nil {
+		return fallbackKey, false
+	}
+
+	return chosenKey, false
+}
+
+func (ca *accessor) EvictionStatistics() EvictionStatistics {
+	ca.mtx.RLock()
+	defer ca.mtx.RUnlock()
+
+	byEntries, byBytes := ca.evictions.byEntries.Load(), ca.evictions.byBytes.Load()
+	return EvictionStatistics{
+		Entries:          len(ca.db),
+		UsedBytes:        ca.used,
+		Evicted:          byEntries + byBytes,
+		EvictedByEntries: byEntries,
+		EvictedByBytes:   byBytes,
+	}
+}
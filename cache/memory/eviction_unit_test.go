@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEviction(t *testing.T) {
+	// 超过最大key数量时，淘汰最久未使用的key
+	t.Run("Eviction:LRU", func(t *testing.T) {
+		impl := NewMemoryCache(Config{MaxEntries: 3, EvictionPolicy: EvictionPolicyLRU})
+		for i := 0; i < 3; i++ {
+			_ = impl.Store(context.Background(), strconv.Itoa(i), "value")
+			time.Sleep(time.Millisecond)
+		}
+
+		// 访问最早写入的key，使其成为最近使用的key
+		_, _, _ = impl.Load(context.Background(), "0")
+		time.Sleep(time.Millisecond)
+		_ = impl.Store(context.Background(), "3", "value")
+
+		for key, want := range map[string]bool{"0": true, "1": false, "2": true, "3": true} {
+			if exist, _ := impl.ExistKey(context.Background(), key); exist != want {
+				t.Errorf("Eviction:LRU case failed: key %s exist %v, want %v", key, exist, want)
+			}
+		}
+
+		statistics := impl.(EvictionReporter).EvictionStatistics()
+		if statistics.Entries != 3 || statistics.Evicted != 1 || statistics.EvictedByEntries != 1 {
+			t.Errorf("Eviction:LRU case failed: incorrect statistics %+v", statistics)
+		}
+	})
+
+	// 超过最大key数量时，淘汰使用次数最少的key
+	t.Run("Eviction:LFU", func(t *testing.T) {
+		impl := NewMemoryCache(Config{MaxEntries: 2, EvictionPolicy: EvictionPolicyLFU})
+		_ = impl.Store(context.Background(), "frequent", "value")
+		_ = impl.Store(context.Background(), "rare", "value")
+		for i := 0; i < 10; i++ {
+			_, _, _ = impl.Load(context.Background(), "frequent")
+		}
+		_ = impl.Store(context.Background(), "new", "value")
+
+		if exist, _ := impl.ExistKey(context.Background(), "rare"); exist {
+			t.Errorf("Eviction:LFU case failed: rare key is not evicted")
+		}
+		if exist, _ := impl.ExistKey(context.Background(), "frequent"); !exist {
+			t.Errorf("Eviction:LFU case failed: frequent key is evicted")
+		}
+	})
+
+	// 淘汰最快过期的key
+	t.Run("Eviction:VolatileTTL", func(t *testing.T) {
+		impl := NewMemoryCache(Config{MaxEntries: 2, EvictionPolicy: EvictionPolicyVolatileTTL})
+		_ = impl.Store(context.Background(), "persistent", "value")
+		_ = impl.StoreEX(context.Background(), "volatile", "value", time.Hour)
+		_ = impl.StoreEX(context.Background(), "new", "value", time.Minute)
+
+		if exist, _ := impl.ExistKey(context.Background(), "volatile"); exist {
+			t.Errorf("Eviction:VolatileTTL case failed: volatile key is not evicted")
+		}
+		if exist, _ := impl.ExistKey(context.Background(), "persistent"); !exist {
+			t.Errorf("Eviction:VolatileTTL case failed: persistent key is evicted")
+		}
+	})
+
+	// 超过最大内存时淘汰key，写入集合和哈希也需要计算大小
+	t.Run("Eviction:MaxMemoryBytes", func(t *testing.T) {
+		impl := NewMemoryCache(Config{MaxMemoryBytes: 4096, EvictionPolicy: EvictionPolicyRandom})
+		value := strings.Repeat("v", 512)
+		for i := 0; i < 32; i++ {
+			switch i % 3 {
+			case 0:
+				_ = impl.Store(context.Background(), strconv.Itoa(i), value)
+			case 1:
+				_ = impl.HSetValue(context.Background(), strconv.Itoa(i), "field", value)
+			default:
+				_ = impl.AddMember(context.Background(), strconv.Itoa(i), value)
+			}
+		}
+
+		statistics := impl.(EvictionReporter).EvictionStatistics()
+		if statistics.UsedBytes > 4096 {
+			t.Errorf("Eviction:MaxMemoryBytes case failed: used %d bytes, want at most 4096", statistics.UsedBytes)
+		}
+		if statistics.EvictedByBytes == 0 || statistics.EvictedByEntries != 0 {
+			t.Errorf("Eviction:MaxMemoryBytes case failed: incorrect statistics %+v", statistics)
+		}
+	})
+
+	// 删除key后释放占用的内存
+	t.Run("Eviction:Accounting", func(t *testing.T) {
+		impl := NewMemoryCache(Config{MaxMemoryBytes: 1 << 20})
+		_ = impl.HSetValues(context.Background(), "hash", map[string]string{"a": "1", "b": "2"})
+		_ = impl.AddMembers(context.Background(), "set", "a", "b", "c")
+		_ = impl.Delete(context.Background(), "hash")
+		_ = impl.Delete(context.Background(), "set")
+
+		if statistics := impl.(EvictionReporter).EvictionStatistics(); statistics.UsedBytes != 0 || statistics.Entries != 0 {
+			t.Errorf("Eviction:Accounting case failed: incorrect statistics %+v", statistics)
+		}
+	})
+}
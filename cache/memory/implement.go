@@ -1,8 +1,12 @@
 package memory
 
 import (
+	"container/list"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
 )
 
 type Type string
@@ -12,13 +16,40 @@ const (
 	String Type = "string"
 	Set    Type = "set"
 	Hash   Type = "hash"
+	ZSet   Type = "zset"
+	List   Type = "list"
+)
+
+// entryOverhead and memberOverhead are the approximate bytes used by the bookkeeping of a key
+// and of a member or field inside a set or hash, they are only used to estimate memory usage.
+const (
+	entryOverhead  = 64
+	memberOverhead = 16
 )
 
 type trackable struct {
 	createdAt   time.Time
 	expiredTime time.Duration
+	accessedAt  atomic.Int64
+	hits        atomic.Uint64
+	accounted   int64
+}
+
+func (e *trackable) Touch() {
+	e.accessedAt.Store(time.Now().UnixNano())
+	e.hits.Add(1)
 }
 
+func (e *trackable) GetAccessedAt() int64 { return e.accessedAt.Load() }
+
+func (e *trackable) GetHits() uint64 { return e.hits.Load() }
+
+// getAccountedSize and setAccountedSize record the size counted into the accessor, they must be
+// called with the lock of the accessor held.
+func (e *trackable) getAccountedSize() int64 { return e.accounted }
+
+func (e *trackable) setAccountedSize(size int64) { e.accounted = size }
+
 func (e *trackable) GetExpiredAt() time.Time {
 	if e.expiredTime < 0 || e.createdAt.IsZero() {
 		return time.Time{}
@@ -55,6 +86,12 @@ type entry interface {
 	IsExpired() bool
 	SetExpireTime(expiredTime time.Duration)
 	GetExpireTime() time.Duration
+	Touch()
+	GetAccessedAt() int64
+	GetHits() uint64
+	Size() int64
+	getAccountedSize() int64
+	setAccountedSize(size int64)
 }
 
 type counterEntry struct {
@@ -73,6 +110,8 @@ func (e *counterEntry) Sub(delta int64) { e.mtx.Lock(); defer e.mtx.Unlock(); e.
 
 func (e *counterEntry) Set(val int64) { e.mtx.Lock(); defer e.mtx.Unlock(); e.val = val }
 
+func (e *counterEntry) Size() int64 { return 8 }
+
 func newCounterEntry(val int64) entry { return &counterEntry{val: val} }
 
 type stringEntry struct {
@@ -84,40 +123,59 @@ func (e *stringEntry) Type() Type { return String }
 
 func (e *stringEntry) Value() string { return e.val }
 
+func (e *stringEntry) Size() int64 { return int64(len(e.val)) }
+
 func newStringEntry(val string) entry { return &stringEntry{val: val} }
 
 type setEntry struct {
 	trackable
-	mtx sync.RWMutex
-	val map[string]struct{}
+	mtx  sync.RWMutex
+	val  map[string]struct{}
+	size int64
 }
 
 func (e *setEntry) Type() Type { return Set }
 
+func (e *setEntry) Size() int64 { e.mtx.RLock(); defer e.mtx.RUnlock(); return e.size }
+
+func (e *setEntry) addMember(key string) {
+	if _, exist := e.val[key]; !exist {
+		e.val[key] = struct{}{}
+		e.size += int64(len(key)) + memberOverhead
+	}
+}
+
+func (e *setEntry) removeMember(key string) {
+	if _, exist := e.val[key]; exist {
+		delete(e.val, key)
+		e.size -= int64(len(key)) + memberOverhead
+	}
+}
+
 func (e *setEntry) AddMember(key string) {
 	e.mtx.Lock()
-	e.val[key] = struct{}{}
+	e.addMember(key)
 	e.mtx.Unlock()
 }
 
 func (e *setEntry) AddMembers(keys ...string) {
 	e.mtx.Lock()
 	for _, key := range keys {
-		e.val[key] = struct{}{}
+		e.addMember(key)
 	}
 	e.mtx.Unlock()
 }
 
 func (e *setEntry) RemoveMember(key string) {
 	e.mtx.Lock()
-	delete(e.val, key)
+	e.removeMember(key)
 	e.mtx.Unlock()
 }
 
 func (e *setEntry) RemoveMembers(keys ...string) {
 	e.mtx.Lock()
 	for _, key := range keys {
-		delete(e.val, key)
+		e.removeMember(key)
 	}
 	e.mtx.Unlock()
 }
@@ -142,36 +200,55 @@ func newSetEntry() *setEntry { return &setEntry{mtx: sync.RWMutex{}, val: map[st
 
 type hashEntry struct {
 	trackable
-	mtx sync.RWMutex
-	val map[string]string
+	mtx  sync.RWMutex
+	val  map[string]string
+	size int64
 }
 
 func (e *hashEntry) Type() Type { return Hash }
 
+func (e *hashEntry) Size() int64 { e.mtx.RLock(); defer e.mtx.RUnlock(); return e.size }
+
+func (e *hashEntry) addField(field, value string) {
+	if old, exist := e.val[field]; exist {
+		e.size += int64(len(value) - len(old))
+	} else {
+		e.size += int64(len(field)+len(value)) + memberOverhead
+	}
+	e.val[field] = value
+}
+
+func (e *hashEntry) removeField(field string) {
+	if old, exist := e.val[field]; exist {
+		delete(e.val, field)
+		e.size -= int64(len(field)+len(old)) + memberOverhead
+	}
+}
+
 func (e *hashEntry) AddField(field, value string) {
 	e.mtx.Lock()
-	e.val[field] = value
+	e.addField(field, value)
 	e.mtx.Unlock()
 }
 
 func (e *hashEntry) AddFields(fields map[string]string) {
 	e.mtx.Lock()
 	for field, value := range fields {
-		e.val[field] = value
+		e.addField(field, value)
 	}
 	e.mtx.Unlock()
 }
 
 func (e *hashEntry) RemoveField(field string) {
 	e.mtx.Lock()
-	delete(e.val, field)
+	e.removeField(field)
 	e.mtx.Unlock()
 }
 
 func (e *hashEntry) RemoveFields(fields ...string) {
 	e.mtx.Lock()
 	for _, field := range fields {
-		delete(e.val, field)
+		e.removeField(field)
 	}
 	e.mtx.Unlock()
 }
@@ -211,3 +288,295 @@ func (e *hashEntry) GetAllFields() (resultMap map[string]string) {
 }
 
 func newHashEntry() *hashEntry { return &hashEntry{mtx: sync.RWMutex{}, val: map[string]string{}} }
+
+type zsetEntry struct {
+	trackable
+	mtx  sync.RWMutex
+	dict map[string]float64
+	sl   *skiplist
+	size int64
+}
+
+func (e *zsetEntry) Type() Type { return ZSet }
+
+func (e *zsetEntry) Size() int64 { e.mtx.RLock(); defer e.mtx.RUnlock(); return e.size }
+
+func (e *zsetEntry) setScore(member string, score float64) (added bool) {
+	if old, exist := e.dict[member]; exist {
+		if old != score {
+			e.sl.delete(old, member)
+			e.sl.insert(score, member)
+			e.dict[member] = score
+		}
+
+		return false
+	}
+
+	e.sl.insert(score, member)
+	e.dict[member] = score
+	e.size += int64(len(member)) + memberOverhead + 8
+	return true
+}
+
+func (e *zsetEntry) removeMember(member string) (removed bool) {
+	score, exist := e.dict[member]
+	if !exist {
+		return false
+	}
+
+	e.sl.delete(score, member)
+	delete(e.dict, member)
+	e.size -= int64(len(member)) + memberOverhead + 8
+	return true
+}
+
+// normalizeRange converts the start and stop index of redis style, which can be negative, to
+// 1-based ranks of the skiplist, ok is false if the range is empty.
+func (e *zsetEntry) normalizeRange(start, stop int64) (from, to int64, ok bool) {
+	length := e.sl.length
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+
+	return start + 1, stop + 1, true
+}
+
+func (e *zsetEntry) AddMembers(members ...cache.ZMember) (added int64) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	for _, member := range members {
+		if e.setScore(member.Member, member.Score) {
+			added++
+		}
+	}
+
+	return added
+}
+
+func (e *zsetEntry) IncrBy(member string, increment float64) (score float64) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	score = e.dict[member] + increment
+	e.setScore(member, score)
+	return score
+}
+
+func (e *zsetEntry) Score(member string) (score float64, exist bool) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	score, exist = e.dict[member]
+	return score, exist
+}
+
+// Rank returns the 0-based rank of the member, ordered from the lowest score when reverse is
+// false and from the highest score when reverse is true.
+func (e *zsetEntry) Rank(member string, reverse bool) (rank int64, exist bool) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	score, exist := e.dict[member]
+	if !exist {
+		return 0, false
+	}
+
+	rank = e.sl.rank(score, member)
+	if reverse {
+		return e.sl.length - rank, true
+	}
+
+	return rank - 1, true
+}
+
+func (e *zsetEntry) Range(start, stop int64, reverse bool) (members []cache.ZMember) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	if reverse {
+		// 逆序时，将下标转换为正序的下标
+		start, stop = -stop-1, -start-1
+	}
+	from, to, ok := e.normalizeRange(start, stop)
+	if !ok {
+		return []cache.ZMember{}
+	}
+
+	members = make([]cache.ZMember, 0, to-from+1)
+	for node, rank := e.sl.byRank(from), from; node != nil && rank <= to; node, rank = node.level[0].forward, rank+1 {
+		members = append(members, cache.ZMember{Member: node.member, Score: node.score})
+	}
+	if reverse {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
+
+	return members
+}
+
+func (e *zsetEntry) RangeByScore(min, max float64, offset, count int64) (members []cache.ZMember) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	members = []cache.ZMember{}
+	for node := e.sl.firstFrom(min); node != nil && node.score <= max; node = node.level[0].forward {
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if count > 0 && int64(len(members)) >= count {
+			break
+		}
+
+		members = append(members, cache.ZMember{Member: node.member, Score: node.score})
+	}
+
+	return members
+}
+
+func (e *zsetEntry) RemoveMembers(members ...string) (removed int64) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	for _, member := range members {
+		if e.removeMember(member) {
+			removed++
+		}
+	}
+
+	return removed
+}
+
+func (e *zsetEntry) Card() int64 {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.sl.length
+}
+
+// Pop removes and returns at most count members with the lowest scores, or with the highest
+// scores when max is true.
+func (e *zsetEntry) Pop(count int64, max bool) (members []cache.ZMember) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	members = []cache.ZMember{}
+	for int64(len(members)) < count && e.sl.length > 0 {
+		node := e.sl.header.level[0].forward
+		if max {
+			node = e.sl.tail
+		}
+
+		members = append(members, cache.ZMember{Member: node.member, Score: node.score})
+		e.removeMember(node.member)
+	}
+
+	return members
+}
+
+func newZSetEntry() *zsetEntry {
+	return &zsetEntry{mtx: sync.RWMutex{}, dict: map[string]float64{}, sl: newSkiplist()}
+}
+
+type listEntry struct {
+	trackable
+	mtx  sync.RWMutex
+	val  *list.List
+	size int64
+}
+
+func (e *listEntry) Type() Type { return List }
+
+func (e *listEntry) Size() int64 { e.mtx.RLock(); defer e.mtx.RUnlock(); return e.size }
+
+func (e *listEntry) PushFront(values ...string) (length int64) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	for _, value := range values {
+		e.val.PushFront(value)
+		e.size += int64(len(value)) + memberOverhead
+	}
+
+	return int64(e.val.Len())
+}
+
+func (e *listEntry) PushBack(values ...string) (length int64) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	for _, value := range values {
+		e.val.PushBack(value)
+		e.size += int64(len(value)) + memberOverhead
+	}
+
+	return int64(e.val.Len())
+}
+
+func (e *listEntry) pop(element *list.Element) (value string, exist bool) {
+	if element == nil {
+		return "", false
+	}
+
+	value = e.val.Remove(element).(string)
+	e.size -= int64(len(value)) + memberOverhead
+	return value, true
+}
+
+func (e *listEntry) PopFront() (value string, exist bool) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.pop(e.val.Front())
+}
+
+func (e *listEntry) PopBack() (value string, exist bool) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.pop(e.val.Back())
+}
+
+// Range returns the values between start and stop, both are inclusive and can be negative to
+// count from the end of the list, the same as LRANGE of redis.
+func (e *listEntry) Range(start, stop int64) (values []string) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	length := int64(e.val.Len())
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return []string{}
+	}
+
+	values = make([]string, 0, stop-start+1)
+	element := e.val.Front()
+	for i := int64(0); i < start; i++ {
+		element = element.Next()
+	}
+	for i := start; i <= stop; i++ {
+		values = append(values, element.Value.(string))
+		element = element.Next()
+	}
+
+	return values
+}
+
+func (e *listEntry) Len() int64 {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return int64(e.val.Len())
+}
+
+func newListEntry() *listEntry { return &listEntry{mtx: sync.RWMutex{}, val: list.New()} }
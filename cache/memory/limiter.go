@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/limiter"
+	"github.com/google/uuid"
+)
+
+// Take implements limiter.Storage with the same algorithms and state layout as the redis driver,
+// the state is kept in counters, sorted sets and hashes so that it is visible to other commands.
+func (ca *accessor) Take(_ context.Context, key string, rule limiter.Rule, n int64) (result limiter.Result, err error) {
+	if validateErr := rule.Validate(); validateErr != nil {
+		return limiter.Result{}, validateErr
+	}
+
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	now, window := time.Now().UnixMilli(), rule.Window.Milliseconds()
+	switch rule.Algorithm {
+	case limiter.AlgorithmFixedWindow:
+		return ca.takeFixedWindow(key, rule.Limit, window, n)
+	case limiter.AlgorithmSlidingLog:
+		return ca.takeSlidingLog(key, rule.Limit, window, n, now)
+	case limiter.AlgorithmSlidingWindow:
+		return ca.takeSlidingWindow(key, rule.Limit, window, n, now)
+	default:
+		return ca.takeTokenBucket(key, rule.Limit, window, n, now)
+	}
+}
+
+func (ca *accessor) takeFixedWindow(key string, limit, window, n int64) (result limiter.Result, err error) {
+	existEntry, exist := ca.lookup(key)
+	counter, current := newCounterEntry(0).(*counterEntry), int64(0)
+	if exist {
+		existCounter, isCounter := existEntry.(*counterEntry)
+		if !isCounter {
+			return limiter.Result{}, fmt.Errorf("take quota of key %s: %w", key, NewValueTypeNotMatchError(Int, existEntry.Type()))
+		}
+		counter, current = existCounter, existCounter.Value()
+	}
+
+	if current+n > limit {
+		// 当前窗口的配额不足，等待窗口结束
+		retryAfter := time.Duration(window) * time.Millisecond
+		if expireTime := counter.GetExpireTime(); exist && expireTime > 0 {
+			retryAfter = expireTime
+		}
+		return limiter.Result{Remaining: max(limit-current, 0), RetryAfter: retryAfter}, nil
+	}
+
+	counter.Add(n)
+	if !exist || counter.GetExpireTime() <= 0 {
+		// 第一个请求开始一个新的窗口
+		counter.SetExpireTime(time.Duration(window) * time.Millisecond)
+	}
+	ca.put(key, counter)
+	return limiter.Result{Allowed: true, Remaining: limit - current - n}, nil
+}
+
+func (ca *accessor) takeSlidingLog(key string, limit, window, n, now int64) (result limiter.Result, err error) {
+	existEntry, exist := ca.lookup(key)
+	log := newZSetEntry()
+	if exist {
+		existLog, isZSet := existEntry.(*zsetEntry)
+		if !isZSet {
+			return limiter.Result{}, fmt.Errorf("take quota of key %s: %w", key, NewValueTypeNotMatchError(ZSet, existEntry.Type()))
+		}
+		log = existLog
+	}
+
+	// 移除已经离开窗口的请求记录
+	for _, member := range log.RangeByScore(math.Inf(-1), float64(now-window), 0, 0) {
+		log.RemoveMembers(member.Member)
+	}
+
+	count := log.Card()
+	if count+n > limit {
+		// 等待足够多的请求记录离开窗口
+		retryAfter := window
+		if oldest := log.Range(count+n-limit-1, count+n-limit-1, false); len(oldest) > 0 {
+			retryAfter = int64(oldest[0].Score) + window - now
+		}
+		if count == 0 {
+			ca.remove(key)
+		} else {
+			ca.put(key, log)
+		}
+		return limiter.Result{Remaining: max(limit-count, 0), RetryAfter: time.Duration(max(retryAfter, 1)) * time.Millisecond}, nil
+	}
+
+	prefix, members := uuid.NewString(), make([]cache.ZMember, 0, n)
+	for i := int64(1); i <= n; i++ {
+		members = append(members, cache.ZMember{Member: prefix + ":" + strconv.FormatInt(i, 10), Score: float64(now)})
+	}
+	log.AddMembers(members...)
+	log.SetExpireTime(time.Duration(window) * time.Millisecond)
+	ca.put(key, log)
+	return limiter.Result{Allowed: true, Remaining: limit - count - n}, nil
+}
+
+func (ca *accessor) takeSlidingWindow(key string, limit, window, n, now int64) (result limiter.Result, err error) {
+	existEntry, exist := ca.lookup(key)
+	state := newHashEntry()
+	if exist {
+		existState, isHash := existEntry.(*hashEntry)
+		if !isHash {
+			return limiter.Result{}, fmt.Errorf("take quota of key %s: %w", key, NewValueTypeNotMatchError(Hash, existEntry.Type()))
+		}
+		state = existState
+	}
+
+	index, elapsed := now/window, now%window
+	fields := state.GetFields("window", "current", "previous")
+	storedIndex, _ := strconv.ParseInt(fields["window"], 10, 64)
+	current, _ := strconv.ParseInt(fields["current"], 10, 64)
+	previous, _ := strconv.ParseInt(fields["previous"], 10, 64)
+	if storedIndex != index {
+		// 进入新的窗口，只有相邻的上一个窗口的计数才会被加权
+		if storedIndex == index-1 {
+			previous = current
+		} else {
+			previous = 0
+		}
+		current = 0
+	}
+
+	estimated := float64(previous)*float64(window-elapsed)/float64(window) + float64(current)
+	if estimated+float64(n) > float64(limit) {
+		var retryAfter int64
+		if current+n <= limit {
+			// 等待上一个窗口的权重降低到足够小
+			retryAfter = int64(math.Ceil(float64(window)*(1-float64(limit-current-n)/float64(previous)))) - elapsed
+		} else {
+			// 等待下一个窗口，并等待当前窗口的计数作为上一个窗口时权重降低到足够小
+			retryAfter = window - elapsed
+			if current > 0 {
+				retryAfter += max(int64(math.Ceil(float64(window)*(1-float64(limit-n)/float64(current)))), 0)
+			}
+		}
+		return limiter.Result{Remaining: max(int64(float64(limit)-estimated), 0), RetryAfter: time.Duration(max(retryAfter, 1)) * time.Millisecond}, nil
+	}
+
+	state.AddFields(map[string]string{
+		"window":   strconv.FormatInt(index, 10),
+		"current":  strconv.FormatInt(current+n, 10),
+		"previous": strconv.FormatInt(previous, 10),
+	})
+	state.SetExpireTime(time.Duration(window*2) * time.Millisecond)
+	ca.put(key, state)
+	return limiter.Result{Allowed: true, Remaining: int64(float64(limit) - estimated - float64(n))}, nil
+}
+
+func (ca *accessor) takeTokenBucket(key string, limit, window, n, now int64) (result limiter.Result, err error) {
+	existEntry, exist := ca.lookup(key)
+	state := newHashEntry()
+	if exist {
+		existState, isHash := existEntry.(*hashEntry)
+		if !isHash {
+			return limiter.Result{}, fmt.Errorf("take quota of key %s: %w", key, NewValueTypeNotMatchError(Hash, existEntry.Type()))
+		}
+		state = existState
+	}
+
+	// 每毫秒补充的令牌数量，桶在经过window后被补满
+	rate, tokens, timestamp := float64(limit)/float64(window), float64(limit), now
+	fields := state.GetFields("tokens", "timestamp")
+	if storedTokens, parseErr := strconv.ParseFloat(fields["tokens"], 64); parseErr == nil {
+		if storedTimestamp, parseErr := strconv.ParseInt(fields["timestamp"], 10, 64); parseErr == nil {
+			tokens, timestamp = storedTokens, storedTimestamp
+		}
+	}
+	tokens = math.Min(float64(limit), tokens+float64(max(now-timestamp, 0))*rate)
+
+	if tokens < float64(n) {
+		retryAfter := int64(math.Ceil((float64(n) - tokens) / rate))
+		return limiter.Result{Remaining: int64(tokens), RetryAfter: time.Duration(max(retryAfter, 1)) * time.Millisecond}, nil
+	}
+
+	tokens -= float64(n)
+	state.AddFields(map[string]string{
+		"tokens":    strconv.FormatFloat(tokens, 'g', 17, 64),
+		"timestamp": strconv.FormatInt(now, 10),
+	})
+	state.SetExpireTime(time.Duration(window) * time.Millisecond)
+	ca.put(key, state)
+	return limiter.Result{Allowed: true, Remaining: int64(tokens)}, nil
+}
@@ -0,0 +1,295 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+var BaseLoaderUnitTestCaseList = []TestCase[cache.Cache]{
+	{
+		CaseName:     "GetOrLoad",
+		TestFunction: GetOrLoadFunction,
+	},
+	{
+		CaseName:     "GetOrLoadSingleflight",
+		TestFunction: GetOrLoadSingleflightFunction,
+	},
+	{
+		CaseName:     "GetOrLoadCancelled",
+		TestFunction: GetOrLoadCancelledFunction,
+	},
+	{
+		CaseName:     "GetOrLoadWithLocker",
+		TestFunction: GetOrLoadWithLockerFunction,
+	},
+	{
+		CaseName:     "GetOrLoadNegative",
+		TestFunction: GetOrLoadNegativeFunction,
+	},
+	{
+		CaseName:     "GetOrLoadEarlyRefresh",
+		TestFunction: GetOrLoadEarlyRefreshFunction,
+	},
+	{
+		CaseName:     "GetOrLoadNoExpire",
+		TestFunction: GetOrLoadNoExpireFunction,
+	},
+	{
+		CaseName:     "GetOrLoadJson",
+		TestFunction: GetOrLoadJsonFunction,
+	},
+}
+
+func GetOrLoadFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, key, calls := context.Background(), "GetOrLoadFunction", int64(0)
+		_ = impl.Delete(ctx, key)
+		loader := cache.NewLoader(impl)
+		load := func() (string, error) {
+			atomic.AddInt64(&calls, 1)
+			return "value", nil
+		}
+
+		for i := 0; i < 3; i++ {
+			value, err := loader.GetOrLoad(ctx, key, time.Minute, load)
+			if err != nil || value != "value" {
+				t.Fatalf("GetOrLoadFunction case failed: got %s, %v", value, err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("GetOrLoadFunction case failed: loader called %d times", calls)
+		}
+
+		// 加载失败的结果不会被缓存
+		failed := errors.New("failed")
+		_, err := loader.GetOrLoad(ctx, key+":failed", time.Minute, func() (string, error) { return "", failed })
+		if !errors.Is(err, failed) {
+			t.Errorf("GetOrLoadFunction case failed: expected loader error, got %v", err)
+		}
+		if exist, _ := impl.ExistKey(ctx, key+":failed"); exist {
+			t.Error("GetOrLoadFunction case failed: error result is cached")
+		}
+	}
+}
+
+func GetOrLoadSingleflightFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, key, calls := context.Background(), "GetOrLoadSingleflightFunction", int64(0)
+		_ = impl.Delete(ctx, key)
+		loader := cache.NewLoader(impl)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := loader.GetOrLoad(ctx, key, time.Minute, func() (string, error) {
+					atomic.AddInt64(&calls, 1)
+					time.Sleep(time.Millisecond * 100)
+					return "value", nil
+				})
+				if err != nil || value != "value" {
+					t.Errorf("GetOrLoadSingleflightFunction case failed: got %s, %v", value, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls != 1 {
+			t.Errorf("GetOrLoadSingleflightFunction case failed: loader called %d times", calls)
+		}
+	}
+}
+
+func GetOrLoadCancelledFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		key := "GetOrLoadCancelledFunction"
+		_ = impl.Delete(context.Background(), key)
+		loader := cache.NewLoader(impl)
+		load := func() (string, error) {
+			time.Sleep(time.Millisecond * 200)
+			return "value", nil
+		}
+
+		// 第一个调用方取消后，不影响等待同一个加载的其他调用方
+		cancelledCtx, cancel := context.WithCancel(context.Background())
+		cancelled := make(chan error, 1)
+		go func() {
+			_, err := loader.GetOrLoad(cancelledCtx, key, time.Minute, load)
+			cancelled <- err
+		}()
+		time.Sleep(time.Millisecond * 50)
+
+		waited := make(chan error, 1)
+		go func() {
+			value, err := loader.GetOrLoad(context.Background(), key, time.Minute, load)
+			if err == nil && value != "value" {
+				err = errors.New("unexpected value " + value)
+			}
+			waited <- err
+		}()
+		time.Sleep(time.Millisecond * 50)
+		cancel()
+
+		if err := <-cancelled; !errors.Is(err, context.Canceled) {
+			t.Errorf("GetOrLoadCancelledFunction case failed: expected cancelled, got %v", err)
+		}
+		if err := <-waited; err != nil {
+			t.Errorf("GetOrLoadCancelledFunction case failed: waiter got %v", err)
+		}
+		if exist, _ := impl.ExistKey(context.Background(), key); !exist {
+			t.Error("GetOrLoadCancelledFunction case failed: loaded value is not stored")
+		}
+	}
+}
+
+func GetOrLoadWithLockerFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, key, calls := context.Background(), "GetOrLoadWithLockerFunction", int64(0)
+		_ = impl.Delete(ctx, key)
+
+		// 每个加载器模拟一个进程，通过锁保证只有一个进程加载
+		wg := sync.WaitGroup{}
+		for i := 0; i < 5; i++ {
+			loader := cache.NewLoader(impl, cache.WithLockerOpts(impl.(cache.Locker), time.Second))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := loader.GetOrLoad(ctx, key, time.Minute, func() (string, error) {
+					atomic.AddInt64(&calls, 1)
+					time.Sleep(time.Millisecond * 100)
+					return "value", nil
+				})
+				if err != nil || value != "value" {
+					t.Errorf("GetOrLoadWithLockerFunction case failed: got %s, %v", value, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls != 1 {
+			t.Errorf("GetOrLoadWithLockerFunction case failed: loader called %d times", calls)
+		}
+	}
+}
+
+func GetOrLoadNegativeFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, key, calls := context.Background(), "GetOrLoadNegativeFunction", int64(0)
+		_ = impl.Delete(ctx, key)
+		loader := cache.NewLoader(impl, cache.WithNegativeCacheOpts(time.Millisecond*500))
+		load := func() (string, error) {
+			atomic.AddInt64(&calls, 1)
+			return "", cache.ErrNotFound
+		}
+
+		for i := 0; i < 3; i++ {
+			if _, err := loader.GetOrLoad(ctx, key, time.Minute, load); !errors.Is(err, cache.ErrNotFound) {
+				t.Fatalf("GetOrLoadNegativeFunction case failed: expected not found, got %v", err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("GetOrLoadNegativeFunction case failed: loader called %d times", calls)
+		}
+
+		// 不存在的结果过期后重新加载
+		time.Sleep(time.Millisecond * 600)
+		value, err := loader.GetOrLoad(ctx, key, time.Minute, func() (string, error) { return "value", nil })
+		if err != nil || value != "value" {
+			t.Errorf("GetOrLoadNegativeFunction case failed: got %s, %v", value, err)
+		}
+	}
+}
+
+func GetOrLoadEarlyRefreshFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, key, calls := context.Background(), "GetOrLoadEarlyRefreshFunction", int64(0)
+		_ = impl.Delete(ctx, key)
+		load := func() (string, error) {
+			time.Sleep(time.Millisecond * 20)
+			return "value", nil
+		}
+
+		// 没有开启提前刷新时，值在过期前不会重新加载
+		plain := cache.NewLoader(impl)
+		_, _ = plain.GetOrLoad(ctx, key, time.Minute, load)
+		_, _ = plain.GetOrLoad(ctx, key, time.Minute, func() (string, error) {
+			atomic.AddInt64(&calls, 1)
+			return "value", nil
+		})
+		if calls != 0 {
+			t.Fatalf("GetOrLoadEarlyRefreshFunction case failed: refreshed without early refresh")
+		}
+
+		// beta足够大时，加载耗时20ms的值几乎一定会被提前刷新
+		refreshing := cache.NewLoader(impl, cache.WithEarlyRefreshOpts(1e6))
+		value, err := refreshing.GetOrLoad(ctx, key, time.Minute, func() (string, error) {
+			atomic.AddInt64(&calls, 1)
+			return "refreshed", nil
+		})
+		if err != nil || value != "refreshed" || calls != 1 {
+			t.Errorf("GetOrLoadEarlyRefreshFunction case failed: got %s, %v, %d calls", value, err, calls)
+		}
+
+		// 提前刷新失败时返回缓存的值
+		_ = impl.Delete(ctx, key)
+		_, _ = refreshing.GetOrLoad(ctx, key, time.Minute, load)
+		value, err = refreshing.GetOrLoad(ctx, key, time.Minute, func() (string, error) { return "", errors.New("failed") })
+		if err != nil || value != "value" {
+			t.Errorf("GetOrLoadEarlyRefreshFunction case failed: got %s, %v", value, err)
+		}
+	}
+}
+
+func GetOrLoadNoExpireFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx, key := context.Background(), "GetOrLoadNoExpireFunction"
+		_ = impl.Delete(ctx, key)
+		loader := cache.NewLoader(impl)
+
+		// 非正数的过期时间视为永不过期
+		value, err := loader.GetOrLoad(ctx, key, 0, func() (string, error) { return "value", nil })
+		if err != nil || value != "value" {
+			t.Fatalf("GetOrLoadNoExpireFunction case failed: got %s, %v", value, err)
+		}
+		if exist, expiredAt, _ := impl.GetExpiredTime(ctx, key); !exist || !expiredAt.IsZero() {
+			t.Errorf("GetOrLoadNoExpireFunction case failed: exist %v, expired at %v", exist, expiredAt)
+		}
+		_ = impl.Delete(ctx, key)
+	}
+}
+
+func GetOrLoadJsonFunction(impl cache.Cache) func(t *testing.T) {
+	return func(t *testing.T) {
+		type user struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}
+
+		ctx, key := context.Background(), "GetOrLoadJsonFunction"
+		_ = impl.Delete(ctx, key)
+		loader := cache.NewLoader(impl)
+
+		for i := 0; i < 2; i++ {
+			receiver := user{}
+			err := loader.GetOrLoadJson(ctx, key, time.Minute, &receiver, func() (any, error) {
+				return user{Name: "alioth", Age: 18 + i}, nil
+			})
+			if err != nil || receiver.Name != "alioth" || receiver.Age != 18 {
+				t.Errorf("GetOrLoadJsonFunction case failed: got %+v, %v", receiver, err)
+			}
+		}
+	}
+}
+
+func RunLoaderTestCases(t *testing.T, impl cache.Cache) {
+	for _, v := range BaseLoaderUnitTestCaseList {
+		t.Run(v.CaseName, v.TestFunction(impl))
+	}
+}
@@ -0,0 +1,84 @@
+package memory
+
+// matchPattern reports whether the key matches the glob-style pattern, the syntax is the same as
+// the redis KEYS and SCAN commands: '*' matches any sequence of characters, '?' matches a single
+// character, "[abc]", "[^abc]" and "[a-z]" match a character class, and a backslash escapes the next
+// character.
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// 合并连续的*，并尝试匹配剩余的每一个后缀
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern, key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			pattern, key = pattern[1:], key[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			rest, matched := matchClass(pattern[1:], key[0])
+			if !matched {
+				return false
+			}
+			pattern, key = rest, key[1:]
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			pattern, key = pattern[1:], key[1:]
+		}
+	}
+
+	return len(key) == 0
+}
+
+// matchClass matches c with the character class following '[', it returns the pattern after the
+// closing ']'. An unclosed class extends to the end of the pattern, the same as redis.
+func matchClass(pattern string, c byte) (rest string, matched bool) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			matched = matched || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) >= 3 && pattern[1] == '-' && pattern[2] != ']':
+			low, high := pattern[0], pattern[2]
+			if low > high {
+				low, high = high, low
+			}
+			matched = matched || (c >= low && c <= high)
+			pattern = pattern[3:]
+		default:
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+
+	return pattern, matched != negate
+}
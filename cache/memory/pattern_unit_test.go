@@ -0,0 +1,34 @@
+package memory
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		key     string
+		matched bool
+	}{
+		{pattern: "*", key: "", matched: true},
+		{pattern: "user:*", key: "user:1:name", matched: true},
+		{pattern: "user:*", key: "order:1", matched: false},
+		{pattern: "user:*:name", key: "user:1:name", matched: true},
+		{pattern: "user:*:name", key: "user:1:age", matched: false},
+		{pattern: "h?llo", key: "hello", matched: true},
+		{pattern: "h?llo", key: "hllo", matched: false},
+		{pattern: "h[ae]llo", key: "hallo", matched: true},
+		{pattern: "h[ae]llo", key: "hillo", matched: false},
+		{pattern: "h[^e]llo", key: "hallo", matched: true},
+		{pattern: "h[^e]llo", key: "hello", matched: false},
+		{pattern: "h[a-c]llo", key: "hbllo", matched: true},
+		{pattern: "h[a-c]llo", key: "hdllo", matched: false},
+		{pattern: `h\*llo`, key: "h*llo", matched: true},
+		{pattern: `h\*llo`, key: "hello", matched: false},
+		{pattern: "**a", key: "bba", matched: true},
+	}
+
+	for _, c := range cases {
+		if matched := matchPattern(c.pattern, c.key); matched != c.matched {
+			t.Errorf("pattern %q with key %q matched %v, want %v", c.pattern, c.key, matched, c.matched)
+		}
+	}
+}
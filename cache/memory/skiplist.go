@@ -0,0 +1,180 @@
+package memory
+
+import "math/rand"
+
+const (
+	skiplistMaxLevel    = 32
+	skiplistProbability = 0.25
+)
+
+type skiplistLevel struct {
+	forward *skiplistNode
+	span    int64
+}
+
+type skiplistNode struct {
+	member   string
+	score    float64
+	backward *skiplistNode
+	level    []skiplistLevel
+}
+
+// before reports whether the node is ordered before (score, member), nodes are ordered by score
+// and then by member, the same as redis sorted sets.
+func (n *skiplistNode) before(score float64, member string) bool {
+	return n.score < score || (n.score == score && n.member < member)
+}
+
+// skiplist is the ordered index of a sorted set, every level records the span between nodes so
+// that ranks can be calculated in O(log n).
+type skiplist struct {
+	header *skiplistNode
+	tail   *skiplistNode
+	length int64
+	level  int
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		header: &skiplistNode{level: make([]skiplistLevel, skiplistMaxLevel)},
+		level:  1,
+	}
+}
+
+func randomSkiplistLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.Float64() < skiplistProbability {
+		level++
+	}
+
+	return level
+}
+
+// insert adds a new node, the caller must make sure the member does not exist.
+func (sl *skiplist) insert(score float64, member string) {
+	update, rank := [skiplistMaxLevel]*skiplistNode{}, [skiplistMaxLevel]int64{}
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		if i != sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomSkiplistLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			rank[i], update[i] = 0, sl.header
+			update[i].level[i].span = sl.length
+		}
+		sl.level = level
+	}
+
+	x = &skiplistNode{member: member, score: score, level: make([]skiplistLevel, level)}
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < sl.level; i++ {
+		update[i].level[i].span++
+	}
+
+	if update[0] != sl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+	sl.length++
+}
+
+// delete removes the node of (score, member), it returns false if the node does not exist.
+func (sl *skiplist) delete(score float64, member string) bool {
+	update := [skiplistMaxLevel]*skiplistNode{}
+
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.level[0].forward
+	if x == nil || x.score != score || x.member != member {
+		return false
+	}
+
+	for i := 0; i < sl.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+	for sl.level > 1 && sl.header.level[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+
+	return true
+}
+
+// rank returns the 1-based rank of (score, member), or 0 if the node does not exist.
+func (sl *skiplist) rank(score float64, member string) int64 {
+	rank, x := int64(0), sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && (x.level[i].forward.before(score, member) || (x.level[i].forward.score == score && x.level[i].forward.member == member)) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+		if x != sl.header && x.score == score && x.member == member {
+			return rank
+		}
+	}
+
+	return 0
+}
+
+// byRank returns the node with the 1-based rank, or nil if the rank is out of range.
+func (sl *skiplist) byRank(rank int64) *skiplistNode {
+	traversed, x := int64(0), sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+
+	return nil
+}
+
+// firstFrom returns the first node whose score is not less than min, or nil if there is none.
+func (sl *skiplist) firstFrom(min float64) *skiplistNode {
+	x := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.score < min {
+			x = x.level[i].forward
+		}
+	}
+
+	return x.level[0].forward
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+// snapshotVersion is the version of the snapshot format written by current code, increase it and
+// keep the loader of older versions when the format changes.
+const snapshotVersion = 1
+
+// ErrSnapshotVersionNotSupported is returned when loading a snapshot written by a newer version.
+var ErrSnapshotVersionNotSupported = errors.New("snapshot version is not supported")
+
+type snapshot struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Entries   []snapshotEntry `json:"entries"`
+}
+
+// snapshotEntry records one key, only the field of its type is set. ExpiredAt is the absolute
+// expiration time, so the time spent while the process is down is also counted.
+type snapshotEntry struct {
+	Key       string            `json:"key"`
+	Type      Type              `json:"type"`
+	ExpiredAt time.Time         `json:"expired_at"`
+	Counter   int64             `json:"counter,omitempty"`
+	String    string            `json:"string,omitempty"`
+	Members   []string          `json:"members,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	ZMembers  []cache.ZMember   `json:"z_members,omitempty"`
+}
+
+func newSnapshotEntry(key string, value entry) (result snapshotEntry, ok bool) {
+	result = snapshotEntry{Key: key, Type: value.Type(), ExpiredAt: value.GetExpiredAt()}
+	switch typed := value.(type) {
+	case *counterEntry:
+		result.Counter = typed.Value()
+	case *stringEntry:
+		result.String = typed.Value()
+	case *setEntry:
+		result.Members = typed.Members()
+	case *hashEntry:
+		result.Fields = typed.GetAllFields()
+	case *zsetEntry:
+		result.ZMembers = typed.Range(0, -1, false)
+	case *listEntry:
+		result.Members = typed.Range(0, -1)
+	default:
+		return snapshotEntry{}, false
+	}
+
+	return result, true
+}
+
+func (se snapshotEntry) restore() (result entry, ok bool) {
+	switch se.Type {
+	case Int:
+		result = newCounterEntry(se.Counter)
+	case String:
+		result = newStringEntry(se.String)
+	case Set:
+		set := newSetEntry()
+		set.AddMembers(se.Members...)
+		result = set
+	case Hash:
+		hash := newHashEntry()
+		hash.AddFields(se.Fields)
+		result = hash
+	case ZSet:
+		zset := newZSetEntry()
+		zset.AddMembers(se.ZMembers...)
+		result = zset
+	case List:
+		values := newListEntry()
+		values.PushBack(se.Members...)
+		result = values
+	default:
+		return nil, false
+	}
+
+	if !se.ExpiredAt.IsZero() {
+		result.SetExpireTime(time.Until(se.ExpiredAt))
+	}
+	return result, true
+}
+
+// saveSnapshot writes all the keys which are not expired to path, the file is written to a
+// temporary file first and then renamed, so that a crash never leaves a broken snapshot.
+func (ca *accessor) saveSnapshot(path string) (err error) {
+	data := snapshot{Version: snapshotVersion, CreatedAt: time.Now()}
+
+	ca.mtx.RLock()
+	data.Entries = make([]snapshotEntry, 0, len(ca.db))
+	for key, value := range ca.db {
+		if value.IsExpired() {
+			continue
+		}
+		if se, ok := newSnapshotEntry(key, value); ok {
+			data.Entries = append(data.Entries, se)
+		}
+	}
+	ca.mtx.RUnlock()
+
+	temporary, createErr := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if createErr != nil {
+		return fmt.Errorf("create snapshot file of %s: %w", path, createErr)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(temporary.Name())
+		}
+	}()
+
+	writer := bufio.NewWriter(temporary)
+	if encodeErr := json.NewEncoder(writer).Encode(data); encodeErr != nil {
+		_ = temporary.Close()
+		return fmt.Errorf("encode snapshot of %s: %w", path, encodeErr)
+	}
+	if flushErr := writer.Flush(); flushErr != nil {
+		_ = temporary.Close()
+		return fmt.Errorf("write snapshot of %s: %w", path, flushErr)
+	}
+	if closeErr := temporary.Close(); closeErr != nil {
+		return fmt.Errorf("write snapshot of %s: %w", path, closeErr)
+	}
+	if renameErr := os.Rename(temporary.Name(), path); renameErr != nil {
+		return fmt.Errorf("replace snapshot of %s: %w", path, renameErr)
+	}
+
+	return nil
+}
+
+// loadSnapshot restores the keys from path, the keys expired while the process is down are
+// skipped, and a missing file is not an error.
+func (ca *accessor) loadSnapshot(path string) (loaded int, err error) {
+	file, openErr := os.Open(path)
+	if errors.Is(openErr, os.ErrNotExist) {
+		return 0, nil
+	} else if openErr != nil {
+		return 0, fmt.Errorf("open snapshot of %s: %w", path, openErr)
+	}
+	defer func() { _ = file.Close() }()
+
+	data := snapshot{}
+	if decodeErr := json.NewDecoder(bufio.NewReader(file)).Decode(&data); decodeErr != nil {
+		return 0, fmt.Errorf("decode snapshot of %s: %w", path, decodeErr)
+	}
+	if data.Version <= 0 || data.Version > snapshotVersion {
+		return 0, fmt.Errorf("load snapshot of %s with version %d: %w", path, data.Version, ErrSnapshotVersionNotSupported)
+	}
+
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+	for _, se := range data.Entries {
+		if !se.ExpiredAt.IsZero() && !se.ExpiredAt.After(time.Now()) {
+			continue
+		}
+		if restored, ok := se.restore(); ok {
+			ca.put(se.Key, restored)
+			loaded++
+		}
+	}
+
+	return loaded, nil
+}
+
+// snapshotPeriodically saves the snapshot every interval until the exit channel is closed.
+func (ca *accessor) snapshotPeriodically(path string, interval time.Duration, exit chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-exit:
+			return
+		case <-ticker.C:
+			if saveErr := ca.saveSnapshot(path); saveErr != nil {
+				fmt.Println("failed to save memory cache snapshot:", saveErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+func TestSnapshot(t *testing.T) {
+	// 保存后重新创建缓存，所有类型的值和过期时间都被恢复
+	t.Run("Snapshot:Restore", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.snapshot")
+		ctx, impl := context.Background(), newCache(Config{})
+		impl.Increase(ctx, "counter", 3)
+		_ = impl.StoreEX(ctx, "string", "value", time.Hour)
+		_ = impl.StoreEX(ctx, "expired", "value", time.Millisecond*50)
+		_ = impl.AddMembers(ctx, "set", "a", "b")
+		_ = impl.HSetValues(ctx, "hash", map[string]string{"field": "value"})
+		_, _ = impl.ZAdd(ctx, "zset", cache.ZMember{Member: "a", Score: 1}, cache.ZMember{Member: "b", Score: 2})
+		_, _ = impl.RPush(ctx, "list", "a", "b", "c")
+		if saveErr := impl.saveSnapshot(path); saveErr != nil {
+			t.Fatalf("Snapshot:Restore case failed when saving: %v", saveErr)
+		}
+
+		time.Sleep(time.Millisecond * 100)
+		restored := newCache(Config{SnapshotPath: path})
+		if value, _ := restored.Get(ctx, "counter"); value != 3 {
+			t.Errorf("Snapshot:Restore case failed: counter %d", value)
+		}
+		if _, expiredTime, value, _ := restored.LoadWithEX(ctx, "string"); value != "value" || expiredTime <= time.Minute*59 {
+			t.Errorf("Snapshot:Restore case failed: string %s, expired time %v", value, expiredTime)
+		}
+		if exist, _ := restored.ExistKey(ctx, "expired"); exist {
+			t.Errorf("Snapshot:Restore case failed: expired key is restored")
+		}
+		members, _ := restored.GetMembers(ctx, "set")
+		if sort.Strings(members); !reflect.DeepEqual(members, []string{"a", "b"}) {
+			t.Errorf("Snapshot:Restore case failed: set %v", members)
+		}
+		if exist, value, _ := restored.HGetValue(ctx, "hash", "field"); !exist || value != "value" {
+			t.Errorf("Snapshot:Restore case failed: hash %s", value)
+		}
+		if zMembers, _ := restored.ZRange(ctx, "zset", 0, -1); len(zMembers) != 2 || zMembers[1] != (cache.ZMember{Member: "b", Score: 2}) {
+			t.Errorf("Snapshot:Restore case failed: zset %v", zMembers)
+		}
+		if values, _ := restored.LRange(ctx, "list", 0, -1); !reflect.DeepEqual(values, []string{"a", "b", "c"}) {
+			t.Errorf("Snapshot:Restore case failed: list %v", values)
+		}
+	})
+
+	// 文件不存在时以空缓存启动
+	t.Run("Snapshot:NotExists", func(t *testing.T) {
+		loaded, loadErr := newCache(Config{}).loadSnapshot(filepath.Join(t.TempDir(), "not-exists"))
+		if loadErr != nil || loaded != 0 {
+			t.Errorf("Snapshot:NotExists case failed: loaded %d, error %v", loaded, loadErr)
+		}
+	})
+
+	// 不支持更新版本的快照
+	t.Run("Snapshot:Version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.snapshot")
+		if writeErr := os.WriteFile(path, []byte(`{"version":999,"entries":[]}`), 0o600); writeErr != nil {
+			t.Fatalf("Snapshot:Version case failed when writing: %v", writeErr)
+		}
+
+		if _, loadErr := newCache(Config{}).loadSnapshot(path); !errors.Is(loadErr, ErrSnapshotVersionNotSupported) {
+			t.Errorf("Snapshot:Version case failed: error %v", loadErr)
+		}
+	})
+}
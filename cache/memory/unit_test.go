@@ -1,9 +1,14 @@
 package memory
 
 import (
+	"context"
+	"math/rand"
+	"sort"
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
 )
 
 func TestMemoryCache(t *testing.T) {
@@ -28,6 +33,83 @@ func TestMemoryCounter(t *testing.T) {
 	RunCounterTestCases(t, impl)
 }
 
+func TestMemoryLocker(t *testing.T) {
+	impl := NewMemoryLocker(Config{
+		EnableInitiativeClean: true,
+		CleanIntervalSecond:   1,
+		MaxCleanMicroSecond:   100,
+		MaxCleanPercentage:    10,
+	})
+
+	RunLockerTestCases(t, impl)
+}
+
+func TestMemoryBatcher(t *testing.T) {
+	impl := NewMemoryCache(Config{
+		EnableInitiativeClean: true,
+		CleanIntervalSecond:   1,
+		MaxCleanMicroSecond:   100,
+		MaxCleanPercentage:    10,
+	})
+
+	RunBatchTestCases(t, impl.(cache.Batcher))
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	impl := NewMemoryLimiterStorage(Config{
+		EnableInitiativeClean: true,
+		CleanIntervalSecond:   1,
+		MaxCleanMicroSecond:   100,
+		MaxCleanPercentage:    10,
+	})
+
+	RunLimiterTestCases(t, impl)
+}
+
+func TestZSetEntry(t *testing.T) {
+	entry, reference := newZSetEntry(), map[string]float64{}
+	for i := 0; i < 2000; i++ {
+		member := strconv.Itoa(rand.Intn(300))
+		if rand.Intn(4) == 0 {
+			entry.RemoveMembers(member)
+			delete(reference, member)
+			continue
+		}
+
+		score := float64(rand.Intn(50))
+		entry.AddMembers(cache.ZMember{Member: member, Score: score})
+		reference[member] = score
+	}
+
+	// 按分数和成员排序后，与跳表的结果逐一比较
+	expected := make([]cache.ZMember, 0, len(reference))
+	for member, score := range reference {
+		expected = append(expected, cache.ZMember{Member: member, Score: score})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].Score != expected[j].Score {
+			return expected[i].Score < expected[j].Score
+		}
+		return expected[i].Member < expected[j].Member
+	})
+
+	actual := entry.Range(0, -1, false)
+	if len(actual) != len(expected) || entry.Card() != int64(len(expected)) {
+		t.Fatalf("zset entry has %d members, want %d", len(actual), len(expected))
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("zset entry member %d is %v, want %v", i, actual[i], expected[i])
+		}
+		if rank, exist := entry.Rank(expected[i].Member, false); !exist || rank != int64(i) {
+			t.Fatalf("zset entry rank of %s is %d, want %d", expected[i].Member, rank, i)
+		}
+		if rank, exist := entry.Rank(expected[i].Member, true); !exist || rank != int64(len(expected)-1-i) {
+			t.Fatalf("zset entry reverse rank of %s is %d, want %d", expected[i].Member, rank, len(expected)-1-i)
+		}
+	}
+}
+
 func BenchmarkMemoryCache(b *testing.B) {
 	cache := NewMemoryCache(Config{
 		EnableInitiativeClean: true,
@@ -39,3 +121,38 @@ func BenchmarkMemoryCache(b *testing.B) {
 		go cache.StoreEX(nil, strconv.Itoa(i), "", time.Second+time.Duration(i)*time.Millisecond)
 	}
 }
+
+func TestMemoryLoader(t *testing.T) {
+	impl := NewMemoryCache(Config{
+		EnableInitiativeClean: true,
+		CleanIntervalSecond:   1,
+		MaxCleanMicroSecond:   100,
+		MaxCleanPercentage:    10,
+	})
+
+	RunLoaderTestCases(t, impl)
+}
+
+func TestMemoryEvent(t *testing.T) {
+	impl := NewMemoryCache(Config{
+		EnableInitiativeClean: true,
+		CleanIntervalSecond:   1,
+		MaxCleanMicroSecond:   100,
+		MaxCleanPercentage:    10,
+	})
+
+	RunEventTestCases(t, impl)
+
+	t.Run("CleanExpired", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := impl.Subscribe(ctx, "CleanExpired")
+
+		// 不读取过期的key，由主动清理删除
+		_ = impl.StoreEX(ctx, "CleanExpired", "value", time.Millisecond*100)
+		<-events
+		if event, received := receiveEvent(events, time.Second*3); !received || event.Type != cache.CacheEventExpire {
+			t.Errorf("expected expire event from cleaning, got %+v, %v", event, received)
+		}
+	})
+}
@@ -4,16 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/limiter"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 )
 
 type accessor struct {
-	db *redis.Client
-	kb keyBuilder
+	db    redis.UniversalClient
+	kb    keyBuilder
+	codec cache.Codec
 }
 
 func (ra *accessor) copySenderToReceiver(key string, senderPtr, receiverPtr any) error {
@@ -128,6 +134,66 @@ func (ra *accessor) ExpireImmediately(ctx context.Context, key string) (result c
 	return cache.CounterResultEnumSuccess
 }
 
+func (ra *accessor) Decrease(ctx context.Context, key string, delta uint64) (value int64, result cache.CounterResultEnum) {
+	value, executeErr := ra.db.DecrBy(ctx, ra.kb.BuildKey(key), int64(delta)).Result()
+	if executeErr != nil {
+		return 0, cache.CounterResultEnumFailed
+	}
+
+	return value, cache.CounterResultEnumSuccess
+}
+
+func (ra *accessor) Get(ctx context.Context, key string) (value int64, result cache.CounterResultEnum) {
+	value, executeErr := ra.db.Get(ctx, ra.kb.BuildKey(key)).Int64()
+	if errors.Is(executeErr, redis.Nil) {
+		return 0, cache.CounterResultEnumNotEffective
+	} else if executeErr != nil {
+		// 获取失败，或者值不是整数
+		return 0, cache.CounterResultEnumFailed
+	}
+
+	return value, cache.CounterResultEnumSuccess
+}
+
+func (ra *accessor) Set(ctx context.Context, key string, value int64) (newValue int64, result cache.CounterResultEnum) {
+	if ra.db.Set(ctx, ra.kb.BuildKey(key), value, redis.KeepTTL).Err() != nil {
+		return 0, cache.CounterResultEnumFailed
+	}
+
+	return value, cache.CounterResultEnumSuccess
+}
+
+func (ra *accessor) IncreaseWithLimit(ctx context.Context, key string, delta uint64, limit int64) (value int64, result cache.CounterResultEnum) {
+	return ra.runCounterScript(ctx, increaseWithLimitScript, key, delta, limit)
+}
+
+func (ra *accessor) CompareAndSwap(ctx context.Context, key string, old, new int64) (value int64, result cache.CounterResultEnum) {
+	return ra.runCounterScript(ctx, compareAndSwapScript, key, strconv.FormatInt(old, 10), strconv.FormatInt(new, 10))
+}
+
+// runCounterScript runs a script replying {effective, value} and converts the reply.
+func (ra *accessor) runCounterScript(ctx context.Context, script *redis.Script, key string, args ...interface{}) (value int64, result cache.CounterResultEnum) {
+	replies, executeErr := script.Run(ctx, ra.db, []string{ra.kb.BuildKey(key)}, args...).Slice()
+	if executeErr != nil || len(replies) != 2 {
+		return 0, cache.CounterResultEnumFailed
+	}
+
+	effective, isInt := replies[0].(int64)
+	rawValue, isString := replies[1].(string)
+	if !isInt || !isString {
+		return 0, cache.CounterResultEnumFailed
+	}
+	value, parseErr := strconv.ParseInt(rawValue, 10, 64)
+	if parseErr != nil {
+		return 0, cache.CounterResultEnumFailed
+	}
+	if effective == 0 {
+		return value, cache.CounterResultEnumNotEffective
+	}
+
+	return value, cache.CounterResultEnumSuccess
+}
+
 func (ra *accessor) ExistKey(ctx context.Context, key string) (exist bool, err error) {
 	count, executeRedisErr := ra.db.Exists(ctx, ra.kb.BuildKey(key)).Result()
 	if executeRedisErr != nil && !errors.Is(executeRedisErr, redis.Nil) {
@@ -210,7 +276,7 @@ func (ra *accessor) LoadJson(ctx context.Context, key string, receiverPtr any) (
 		return false, nil
 	}
 
-	unmarshalJsonErr := json.Unmarshal([]byte(value), receiverPtr)
+	unmarshalJsonErr := ra.codec.Unmarshal([]byte(value), receiverPtr)
 	if unmarshalJsonErr != nil {
 		return true, ra.kb.BuildError("load json data", unmarshalJsonErr, key)
 	}
@@ -227,7 +293,7 @@ func (ra *accessor) LoadJsonWithEX(ctx context.Context, key string, receiverPtr
 		return false, 0, nil
 	}
 
-	unmarshalJsonErr := json.Unmarshal([]byte(value), receiverPtr)
+	unmarshalJsonErr := ra.codec.Unmarshal([]byte(value), receiverPtr)
 	if unmarshalJsonErr != nil {
 		return true, 0, ra.kb.BuildError("load ex json data", unmarshalJsonErr, key)
 	}
@@ -262,7 +328,7 @@ func (ra *accessor) StoreEX(ctx context.Context, key string, value string, expir
 }
 
 func (ra *accessor) StoreJson(ctx context.Context, key string, senderPtr any) (err error) {
-	payload, marshalJsonErr := json.Marshal(senderPtr)
+	payload, marshalJsonErr := ra.codec.Marshal(senderPtr)
 	if marshalJsonErr != nil {
 		return ra.kb.BuildError("marshal json data", marshalJsonErr, key)
 	}
@@ -276,7 +342,7 @@ func (ra *accessor) StoreJson(ctx context.Context, key string, senderPtr any) (e
 }
 
 func (ra *accessor) StoreJsonEX(ctx context.Context, key string, senderPtr any, expiration time.Duration) (err error) {
-	payload, marshalJsonErr := json.Marshal(senderPtr)
+	payload, marshalJsonErr := ra.codec.Marshal(senderPtr)
 	if marshalJsonErr != nil {
 		return ra.kb.BuildError("marshal ex json data", marshalJsonErr, key)
 	}
@@ -331,7 +397,7 @@ func (ra *accessor) LoadAndDeleteJson(ctx context.Context, key string, receivePt
 	if !exist {
 		return false, nil
 	}
-	if unmarshalJsonErr := json.Unmarshal([]byte(value), receivePtr); unmarshalJsonErr != nil {
+	if unmarshalJsonErr := ra.codec.Unmarshal([]byte(value), receivePtr); unmarshalJsonErr != nil {
 		return true, ra.kb.BuildError("unmarshal json data", unmarshalJsonErr, key)
 	}
 
@@ -385,7 +451,7 @@ func (ra *accessor) LoadOrStoreEX(ctx context.Context, key string, storeValue st
 }
 
 func (ra *accessor) LoadOrStoreJson(ctx context.Context, key string, senderPtr any, receiverPtr any) (loaded bool, err error) {
-	payload, marshalJsonErr := json.Marshal(senderPtr)
+	payload, marshalJsonErr := ra.codec.Marshal(senderPtr)
 	if marshalJsonErr != nil {
 		return false, ra.kb.BuildError("marshal json data", marshalJsonErr, key)
 	}
@@ -398,7 +464,7 @@ func (ra *accessor) LoadOrStoreJson(ctx context.Context, key string, senderPtr a
 		return false, nil
 	}
 
-	if unmarshalJsonErr := json.Unmarshal([]byte(value), receiverPtr); unmarshalJsonErr != nil {
+	if unmarshalJsonErr := ra.codec.Unmarshal([]byte(value), receiverPtr); unmarshalJsonErr != nil {
 		return true, ra.kb.BuildError("unmarshal json data", unmarshalJsonErr, key)
 	}
 
@@ -406,7 +472,7 @@ func (ra *accessor) LoadOrStoreJson(ctx context.Context, key string, senderPtr a
 }
 
 func (ra *accessor) LoadOrStoreJsonEX(ctx context.Context, key string, senderPtr any, receiverPtr any, expiration time.Duration) (loaded bool, err error) {
-	payload, marshalJsonErr := json.Marshal(senderPtr)
+	payload, marshalJsonErr := ra.codec.Marshal(senderPtr)
 	if marshalJsonErr != nil {
 		return false, ra.kb.BuildError("marshal ex json data", marshalJsonErr, key)
 	}
@@ -419,7 +485,7 @@ func (ra *accessor) LoadOrStoreJsonEX(ctx context.Context, key string, senderPtr
 		return false, nil
 	}
 
-	if unmarshalJsonErr := json.Unmarshal([]byte(value), receiverPtr); unmarshalJsonErr != nil {
+	if unmarshalJsonErr := ra.codec.Unmarshal([]byte(value), receiverPtr); unmarshalJsonErr != nil {
 		return true, ra.kb.BuildError("marshal ex json data", marshalJsonErr, key)
 	}
 
@@ -602,7 +668,7 @@ func (ra *accessor) HGetJson(ctx context.Context, key string, field string, rece
 	if !existValue {
 		return false, nil
 	}
-	if unmarshalJsonErr := json.Unmarshal([]byte(value), receiverPtr); unmarshalJsonErr != nil {
+	if unmarshalJsonErr := ra.codec.Unmarshal([]byte(value), receiverPtr); unmarshalJsonErr != nil {
 		return true, ra.kb.BuildError("unmarshal hash json data", unmarshalJsonErr, key)
 	}
 
@@ -715,3 +781,541 @@ func (ra *accessor) Expire(ctx context.Context, key string, expire time.Duration
 
 	return nil
 }
+
+func (ra *accessor) Persist(ctx context.Context, key string) (err error) {
+	if executeRedisErr := ra.db.Persist(ctx, ra.kb.BuildKey(key)).Err(); executeRedisErr != nil {
+		return ra.kb.BuildError("persist", executeRedisErr, key)
+	}
+
+	return nil
+}
+
+func (ra *accessor) Type(ctx context.Context, key string) (exist bool, keyType string, err error) {
+	keyType, executeRedisErr := ra.db.Type(ctx, ra.kb.BuildKey(key)).Result()
+	if executeRedisErr != nil {
+		return false, "", ra.kb.BuildError("get type", executeRedisErr, key)
+	}
+	if keyType == "none" {
+		return false, "", nil
+	}
+
+	return true, keyType, nil
+}
+
+func (ra *accessor) Scan(ctx context.Context, cursor uint64, pattern string, count int64) (keys []string, nextCursor uint64, err error) {
+	masters, mastersErr := ra.masters(ctx)
+	if mastersErr != nil {
+		return nil, 0, ra.kb.BuildError("scan", mastersErr, pattern)
+	}
+
+	// 集群模式下游标的高位记录正在扫描的主节点，低位为该节点的游标
+	index, nodeCursor := splitClusterCursor(cursor)
+	if index >= len(masters) {
+		return nil, 0, nil
+	}
+	builtKeys, nodeCursor, executeRedisErr := masters[index].Scan(ctx, nodeCursor, ra.kb.BuildPattern(pattern), count).Result()
+	if executeRedisErr != nil {
+		return nil, 0, ra.kb.BuildError("scan", executeRedisErr, pattern)
+	}
+	if nodeCursor == 0 && index+1 < len(masters) {
+		nextCursor = joinClusterCursor(index+1, 0)
+	} else if nodeCursor != 0 {
+		nextCursor = joinClusterCursor(index, nodeCursor)
+	}
+
+	keys = make([]string, len(builtKeys))
+	for i, builtKey := range builtKeys {
+		keys[i] = ra.kb.TrimKey(builtKey)
+	}
+
+	return keys, nextCursor, nil
+}
+
+func (ra *accessor) DeleteByPattern(ctx context.Context, pattern string) (deleted int64, err error) {
+	masters, mastersErr := ra.masters(ctx)
+	if mastersErr != nil {
+		return 0, ra.kb.BuildError("scan", mastersErr, pattern)
+	}
+
+	builtPattern := ra.kb.BuildPattern(pattern)
+	for _, master := range masters {
+		for cursor := uint64(0); ; {
+			builtKeys, nextCursor, scanErr := master.Scan(ctx, cursor, builtPattern, deleteByPatternScanCount).Result()
+			if scanErr != nil {
+				return deleted, ra.kb.BuildError("scan", scanErr, pattern)
+			}
+
+			if len(builtKeys) > 0 {
+				count, deleteErr := ra.deleteKeys(ctx, builtKeys...)
+				if deleteErr != nil {
+					return deleted, ra.kb.BuildError("delete by pattern", deleteErr, pattern)
+				}
+				deleted += count
+			}
+
+			if cursor = nextCursor; cursor == 0 {
+				break
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// deleteKeys deletes the built keys, the keys in different slots of a cluster are deleted one by
+// one in a pipeline since a single DEL can not cross slots.
+func (ra *accessor) deleteKeys(ctx context.Context, builtKeys ...string) (deleted int64, err error) {
+	if ra.sameSlot(builtKeys...) {
+		return ra.db.Del(ctx, builtKeys...).Result()
+	}
+
+	commands, pipelineErr := ra.db.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, builtKey := range builtKeys {
+			pipe.Del(ctx, builtKey)
+		}
+		return nil
+	})
+	if pipelineErr != nil {
+		return 0, pipelineErr
+	}
+	for _, command := range commands {
+		deleted += command.(*redis.IntCmd).Val()
+	}
+
+	return deleted, nil
+}
+
+func (ra *accessor) ZAdd(ctx context.Context, key string, members ...cache.ZMember) (added int64, err error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	result, executeRedisErr := ra.db.ZAdd(ctx, ra.kb.BuildKey(key), toRedisZ(members)...).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return 0, nil
+		}
+
+		return 0, ra.kb.BuildError("add sorted set members", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) ZIncrBy(ctx context.Context, key string, member string, increment float64) (score float64, err error) {
+	result, executeRedisErr := ra.db.ZIncrBy(ctx, ra.kb.BuildKey(key), increment, member).Result()
+	if executeRedisErr != nil {
+		return 0, ra.kb.BuildError("increase sorted set member", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) ZScore(ctx context.Context, key string, member string) (exist bool, score float64, err error) {
+	result, executeRedisErr := ra.db.ZScore(ctx, ra.kb.BuildKey(key), member).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return false, 0, nil
+		}
+
+		return false, 0, ra.kb.BuildError("get sorted set score", executeRedisErr, key)
+	}
+
+	return true, result, nil
+}
+
+func (ra *accessor) ZRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error) {
+	result, executeRedisErr := ra.db.ZRank(ctx, ra.kb.BuildKey(key), member).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return false, 0, nil
+		}
+
+		return false, 0, ra.kb.BuildError("get sorted set rank", executeRedisErr, key)
+	}
+
+	return true, result, nil
+}
+
+func (ra *accessor) ZRevRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error) {
+	result, executeRedisErr := ra.db.ZRevRank(ctx, ra.kb.BuildKey(key), member).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return false, 0, nil
+		}
+
+		return false, 0, ra.kb.BuildError("get sorted set reverse rank", executeRedisErr, key)
+	}
+
+	return true, result, nil
+}
+
+func (ra *accessor) ZRange(ctx context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	result, executeRedisErr := ra.db.ZRangeWithScores(ctx, ra.kb.BuildKey(key), start, stop).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return []cache.ZMember{}, nil
+		}
+
+		return []cache.ZMember{}, ra.kb.BuildError("range sorted set", executeRedisErr, key)
+	}
+
+	return fromRedisZ(result), nil
+}
+
+func (ra *accessor) ZRevRange(ctx context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	result, executeRedisErr := ra.db.ZRevRangeWithScores(ctx, ra.kb.BuildKey(key), start, stop).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return []cache.ZMember{}, nil
+		}
+
+		return []cache.ZMember{}, ra.kb.BuildError("reverse range sorted set", executeRedisErr, key)
+	}
+
+	return fromRedisZ(result), nil
+}
+
+func (ra *accessor) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) (members []cache.ZMember, err error) {
+	rangeBy := &redis.ZRangeBy{Min: formatScore(min), Max: formatScore(max), Offset: offset, Count: count}
+	if count <= 0 && offset > 0 {
+		// redis需要同时指定offset和count，count为负数时返回全部
+		rangeBy.Count = -1
+	} else if count <= 0 {
+		rangeBy.Count = 0
+	}
+
+	result, executeRedisErr := ra.db.ZRangeByScoreWithScores(ctx, ra.kb.BuildKey(key), rangeBy).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return []cache.ZMember{}, nil
+		}
+
+		return []cache.ZMember{}, ra.kb.BuildError("range sorted set by score", executeRedisErr, key)
+	}
+
+	return fromRedisZ(result), nil
+}
+
+func (ra *accessor) ZRem(ctx context.Context, key string, members ...string) (removed int64, err error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	membersInterfaces := make([]interface{}, len(members))
+	for i, member := range members {
+		membersInterfaces[i] = member
+	}
+
+	result, executeRedisErr := ra.db.ZRem(ctx, ra.kb.BuildKey(key), membersInterfaces...).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return 0, nil
+		}
+
+		return 0, ra.kb.BuildError("remove sorted set members", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) ZCard(ctx context.Context, key string) (count int64, err error) {
+	result, executeRedisErr := ra.db.ZCard(ctx, ra.kb.BuildKey(key)).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return 0, nil
+		}
+
+		return 0, ra.kb.BuildError("count sorted set", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) ZPopMin(ctx context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	if count <= 0 {
+		return []cache.ZMember{}, nil
+	}
+
+	result, executeRedisErr := ra.db.ZPopMin(ctx, ra.kb.BuildKey(key), count).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return []cache.ZMember{}, nil
+		}
+
+		return []cache.ZMember{}, ra.kb.BuildError("pop min sorted set members", executeRedisErr, key)
+	}
+
+	return fromRedisZ(result), nil
+}
+
+func (ra *accessor) ZPopMax(ctx context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	if count <= 0 {
+		return []cache.ZMember{}, nil
+	}
+
+	result, executeRedisErr := ra.db.ZPopMax(ctx, ra.kb.BuildKey(key), count).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return []cache.ZMember{}, nil
+		}
+
+		return []cache.ZMember{}, ra.kb.BuildError("pop max sorted set members", executeRedisErr, key)
+	}
+
+	return fromRedisZ(result), nil
+}
+
+func (ra *accessor) LPush(ctx context.Context, key string, values ...string) (length int64, err error) {
+	if len(values) == 0 {
+		return ra.LLen(ctx, key)
+	}
+
+	result, executeRedisErr := ra.db.LPush(ctx, ra.kb.BuildKey(key), toInterfaces(values)...).Result()
+	if executeRedisErr != nil {
+		return 0, ra.kb.BuildError("left push list values", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) RPush(ctx context.Context, key string, values ...string) (length int64, err error) {
+	if len(values) == 0 {
+		return ra.LLen(ctx, key)
+	}
+
+	result, executeRedisErr := ra.db.RPush(ctx, ra.kb.BuildKey(key), toInterfaces(values)...).Result()
+	if executeRedisErr != nil {
+		return 0, ra.kb.BuildError("right push list values", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) LPop(ctx context.Context, key string) (exist bool, value string, err error) {
+	result, executeRedisErr := ra.db.LPop(ctx, ra.kb.BuildKey(key)).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return false, "", nil
+		}
+
+		return false, "", ra.kb.BuildError("left pop list value", executeRedisErr, key)
+	}
+
+	return true, result, nil
+}
+
+func (ra *accessor) RPop(ctx context.Context, key string) (exist bool, value string, err error) {
+	result, executeRedisErr := ra.db.RPop(ctx, ra.kb.BuildKey(key)).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return false, "", nil
+		}
+
+		return false, "", ra.kb.BuildError("right pop list value", executeRedisErr, key)
+	}
+
+	return true, result, nil
+}
+
+func (ra *accessor) LRange(ctx context.Context, key string, start, stop int64) (values []string, err error) {
+	result, executeRedisErr := ra.db.LRange(ctx, ra.kb.BuildKey(key), start, stop).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return []string{}, nil
+		}
+
+		return []string{}, ra.kb.BuildError("range list values", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) LLen(ctx context.Context, key string) (length int64, err error) {
+	result, executeRedisErr := ra.db.LLen(ctx, ra.kb.BuildKey(key)).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return 0, nil
+		}
+
+		return 0, ra.kb.BuildError("get list length", executeRedisErr, key)
+	}
+
+	return result, nil
+}
+
+func (ra *accessor) BLPop(ctx context.Context, keys ...string) (popped bool, key string, value string, err error) {
+	if len(keys) == 0 {
+		return false, "", "", nil
+	}
+
+	builtKeys, originKeys := make([]string, len(keys)), make(map[string]string, len(keys))
+	for i, k := range keys {
+		builtKeys[i] = ra.kb.BuildKey(k)
+		originKeys[builtKeys[i]] = k
+	}
+
+	for {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if errors.Is(ctxErr, context.DeadlineExceeded) {
+				return false, "", "", nil
+			}
+
+			return false, "", "", ctxErr
+		}
+
+		// 每次最多阻塞一个轮询周期，使没有截止时间的上下文被取消时能够及时返回
+		wait := blockingPollInterval
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < wait {
+			wait = time.Until(deadline)
+		}
+
+		// redis的阻塞时间以秒为单位，剩余时间不足一秒时服务端的阻塞会超过上下文的截止时间，
+		// 此时连接已被客户端放弃，服务端弹出的值会丢失，因此改为非阻塞的轮询
+		// 集群中不同槽位的键无法在一次BLPOP中等待，同样依次尝试弹出并等待一个短的间隔
+		if wait < time.Second || !ra.sameSlot(builtKeys...) {
+			popped, key, value, err = ra.popFirst(ctx, builtKeys, originKeys)
+			if popped || err != nil {
+				return popped, key, value, err
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(popRetryInterval):
+			}
+			continue
+		}
+
+		result, executeRedisErr := ra.db.BLPop(ctx, wait, builtKeys...).Result()
+		if executeRedisErr != nil {
+			if errors.Is(executeRedisErr, redis.Nil) {
+				continue
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				continue
+			}
+
+			return false, "", "", ra.kb.BuildError("blocking left pop list value", executeRedisErr, strings.Join(keys, ","))
+		}
+
+		return true, originKeys[result[0]], result[1], nil
+	}
+}
+
+// popFirst pops the first value of the first non-empty list in the order of builtKeys.
+func (ra *accessor) popFirst(ctx context.Context, builtKeys []string, originKeys map[string]string) (popped bool, key string, value string, err error) {
+	for _, builtKey := range builtKeys {
+		result, executeRedisErr := ra.db.LPop(ctx, builtKey).Result()
+		if errors.Is(executeRedisErr, redis.Nil) {
+			continue
+		} else if executeRedisErr != nil {
+			if ctx.Err() != nil {
+				return false, "", "", nil
+			}
+
+			return false, "", "", ra.kb.BuildError("blocking left pop list value", executeRedisErr, originKeys[builtKey])
+		}
+
+		return true, originKeys[builtKey], result, nil
+	}
+
+	return false, "", "", nil
+}
+
+func (ra *accessor) TryLock(ctx context.Context, key string, ttl time.Duration) (acquired bool, token string, err error) {
+	if ttl < cache.MinLockTTL {
+		// SETNX的过期时间为0时锁永不过期，与内存驱动保持一致直接拒绝
+		return false, "", ra.kb.BuildError("try lock", cache.ErrInvalidLockTTL, key)
+	}
+
+	token = uuid.NewString()
+	result, executeRedisErr := ra.db.SetNX(ctx, ra.kb.BuildKey(key), token, ttl).Result()
+	if executeRedisErr != nil {
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return false, "", nil
+		}
+
+		return false, "", ra.kb.BuildError("try lock", executeRedisErr, key)
+	}
+	if !result {
+		return false, "", nil
+	}
+
+	return true, token, nil
+}
+
+func (ra *accessor) Lock(ctx context.Context, key string, ttl time.Duration) (token string, err error) {
+	for {
+		acquired, acquiredToken, lockErr := ra.TryLock(ctx, key, ttl)
+		if lockErr != nil {
+			return "", lockErr
+		}
+		if acquired {
+			return acquiredToken, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ra.kb.BuildError("lock", ctx.Err(), key)
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func (ra *accessor) Unlock(ctx context.Context, key string, token string) (err error) {
+	result, executeRedisErr := unlockScript.Run(ctx, ra.db, []string{ra.kb.BuildKey(key)}, token).Int64()
+	if executeRedisErr != nil {
+		return ra.kb.BuildError("unlock", executeRedisErr, key)
+	}
+	if result == 0 {
+		return ra.kb.BuildError("unlock", cache.ErrLockNotHeld, key)
+	}
+
+	return nil
+}
+
+func (ra *accessor) Extend(ctx context.Context, key string, token string, ttl time.Duration) (err error) {
+	if ttl < cache.MinLockTTL {
+		return ra.kb.BuildError("extend lock", cache.ErrInvalidLockTTL, key)
+	}
+
+	result, executeRedisErr := extendScript.Run(ctx, ra.db, []string{ra.kb.BuildKey(key)}, token, ttl.Milliseconds()).Int64()
+	if executeRedisErr != nil {
+		return ra.kb.BuildError("extend lock", executeRedisErr, key)
+	}
+	if result == 0 {
+		return ra.kb.BuildError("extend lock", cache.ErrLockNotHeld, key)
+	}
+
+	return nil
+}
+
+func (ra *accessor) Take(ctx context.Context, key string, rule limiter.Rule, n int64) (result limiter.Result, err error) {
+	if validateErr := rule.Validate(); validateErr != nil {
+		return limiter.Result{}, validateErr
+	}
+
+	script, arguments := fixedWindowScript, []interface{}{rule.Limit, rule.Window.Milliseconds(), n}
+	switch rule.Algorithm {
+	case limiter.AlgorithmSlidingLog:
+		script, arguments = slidingLogScript, append(arguments, uuid.NewString())
+	case limiter.AlgorithmSlidingWindow:
+		script = slidingWindowScript
+	case limiter.AlgorithmTokenBucket:
+		script = tokenBucketScript
+	}
+
+	replies, executeRedisErr := script.Run(ctx, ra.db, []string{ra.kb.BuildKey(key)}, arguments...).Int64Slice()
+	if executeRedisErr != nil {
+		return limiter.Result{}, ra.kb.BuildError("take quota", executeRedisErr, key)
+	}
+	if len(replies) != 3 {
+		return limiter.Result{}, ra.kb.BuildError("take quota", fmt.Errorf("unexpected reply %v", replies), key)
+	}
+
+	return limiter.Result{
+		Allowed:    replies[0] == 1,
+		Remaining:  replies[1],
+		RetryAfter: time.Duration(replies[2]) * time.Millisecond,
+	}, nil
+}
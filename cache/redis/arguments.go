@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+
+	return result
+}
+
+func toRedisZ(members []cache.ZMember) []*redis.Z {
+	result := make([]*redis.Z, len(members))
+	for i, member := range members {
+		result[i] = &redis.Z{Score: member.Score, Member: member.Member}
+	}
+
+	return result
+}
+
+func fromRedisZ(members []redis.Z) []cache.ZMember {
+	result := make([]cache.ZMember, len(members))
+	for i, member := range members {
+		result[i] = cache.ZMember{Member: fmt.Sprint(member.Member), Score: member.Score}
+	}
+
+	return result
+}
+
+// formatScore formats the score as an argument of range commands, infinities are formatted as
+// -inf and +inf which are recognized by redis.
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+}
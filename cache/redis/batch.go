@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+// batch queues the operations into a go-redis pipeline, the results are filled by the collectors
+// after the pipeline is executed.
+type batch struct {
+	ctx        context.Context
+	kb         keyBuilder
+	pipe       redis.Pipeliner
+	collectors []func() error
+	executed   bool
+}
+
+func (ra *accessor) Batch(ctx context.Context) cache.BatchBuilder {
+	return &batch{ctx: ctx, kb: ra.kb, pipe: ra.db.Pipeline()}
+}
+
+func (ra *accessor) TxBatch(ctx context.Context) cache.BatchBuilder {
+	return &batch{ctx: ctx, kb: ra.kb, pipe: ra.db.TxPipeline()}
+}
+
+// collectValue fills the result of a command replying a string, redis.Nil means the key or field
+// does not exist.
+func (b *batch) collectValue(operation string, key string, cmd *redis.StringCmd) (result *cache.BatchValueResult) {
+	result = &cache.BatchValueResult{}
+	b.collectors = append(b.collectors, func() error {
+		value, executeRedisErr := cmd.Result()
+		if errors.Is(executeRedisErr, redis.Nil) {
+			return nil
+		} else if executeRedisErr != nil {
+			result.Err = b.kb.BuildError(operation, executeRedisErr, key)
+			return result.Err
+		}
+
+		result.Exist, result.Value = true, value
+		return nil
+	})
+
+	return result
+}
+
+func (b *batch) collect(operation string, key string, cmd redis.Cmder) (result *cache.BatchResult) {
+	result = &cache.BatchResult{}
+	b.collectors = append(b.collectors, func() error {
+		if executeRedisErr := cmd.Err(); executeRedisErr != nil && !errors.Is(executeRedisErr, redis.Nil) {
+			result.Err = b.kb.BuildError(operation, executeRedisErr, key)
+		}
+		return result.Err
+	})
+
+	return result
+}
+
+func (b *batch) Load(key string) (result *cache.BatchValueResult) {
+	return b.collectValue("batch load", key, b.pipe.Get(b.ctx, b.kb.BuildKey(key)))
+}
+
+func (b *batch) Store(key string, value string) (result *cache.BatchResult) {
+	return b.collect("batch store", key, b.pipe.Set(b.ctx, b.kb.BuildKey(key), value, 0))
+}
+
+func (b *batch) StoreEX(key string, value string, expiration time.Duration) (result *cache.BatchResult) {
+	return b.collect("batch store", key, b.pipe.Set(b.ctx, b.kb.BuildKey(key), value, expiration))
+}
+
+func (b *batch) HGetValue(key string, field string) (result *cache.BatchValueResult) {
+	return b.collectValue("batch hget", key, b.pipe.HGet(b.ctx, b.kb.BuildKey(key), field))
+}
+
+func (b *batch) HSetValue(key string, field string, value string) (result *cache.BatchResult) {
+	return b.collect("batch hset", key, b.pipe.HSet(b.ctx, b.kb.BuildKey(key), field, value))
+}
+
+func (b *batch) Delete(key string) (result *cache.BatchResult) {
+	return b.collect("batch delete", key, b.pipe.Del(b.ctx, b.kb.BuildKey(key)))
+}
+
+func (b *batch) Expire(key string, expire time.Duration) (result *cache.BatchResult) {
+	return b.collect("batch expire", key, b.pipe.Expire(b.ctx, b.kb.BuildKey(key), expire))
+}
+
+func (b *batch) Exec() (err error) {
+	if b.executed {
+		return cache.ErrBatchExecuted
+	}
+	b.executed = true
+
+	// go-redis sets the error of every command when the pipeline fails, so the error of Exec is
+	// the error of the first failed command
+	_, execErr := b.pipe.Exec(b.ctx)
+	for _, collector := range b.collectors {
+		if collectErr := collector(); collectErr != nil && err == nil {
+			err = collectErr
+		}
+	}
+	if err == nil && execErr != nil && !errors.Is(execErr, redis.Nil) {
+		err = execErr
+	}
+
+	return err
+}
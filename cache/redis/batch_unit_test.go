@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+var BaseBatchUnitTestCaseList = []TestCase[cache.Batcher]{
+	{
+		CaseName:     "Batch",
+		TestFunction: BatchFunction,
+	},
+	{
+		CaseName:     "BatchError",
+		TestFunction: BatchErrorFunction,
+	},
+}
+
+func batchReadWrite(t *testing.T, name string, newBatch func(ctx context.Context) cache.BatchBuilder) {
+	key, hashKey := name+":key", name+":hash"
+
+	// 第一批写入
+	writes := newBatch(context.Background())
+	writes.Delete(key)
+	writes.Delete(hashKey)
+	stored := writes.StoreEX(key, "value", time.Minute)
+	hashed := writes.HSetValue(hashKey, "field", "hash-value")
+	if err := writes.Exec(); err != nil || stored.Err != nil || hashed.Err != nil {
+		t.Fatalf("%s case failed: write batch err %v", name, err)
+	}
+
+	// 第二批读取并删除，读取发生在删除之前
+	reads := newBatch(context.Background())
+	loaded, missing := reads.Load(key), reads.Load(name+":missing")
+	field, missingField := reads.HGetValue(hashKey, "field"), reads.HGetValue(hashKey, "missing")
+	reads.Delete(key)
+	if err := reads.Exec(); err != nil {
+		t.Fatalf("%s case failed: read batch err %v", name, err)
+	}
+	if !loaded.Exist || loaded.Value != "value" || missing.Exist {
+		t.Errorf("%s case failed: incorrect load results %+v, %+v", name, loaded, missing)
+	}
+	if !field.Exist || field.Value != "hash-value" || missingField.Exist {
+		t.Errorf("%s case failed: incorrect hget results %+v, %+v", name, field, missingField)
+	}
+
+	// 删除已经生效
+	check := newBatch(context.Background())
+	deleted := check.Load(key)
+	_ = check.Exec()
+	if deleted.Exist {
+		t.Errorf("%s case failed: key is not deleted", name)
+	}
+}
+
+func BatchFunction(impl cache.Batcher) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Run("Batch:Pipeline", func(t *testing.T) {
+			batchReadWrite(t, "Batch:Pipeline", impl.Batch)
+		})
+
+		t.Run("Batch:Transaction", func(t *testing.T) {
+			batchReadWrite(t, "Batch:Transaction", impl.TxBatch)
+		})
+	}
+}
+
+func BatchErrorFunction(impl cache.Batcher) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 类型不匹配的操作失败，不影响其他操作
+		t.Run("BatchError:WrongType", func(t *testing.T) {
+			key, otherKey := "BatchError:WrongType", "BatchError:WrongType:Other"
+			setup := impl.Batch(context.Background())
+			setup.Store(key, "value")
+			_ = setup.Exec()
+
+			b := impl.Batch(context.Background())
+			wrong := b.HGetValue(key, "field")
+			stored := b.Store(otherKey, "value")
+			if err := b.Exec(); err == nil || wrong.Err == nil || stored.Err != nil {
+				t.Errorf("BatchError:WrongType case failed: exec err %v, results %+v, %+v", err, wrong, stored)
+			}
+
+			check := impl.Batch(context.Background())
+			other := check.Load(otherKey)
+			_ = check.Exec()
+			if !other.Exist {
+				t.Errorf("BatchError:WrongType case failed: other operation is not executed")
+			}
+		})
+
+		// 重复执行
+		t.Run("BatchError:ExecutedTwice", func(t *testing.T) {
+			b := impl.Batch(context.Background())
+			b.Load("BatchError:ExecutedTwice")
+			_ = b.Exec()
+			if err := b.Exec(); !errors.Is(err, cache.ErrBatchExecuted) {
+				t.Errorf("BatchError:ExecutedTwice case failed: err %v", err)
+			}
+		})
+	}
+}
+
+func RunBatchTestCases(t *testing.T, impl cache.Batcher) {
+	for _, v := range BaseBatchUnitTestCaseList {
+		t.Run(v.CaseName, v.TestFunction(impl))
+	}
+}
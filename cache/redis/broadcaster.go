@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alioth-center/infrastructure/exit"
+	"github.com/alioth-center/infrastructure/utils/values"
+	"github.com/go-redis/redis/v8"
+)
+
+// Broadcaster publishes and receives messages over a redis pub/sub channel, the channel name
+// is built with the same prefix and separator as the cache keys, so different applications
+// sharing one redis server will not receive each other's messages.
+type Broadcaster struct {
+	db      redis.UniversalClient
+	channel string
+}
+
+// NewRedisBroadcaster creates a broadcaster on the given channel, it uses a dedicated connection
+// pool because a subscription holds its connection until it is closed.
+func NewRedisBroadcaster(cfg Config, channel string) (broadcaster *Broadcaster, err error) {
+	client, connectErr := newClient(cfg)
+	if connectErr != nil {
+		return values.Nil[*Broadcaster](), connectErr
+	}
+
+	// 初始化成功，需要注册退出函数
+	exit.RegisterExitEvent(func(signal os.Signal) {
+		_ = client.Close()
+		fmt.Println("closed redis broadcaster")
+	}, "CLOSE_REDIS_BROADCASTER")
+
+	return &Broadcaster{
+		db:      client,
+		channel: newKeyBuilder(cfg).BuildKey(channel),
+	}, nil
+}
+
+// Publish sends the message to every subscriber of the channel.
+func (b *Broadcaster) Publish(ctx context.Context, message string) (err error) {
+	publishRedisErr := b.db.Publish(ctx, b.channel, message).Err()
+	if publishRedisErr != nil && !errors.Is(publishRedisErr, redis.Nil) {
+		return fmt.Errorf("publish message to channel %s: %w", b.channel, publishRedisErr)
+	}
+
+	return nil
+}
+
+// Subscribe starts receiving messages of the channel in background and calls handler for each
+// of them, the subscription is closed when ctx is done.
+func (b *Broadcaster) Subscribe(ctx context.Context, handler func(message string)) (err error) {
+	subscription := b.db.Subscribe(ctx, b.channel)
+	if _, receiveErr := subscription.Receive(ctx); receiveErr != nil {
+		_ = subscription.Close()
+		return fmt.Errorf("subscribe channel %s: %w", b.channel, receiveErr)
+	}
+
+	go func() {
+		defer func() { _ = subscription.Close() }()
+
+		messages := subscription.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				handler(message.Payload)
+			}
+		}
+	}()
+
+	return nil
+}
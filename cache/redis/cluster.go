@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// clusterSlotCount is the number of hash slots of a redis cluster.
+const clusterSlotCount = 16384
+
+// clusterSlot returns the hash slot of key, only the hash tag between the first '{' and the next
+// '}' is hashed if it is not empty, the same as redis cluster.
+func clusterSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	// CRC16-CCITT (XMODEM)
+	crc := uint16(0)
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return int(crc) % clusterSlotCount
+}
+
+// clusterCursorShift is the number of low bits of a scan cursor holding the cursor of the node,
+// the higher bits hold the index of the node in the masters.
+const clusterCursorShift = 48
+
+func splitClusterCursor(cursor uint64) (index int, nodeCursor uint64) {
+	return int(cursor >> clusterCursorShift), cursor & (1<<clusterCursorShift - 1)
+}
+
+func joinClusterCursor(index int, nodeCursor uint64) (cursor uint64) {
+	return uint64(index)<<clusterCursorShift | nodeCursor
+}
+
+// isCluster reports whether the accessor is connected to a redis cluster.
+func (ra *accessor) isCluster() bool {
+	_, cluster := ra.db.(*redis.ClusterClient)
+	return cluster
+}
+
+// sameSlot reports whether the keys can be used in one command, it is always true when the
+// accessor is not connected to a cluster.
+func (ra *accessor) sameSlot(builtKeys ...string) bool {
+	if !ra.isCluster() || len(builtKeys) == 0 {
+		return true
+	}
+
+	slot := clusterSlot(builtKeys[0])
+	for _, builtKey := range builtKeys[1:] {
+		if clusterSlot(builtKey) != slot {
+			return false
+		}
+	}
+
+	return true
+}
+
+// masters returns the clients of the nodes holding the keyspace, it is the only client when the
+// accessor is not connected to a cluster. The masters of a cluster are ordered by their addresses
+// so that the order is stable between calls.
+func (ra *accessor) masters(ctx context.Context) (masters []*redis.Client, err error) {
+	cluster, isCluster := ra.db.(*redis.ClusterClient)
+	if !isCluster {
+		return []*redis.Client{ra.db.(*redis.Client)}, nil
+	}
+
+	mtx := sync.Mutex{}
+	if iterateErr := cluster.ForEachMaster(ctx, func(_ context.Context, master *redis.Client) error {
+		mtx.Lock()
+		masters = append(masters, master)
+		mtx.Unlock()
+		return nil
+	}); iterateErr != nil {
+		return nil, iterateErr
+	}
+
+	sort.Slice(masters, func(i, j int) bool {
+		return masters[i].Options().Addr < masters[j].Options().Addr
+	})
+	return masters, nil
+}
@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"testing"
+)
+
+func TestClusterSlot(t *testing.T) {
+	t.Run("KnownSlots", func(t *testing.T) {
+		// 与redis CLUSTER KEYSLOT命令的结果一致
+		expected := map[string]int{"": 0, "foo": 12182, "bar": 5061, "123456789": 12739}
+		for key, slot := range expected {
+			if actual := clusterSlot(key); actual != slot {
+				t.Errorf("slot of %q: expected %d, actual %d", key, slot, actual)
+			}
+		}
+	})
+
+	t.Run("HashTag", func(t *testing.T) {
+		if clusterSlot("{user}:a") != clusterSlot("{user}:b") || clusterSlot("{user}:a") != clusterSlot("user") {
+			t.Error("keys with the same hash tag should be in the same slot")
+		}
+		// 空的哈希标签不生效，整个键参与计算
+		if clusterSlot("{}user") == clusterSlot("user") {
+			t.Error("empty hash tag should be ignored")
+		}
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		index, nodeCursor := splitClusterCursor(joinClusterCursor(3, 12345))
+		if index != 3 || nodeCursor != 12345 {
+			t.Errorf("expected (3, 12345), actual (%d, %d)", index, nodeCursor)
+		}
+	})
+}
+
+func TestModeConfig(t *testing.T) {
+	if _, err := newClient(Config{Mode: "unknown"}); err == nil {
+		t.Error("unknown mode should be rejected")
+	}
+}
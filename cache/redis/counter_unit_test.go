@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,6 +31,22 @@ var BaseCounterUnitTestCaseList = []TestCase[cache.Counter]{
 		CaseName:     "ExpireImmediately",
 		TestFunction: ExpireImmediatelyFunction,
 	},
+	{
+		CaseName:     "Decrease",
+		TestFunction: DecreaseFunction,
+	},
+	{
+		CaseName:     "GetAndSet",
+		TestFunction: GetAndSetFunction,
+	},
+	{
+		CaseName:     "IncreaseWithLimit",
+		TestFunction: IncreaseWithLimitFunction,
+	},
+	{
+		CaseName:     "CompareAndSwap",
+		TestFunction: CompareAndSwapFunction,
+	},
 }
 
 func IncreaseFunction(impl cache.Counter) func(t *testing.T) {
@@ -455,6 +472,172 @@ func ExpireImmediatelyFunction(impl cache.Counter) func(t *testing.T) {
 	}
 }
 
+func DecreaseFunction(impl cache.Counter) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 减少一个不存在的计数器
+		t.Run("Decrease:NotExists", func(t *testing.T) {
+			key := "Decrease:NotExists"
+			impl.ExpireImmediately(context.Background(), key)
+			value, result := impl.Decrease(context.Background(), key, 2)
+			if result != cache.CounterResultEnumSuccess || value != -2 {
+				t.Errorf("Decrease:NotExists failed, incorrect result: %d, %v", value, result)
+			}
+		})
+
+		// 减少一个存在的计数器
+		t.Run("Decrease:Exists", func(t *testing.T) {
+			key := "Decrease:Exists"
+			impl.ExpireImmediately(context.Background(), key)
+			impl.Increase(context.Background(), key, 5)
+			value, result := impl.Decrease(context.Background(), key, 2)
+			if result != cache.CounterResultEnumSuccess || value != 3 {
+				t.Errorf("Decrease:Exists failed, incorrect result: %d, %v", value, result)
+			}
+		})
+
+		// 减少一个非计数器的样例
+		t.Run("Decrease:NotCounter", func(t *testing.T) {
+			key := "Decrease:NotCounter"
+			_ = impl.(*accessor).Store(context.Background(), key, "NotCounter")
+			if _, result := impl.Decrease(context.Background(), key, 1); result != cache.CounterResultEnumFailed {
+				t.Errorf("Decrease:NotCounter failed, incorrect result code: %v", result)
+			}
+		})
+	}
+}
+
+func GetAndSetFunction(impl cache.Counter) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 获取一个不存在的计数器
+		t.Run("Get:NotExists", func(t *testing.T) {
+			key := "Get:NotExists"
+			impl.ExpireImmediately(context.Background(), key)
+			if value, result := impl.Get(context.Background(), key); result != cache.CounterResultEnumNotEffective || value != 0 {
+				t.Errorf("Get:NotExists failed, incorrect result: %d, %v", value, result)
+			}
+		})
+
+		// 设置后获取计数器
+		t.Run("Set:Exists", func(t *testing.T) {
+			key := "Set:Exists"
+			impl.ExpireImmediately(context.Background(), key)
+			impl.Increase(context.Background(), key, 5)
+			if value, result := impl.Set(context.Background(), key, -7); result != cache.CounterResultEnumSuccess || value != -7 {
+				t.Errorf("Set:Exists failed, incorrect set result: %d, %v", value, result)
+			}
+			if value, result := impl.Get(context.Background(), key); result != cache.CounterResultEnumSuccess || value != -7 {
+				t.Errorf("Set:Exists failed, incorrect get result: %d, %v", value, result)
+			}
+		})
+
+		// 设置计数器的值不会改变过期时间
+		t.Run("Set:KeepExpire", func(t *testing.T) {
+			key, expire := "Set:KeepExpire", time.Minute
+			impl.ExpireImmediately(context.Background(), key)
+			impl.IncreaseWithExpireWhenNotExist(context.Background(), key, 1, expire)
+			impl.Set(context.Background(), key, 10)
+
+			exist, expired, getErr := impl.(*accessor).GetExpiredTime(context.Background(), key)
+			if getErr != nil || !exist {
+				t.Errorf("Set:KeepExpire failed, get expired time error: %v", getErr)
+			}
+			if !expiredTimeIsCorrect(time.Until(expired), expire) {
+				t.Errorf("Set:KeepExpire failed, incorrect expired time: %v", time.Until(expired))
+			}
+		})
+
+		// 获取一个非计数器的样例
+		t.Run("Get:NotCounter", func(t *testing.T) {
+			key := "Get:NotCounter"
+			_ = impl.(*accessor).Store(context.Background(), key, "NotCounter")
+			if _, result := impl.Get(context.Background(), key); result != cache.CounterResultEnumFailed {
+				t.Errorf("Get:NotCounter failed, incorrect result code: %v", result)
+			}
+		})
+	}
+}
+
+func IncreaseWithLimitFunction(impl cache.Counter) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 未超过上限时增加计数器
+		t.Run("IncreaseWithLimit:UnderLimit", func(t *testing.T) {
+			key := "IncreaseWithLimit:UnderLimit"
+			impl.ExpireImmediately(context.Background(), key)
+			if value, result := impl.IncreaseWithLimit(context.Background(), key, 3, 3); result != cache.CounterResultEnumSuccess || value != 3 {
+				t.Errorf("IncreaseWithLimit:UnderLimit failed, incorrect result: %d, %v", value, result)
+			}
+		})
+
+		// 超过上限时不增加计数器
+		t.Run("IncreaseWithLimit:OverLimit", func(t *testing.T) {
+			key := "IncreaseWithLimit:OverLimit"
+			impl.ExpireImmediately(context.Background(), key)
+			impl.Increase(context.Background(), key, 2)
+			if value, result := impl.IncreaseWithLimit(context.Background(), key, 2, 3); result != cache.CounterResultEnumNotEffective || value != 2 {
+				t.Errorf("IncreaseWithLimit:OverLimit failed, incorrect result: %d, %v", value, result)
+			}
+			if value, _ := impl.Get(context.Background(), key); value != 2 {
+				t.Errorf("IncreaseWithLimit:OverLimit failed, counter is changed: %d", value)
+			}
+		})
+
+		// 并发增加计数器，成功的次数不超过上限
+		t.Run("IncreaseWithLimit:Concurrent", func(t *testing.T) {
+			key, concurrentNum, limit := "IncreaseWithLimit:Concurrent", 200, int64(50)
+			impl.ExpireImmediately(context.Background(), key)
+
+			wg, succeeded := sync.WaitGroup{}, atomic.Int64{}
+			for i := 0; i < concurrentNum; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, result := impl.IncreaseWithLimit(context.Background(), key, 1, limit); result == cache.CounterResultEnumSuccess {
+						succeeded.Add(1)
+					}
+				}()
+			}
+
+			wg.Wait()
+			if value, _ := impl.Get(context.Background(), key); value != limit || succeeded.Load() != limit {
+				t.Errorf("IncreaseWithLimit:Concurrent failed, value %d, succeeded %d", value, succeeded.Load())
+			}
+		})
+	}
+}
+
+func CompareAndSwapFunction(impl cache.Counter) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 当前值与期望值一致时交换
+		t.Run("CompareAndSwap:Matched", func(t *testing.T) {
+			key := "CompareAndSwap:Matched"
+			impl.ExpireImmediately(context.Background(), key)
+			impl.Increase(context.Background(), key, 1)
+			if value, result := impl.CompareAndSwap(context.Background(), key, 1, 10); result != cache.CounterResultEnumSuccess || value != 10 {
+				t.Errorf("CompareAndSwap:Matched failed, incorrect result: %d, %v", value, result)
+			}
+		})
+
+		// 当前值与期望值不一致时不交换，返回当前值
+		t.Run("CompareAndSwap:NotMatched", func(t *testing.T) {
+			key := "CompareAndSwap:NotMatched"
+			impl.ExpireImmediately(context.Background(), key)
+			impl.Increase(context.Background(), key, 2)
+			if value, result := impl.CompareAndSwap(context.Background(), key, 1, 10); result != cache.CounterResultEnumNotEffective || value != 2 {
+				t.Errorf("CompareAndSwap:NotMatched failed, incorrect result: %d, %v", value, result)
+			}
+		})
+
+		// 不存在的计数器视为0
+		t.Run("CompareAndSwap:NotExists", func(t *testing.T) {
+			key := "CompareAndSwap:NotExists"
+			impl.ExpireImmediately(context.Background(), key)
+			if value, result := impl.CompareAndSwap(context.Background(), key, 0, 5); result != cache.CounterResultEnumSuccess || value != 5 {
+				t.Errorf("CompareAndSwap:NotExists failed, incorrect result: %d, %v", value, result)
+			}
+		})
+	}
+}
+
 func RunCounterTestCases(t *testing.T, impl cache.Counter) {
 	for _, v := range BaseCounterUnitTestCaseList {
 		t.Run(v.CaseName, v.TestFunction(impl))
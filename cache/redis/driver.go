@@ -4,15 +4,38 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/limiter"
 	"github.com/alioth-center/infrastructure/exit"
 	"github.com/alioth-center/infrastructure/utils/values"
 	"github.com/go-redis/redis/v8"
 )
 
-const DriverName = "redis"
+const (
+	DriverName = "redis"
+
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+
+	// blockingPollInterval is the longest time a blocking command waits on the server before it
+	// checks the context again.
+	blockingPollInterval = time.Second * 5
+
+	// popRetryInterval is the interval of polling the lists instead of blocking, which is used for
+	// the lists in different slots of a cluster that can not be waited by one blocking command, and
+	// for the context whose deadline is closer than the one second granularity of the blocking.
+	popRetryInterval = time.Millisecond * 100
+
+	// lockRetryInterval is the interval of retrying to acquire a lock held by others.
+	lockRetryInterval = time.Millisecond * 50
+
+	// deleteByPatternScanCount is the number of keys examined by one scan when deleting by pattern.
+	deleteByPatternScanCount = 100
+)
 
 type Config struct {
 	Address       string `json:"address,omitempty" yaml:"address,omitempty" xml:"address,omitempty"`
@@ -25,25 +48,112 @@ type Config struct {
 	MaxOpen       int    `json:"max_open,omitempty" yaml:"max_open,omitempty" xml:"max_open,omitempty"`
 	Prefix        string `json:"prefix,omitempty" yaml:"prefix,omitempty" xml:"prefix,omitempty"`
 	KeySeparator  string `json:"key_separator,omitempty" yaml:"key_separator,omitempty" xml:"key_separator,omitempty"`
+
+	// Mode selects how to connect to redis, it is ModeStandalone when empty. ModeSentinel connects
+	// to the master named SentinelMasterName found by SentinelAddresses and follows its failover,
+	// ModeCluster discovers the nodes from the seed nodes in ClusterAddresses and ignores
+	// DatabaseIndex. Address is only used by ModeStandalone.
+	Mode               string   `json:"mode,omitempty" yaml:"mode,omitempty" xml:"mode,omitempty"`
+	SentinelMasterName string   `json:"sentinel_master_name,omitempty" yaml:"sentinel_master_name,omitempty" xml:"sentinel_master_name,omitempty"`
+	SentinelAddresses  []string `json:"sentinel_addresses,omitempty" yaml:"sentinel_addresses,omitempty" xml:"sentinel_addresses,omitempty"`
+	SentinelUsername   string   `json:"sentinel_username,omitempty" yaml:"sentinel_username,omitempty" xml:"sentinel_username,omitempty"`
+	SentinelPassword   string   `json:"sentinel_password,omitempty" yaml:"sentinel_password,omitempty" xml:"sentinel_password,omitempty"`
+	ClusterAddresses   []string `json:"cluster_addresses,omitempty" yaml:"cluster_addresses,omitempty" xml:"cluster_addresses,omitempty"`
+
+	// Codec is the name of the cache.Codec used by the *Json methods, it is json when empty.
+	Codec string `json:"codec,omitempty" yaml:"codec,omitempty" xml:"codec,omitempty"`
+
+	// NotifyKeyspaceEvents is set to notify-keyspace-events of the servers when it is not empty,
+	// Subscribe needs the keyspace notifications, such as "KA". Leave it empty when the servers are
+	// configured by others or CONFIG is disabled.
+	NotifyKeyspaceEvents string `json:"notify_keyspace_events,omitempty" yaml:"notify_keyspace_events,omitempty" xml:"notify_keyspace_events,omitempty"`
 }
 
-func newRedisClient(cfg Config) (rds *accessor, err error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:         cfg.Address,
-		Username:     cfg.Username,
-		Password:     cfg.Password,
-		DB:           cfg.DatabaseIndex,
-		MaxRetries:   cfg.MaxRetries,
-		DialTimeout:  time.Second * time.Duration(cfg.TimeoutSecond),
-		ReadTimeout:  time.Second * time.Duration(cfg.TimeoutSecond),
-		WriteTimeout: time.Second * time.Duration(cfg.TimeoutSecond),
-		PoolSize:     cfg.MaxOpen,
-		MaxConnAge:   time.Second * time.Duration(cfg.MaxLifeSecond),
-	})
+// servers describes the servers to connect to, it is used in the error messages.
+func (cfg Config) servers() string {
+	switch cfg.Mode {
+	case ModeSentinel:
+		return fmt.Sprintf("%s of sentinels %s", cfg.SentinelMasterName, strings.Join(cfg.SentinelAddresses, ","))
+	case ModeCluster:
+		return fmt.Sprintf("cluster %s", strings.Join(cfg.ClusterAddresses, ","))
+	default:
+		return cfg.Address
+	}
+}
+
+func newClient(cfg Config) (client redis.UniversalClient, err error) {
+	timeout, maxLife := time.Second*time.Duration(cfg.TimeoutSecond), time.Second*time.Duration(cfg.MaxLifeSecond)
+	switch cfg.Mode {
+	case "", ModeStandalone:
+		client = redis.NewClient(&redis.Options{
+			Addr:         cfg.Address,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			DB:           cfg.DatabaseIndex,
+			MaxRetries:   cfg.MaxRetries,
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+			PoolSize:     cfg.MaxOpen,
+			MaxConnAge:   maxLife,
+		})
+	case ModeSentinel:
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.SentinelMasterName,
+			SentinelAddrs:    cfg.SentinelAddresses,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DatabaseIndex,
+			MaxRetries:       cfg.MaxRetries,
+			DialTimeout:      timeout,
+			ReadTimeout:      timeout,
+			WriteTimeout:     timeout,
+			PoolSize:         cfg.MaxOpen,
+			MaxConnAge:       maxLife,
+		})
+	case ModeCluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.ClusterAddresses,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			MaxRetries:   cfg.MaxRetries,
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+			PoolSize:     cfg.MaxOpen,
+			MaxConnAge:   maxLife,
+		})
+	default:
+		return nil, fmt.Errorf("unknown redis mode %s", cfg.Mode)
+	}
 
 	_, pingErr := client.Ping(context.Background()).Result()
 	if pingErr != nil {
-		return values.Nil[*accessor](), fmt.Errorf("failed to connect redis server %s: %w", cfg.Address, pingErr)
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect redis server %s: %w", cfg.servers(), pingErr)
+	}
+
+	return client, nil
+}
+
+func newKeyBuilder(cfg Config) keyBuilder {
+	return keyBuilder{
+		localRedisKeyPrefix: cfg.Prefix,
+		redisKeySeparator:   cfg.KeySeparator,
+	}
+}
+
+func newRedisClient(cfg Config) (rds *accessor, err error) {
+	codec, codecErr := cache.GetCodec(cfg.Codec)
+	if codecErr != nil {
+		return values.Nil[*accessor](), codecErr
+	}
+
+	client, connectErr := newClient(cfg)
+	if connectErr != nil {
+		return values.Nil[*accessor](), connectErr
 	}
 
 	// 初始化成功，需要注册退出函数
@@ -52,13 +162,18 @@ func newRedisClient(cfg Config) (rds *accessor, err error) {
 		fmt.Println("closed redis client")
 	}, "CLOSE_REDIS_CONN")
 
-	return &accessor{
-		db: client,
-		kb: keyBuilder{
-			localRedisKeyPrefix: cfg.Prefix,
-			redisKeySeparator:   cfg.KeySeparator,
-		},
-	}, nil
+	rds = &accessor{
+		db:    client,
+		kb:    newKeyBuilder(cfg),
+		codec: codec,
+	}
+	if cfg.NotifyKeyspaceEvents != "" {
+		if configErr := rds.enableKeyspaceEvents(context.Background(), cfg.NotifyKeyspaceEvents); configErr != nil {
+			return values.Nil[*accessor](), fmt.Errorf("failed to enable keyspace notifications: %w", configErr)
+		}
+	}
+
+	return rds, nil
 }
 
 func NewRedisCache(cfg Config) (rds cache.Cache, err error) {
@@ -68,3 +183,11 @@ func NewRedisCache(cfg Config) (rds cache.Cache, err error) {
 func NewRedisCounter(cfg Config) (rds cache.Counter, err error) {
 	return newRedisClient(cfg)
 }
+
+func NewRedisLocker(cfg Config) (rds cache.Locker, err error) {
+	return newRedisClient(cfg)
+}
+
+func NewRedisLimiterStorage(cfg Config) (rds limiter.Storage, err error) {
+	return newRedisClient(cfg)
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+// subscriptionBufferSize is the capacity of the channel of a subscription, the events are
+// dropped when it is full, so that a slow receiver never blocks the pub/sub connection.
+const subscriptionBufferSize = 256
+
+// keyspaceEventTypes maps the keyspace notifications to the cache events, the other events such
+// as set, hset and lpush are writes. The changes of the ttl and the creation of the keys are not
+// emitted since every write of them is also notified.
+var keyspaceEventTypes = map[string]cache.CacheEventType{
+	"del":         cache.CacheEventDelete,
+	"evicted":     cache.CacheEventDelete,
+	"rename_from": cache.CacheEventDelete,
+	"expired":     cache.CacheEventExpire,
+	"expire":      "",
+	"persist":     "",
+	"new":         "",
+}
+
+func keyspaceChannelPrefix(database int) string {
+	return "__keyspace@" + strconv.Itoa(database) + "__:"
+}
+
+// Subscribe listens to the keyspace notifications, which must be enabled on the server by
+// notify-keyspace-events, such as "KA", see Config.NotifyKeyspaceEvents. The notifications of a
+// cluster are sent by the node holding the key, so every master is subscribed. The channel is
+// closed at once if any of the masters fails to subscribe.
+func (ra *accessor) Subscribe(ctx context.Context, pattern string) <-chan cache.CacheEvent {
+	events := make(chan cache.CacheEvent, subscriptionBufferSize)
+	masters, mastersErr := ra.masters(ctx)
+	if mastersErr != nil {
+		close(events)
+		return events
+	}
+
+	// 确认所有节点都订阅成功后再开始接收，避免订阅失败时静默地没有事件
+	pubsubs, prefixes := make([]*redis.PubSub, 0, len(masters)), make([]string, 0, len(masters))
+	for _, master := range masters {
+		prefix := keyspaceChannelPrefix(master.Options().DB)
+		pubsub := master.PSubscribe(ctx, prefix+ra.kb.BuildPattern(pattern))
+		pubsubs, prefixes = append(pubsubs, pubsub), append(prefixes, prefix)
+		if _, receiveErr := pubsub.Receive(ctx); receiveErr != nil {
+			for _, subscribed := range pubsubs {
+				_ = subscribed.Close()
+			}
+			close(events)
+			return events
+		}
+	}
+
+	done := make(chan struct{}, len(pubsubs))
+	for i, pubsub := range pubsubs {
+		prefix := prefixes[i]
+		go func() {
+			defer func() { done <- struct{}{} }()
+			defer func() { _ = pubsub.Close() }()
+
+			messages := pubsub.Channel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case message, ok := <-messages:
+					if !ok {
+						return
+					}
+
+					eventType, known := keyspaceEventTypes[message.Payload]
+					if !known {
+						eventType = cache.CacheEventSet
+					}
+					if eventType == "" {
+						continue
+					}
+
+					event := cache.CacheEvent{Type: eventType, Key: ra.kb.TrimKey(strings.TrimPrefix(message.Channel, prefix))}
+					select {
+					case events <- event:
+					default:
+						// 订阅者处理不及时，丢弃事件
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for range pubsubs {
+			<-done
+		}
+		close(events)
+	}()
+
+	return events
+}
+
+// enableKeyspaceEvents sets notify-keyspace-events of every master.
+func (ra *accessor) enableKeyspaceEvents(ctx context.Context, classes string) (err error) {
+	masters, mastersErr := ra.masters(ctx)
+	if mastersErr != nil {
+		return mastersErr
+	}
+
+	for _, master := range masters {
+		if configErr := master.ConfigSet(ctx, "notify-keyspace-events", classes).Err(); configErr != nil {
+			return configErr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache/limiter"
+)
+
+var BaseLimiterUnitTestCaseList = []TestCase[limiter.Storage]{
+	{
+		CaseName:     "FixedWindow",
+		TestFunction: FixedWindowFunction,
+	},
+	{
+		CaseName:     "SlidingLog",
+		TestFunction: SlidingLogFunction,
+	},
+	{
+		CaseName:     "SlidingWindow",
+		TestFunction: SlidingWindowFunction,
+	},
+	{
+		CaseName:     "TokenBucket",
+		TestFunction: TokenBucketFunction,
+	},
+	{
+		CaseName:     "Wait",
+		TestFunction: WaitFunction,
+	},
+}
+
+// exhaustThenRetry takes all the quota of the key, checks that the next request is rejected with a
+// retry after duration, then checks that the request is allowed after waiting for the duration.
+func exhaustThenRetry(t *testing.T, impl limiter.Storage, rule limiter.Rule, key string) {
+	rl, err := limiter.NewLimiter(impl, rule)
+	if err != nil {
+		t.Fatalf("%s case failed: %v", key, err)
+	}
+
+	for i := int64(0); i < rule.Limit; i++ {
+		result, allowErr := rl.Allow(context.Background(), key)
+		if allowErr != nil || !result.Allowed || result.Remaining != rule.Limit-i-1 {
+			t.Fatalf("%s case failed: request %d result %+v, err %v", key, i, result, allowErr)
+		}
+	}
+
+	result, allowErr := rl.Allow(context.Background(), key)
+	if allowErr != nil || result.Allowed || result.Remaining != 0 {
+		t.Fatalf("%s case failed: exhausted result %+v, err %v", key, result, allowErr)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > rule.Window*2 {
+		t.Fatalf("%s case failed: incorrect retry after %v", key, result.RetryAfter)
+	}
+
+	time.Sleep(result.RetryAfter + time.Millisecond*20)
+	if result, allowErr = rl.Allow(context.Background(), key); allowErr != nil || !result.Allowed {
+		t.Errorf("%s case failed: request is not allowed after retry after, result %+v, err %v", key, result, allowErr)
+	}
+}
+
+func FixedWindowFunction(impl limiter.Storage) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 超过窗口配额后被拒绝，窗口结束后恢复
+		t.Run("FixedWindow:Exhaust", func(t *testing.T) {
+			rule := limiter.Rule{Algorithm: limiter.AlgorithmFixedWindow, Limit: 3, Window: time.Millisecond * 300}
+			exhaustThenRetry(t, impl, rule, "FixedWindow:Exhaust")
+		})
+
+		// 被拒绝的请求不消耗配额
+		t.Run("FixedWindow:RejectedNotCounted", func(t *testing.T) {
+			key := "FixedWindow:RejectedNotCounted"
+			rl, _ := limiter.NewLimiter(impl, limiter.Rule{Algorithm: limiter.AlgorithmFixedWindow, Limit: 3, Window: time.Second})
+			_, _ = rl.AllowN(context.Background(), key, 2)
+			if result, _ := rl.AllowN(context.Background(), key, 2); result.Allowed || result.Remaining != 1 {
+				t.Errorf("FixedWindow:RejectedNotCounted case failed: result %+v", result)
+			}
+			if result, _ := rl.Allow(context.Background(), key); !result.Allowed || result.Remaining != 0 {
+				t.Errorf("FixedWindow:RejectedNotCounted case failed: result %+v", result)
+			}
+		})
+	}
+}
+
+func SlidingLogFunction(impl limiter.Storage) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Run("SlidingLog:Exhaust", func(t *testing.T) {
+			rule := limiter.Rule{Algorithm: limiter.AlgorithmSlidingLog, Limit: 3, Window: time.Millisecond * 300}
+			exhaustThenRetry(t, impl, rule, "SlidingLog:Exhaust")
+		})
+
+		// 只需要等待最早的请求离开窗口
+		t.Run("SlidingLog:OldestLeaves", func(t *testing.T) {
+			key := "SlidingLog:OldestLeaves"
+			rl, _ := limiter.NewLimiter(impl, limiter.Rule{Algorithm: limiter.AlgorithmSlidingLog, Limit: 2, Window: time.Millisecond * 400})
+			_, _ = rl.Allow(context.Background(), key)
+			time.Sleep(time.Millisecond * 200)
+			_, _ = rl.Allow(context.Background(), key)
+
+			result, _ := rl.Allow(context.Background(), key)
+			if result.Allowed || result.RetryAfter > time.Millisecond*250 {
+				t.Errorf("SlidingLog:OldestLeaves case failed: result %+v", result)
+			}
+		})
+	}
+}
+
+func SlidingWindowFunction(impl limiter.Storage) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Run("SlidingWindow:Exhaust", func(t *testing.T) {
+			rule := limiter.Rule{Algorithm: limiter.AlgorithmSlidingWindow, Limit: 4, Window: time.Millisecond * 300}
+			exhaustThenRetry(t, impl, rule, "SlidingWindow:Exhaust")
+		})
+	}
+}
+
+func TokenBucketFunction(impl limiter.Storage) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Run("TokenBucket:Exhaust", func(t *testing.T) {
+			rule := limiter.Rule{Algorithm: limiter.AlgorithmTokenBucket, Limit: 3, Window: time.Millisecond * 300}
+			exhaustThenRetry(t, impl, rule, "TokenBucket:Exhaust")
+		})
+
+		// 令牌按比例补充，不需要等待整个窗口
+		t.Run("TokenBucket:Refill", func(t *testing.T) {
+			key := "TokenBucket:Refill"
+			rl, _ := limiter.NewLimiter(impl, limiter.Rule{Algorithm: limiter.AlgorithmTokenBucket, Limit: 4, Window: time.Millisecond * 400})
+			_, _ = rl.AllowN(context.Background(), key, 4)
+
+			result, _ := rl.Allow(context.Background(), key)
+			if result.Allowed || result.RetryAfter > time.Millisecond*100 {
+				t.Errorf("TokenBucket:Refill case failed: result %+v", result)
+			}
+		})
+	}
+}
+
+func WaitFunction(impl limiter.Storage) func(t *testing.T) {
+	return func(t *testing.T) {
+		rl, _ := limiter.NewLimiter(impl, limiter.Rule{Algorithm: limiter.AlgorithmTokenBucket, Limit: 1, Window: time.Millisecond * 200})
+
+		// 等待直到配额恢复
+		t.Run("Wait:Allowed", func(t *testing.T) {
+			key := "Wait:Allowed"
+			_, _ = rl.Allow(context.Background(), key)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := rl.Wait(ctx, key); err != nil {
+				t.Errorf("Wait:Allowed case failed: %v", err)
+			}
+		})
+
+		// 配额恢复前超时
+		t.Run("Wait:Timeout", func(t *testing.T) {
+			key := "Wait:Timeout"
+			_, _ = rl.Allow(context.Background(), key)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+			if err := rl.Wait(ctx, key); err == nil {
+				t.Errorf("Wait:Timeout case failed: wait returns without error")
+			}
+		})
+	}
+}
+
+func RunLimiterTestCases(t *testing.T, impl limiter.Storage) {
+	for _, v := range BaseLimiterUnitTestCaseList {
+		t.Run(v.CaseName, v.TestFunction(impl))
+	}
+}
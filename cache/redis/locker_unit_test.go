@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+var BaseLockerUnitTestCaseList = []TestCase[cache.Locker]{
+	{
+		CaseName:     "TryLock",
+		TestFunction: TryLockFunction,
+	},
+	{
+		CaseName:     "Unlock",
+		TestFunction: UnlockFunction,
+	},
+	{
+		CaseName:     "Lock",
+		TestFunction: LockFunction,
+	},
+	{
+		CaseName:     "Lease",
+		TestFunction: LeaseFunction,
+	},
+}
+
+func TryLockFunction(impl cache.Locker) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 锁被持有时无法再次获取
+		t.Run("TryLock:Exclusive", func(t *testing.T) {
+			key := "TryLock:Exclusive"
+			acquired, token, err := impl.TryLock(context.Background(), key, time.Second)
+			if err != nil || !acquired || token == "" {
+				t.Fatalf("TryLock:Exclusive case failed: acquired %v, token %q, err %v", acquired, token, err)
+			}
+			if acquired, _, err = impl.TryLock(context.Background(), key, time.Second); err != nil || acquired {
+				t.Errorf("TryLock:Exclusive case failed: lock acquired twice, err %v", err)
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+
+		// 过期时间不是正数的锁被拒绝，不会出现立即过期或永不过期的锁
+		t.Run("TryLock:ZeroTTL", func(t *testing.T) {
+			key := "TryLock:ZeroTTL"
+			for i := 0; i < 2; i++ {
+				if acquired, _, err := impl.TryLock(context.Background(), key, 0); acquired || !errors.Is(err, cache.ErrInvalidLockTTL) {
+					t.Errorf("TryLock:ZeroTTL case failed: acquired %v, err %v", acquired, err)
+				}
+			}
+
+			acquired, token, err := impl.TryLock(context.Background(), key, time.Second)
+			if err != nil || !acquired {
+				t.Fatalf("TryLock:ZeroTTL case failed: acquired %v, err %v", acquired, err)
+			}
+			if acquired, _, err = impl.TryLock(context.Background(), key, 0); acquired {
+				t.Errorf("TryLock:ZeroTTL case failed: held lock acquired with zero ttl, err %v", err)
+			}
+			if err = impl.Extend(context.Background(), key, token, 0); !errors.Is(err, cache.ErrInvalidLockTTL) {
+				t.Errorf("TryLock:ZeroTTL case failed: extend with zero ttl err %v", err)
+			}
+			if _, err = impl.Lock(context.Background(), key, -time.Second); !errors.Is(err, cache.ErrInvalidLockTTL) {
+				t.Errorf("TryLock:ZeroTTL case failed: lock with negative ttl err %v", err)
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+
+		// 锁过期后可以重新获取
+		t.Run("TryLock:Expired", func(t *testing.T) {
+			key := "TryLock:Expired"
+			_, _, _ = impl.TryLock(context.Background(), key, time.Millisecond*100)
+			time.Sleep(time.Millisecond * 200)
+			acquired, token, err := impl.TryLock(context.Background(), key, time.Second)
+			if err != nil || !acquired {
+				t.Errorf("TryLock:Expired case failed: acquired %v, err %v", acquired, err)
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+	}
+}
+
+func UnlockFunction(impl cache.Locker) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 使用错误的token释放和续期锁
+		t.Run("Unlock:WrongToken", func(t *testing.T) {
+			key := "Unlock:WrongToken"
+			_, token, _ := impl.TryLock(context.Background(), key, time.Second)
+			if err := impl.Unlock(context.Background(), key, "wrong"); !errors.Is(err, cache.ErrLockNotHeld) {
+				t.Errorf("Unlock:WrongToken case failed: unlock err %v, want ErrLockNotHeld", err)
+			}
+			if err := impl.Extend(context.Background(), key, "wrong", time.Second); !errors.Is(err, cache.ErrLockNotHeld) {
+				t.Errorf("Unlock:WrongToken case failed: extend err %v, want ErrLockNotHeld", err)
+			}
+			if err := impl.Unlock(context.Background(), key, token); err != nil {
+				t.Errorf("Unlock:WrongToken case failed: unlock with correct token err %v", err)
+			}
+		})
+
+		// 释放后锁可以被重新获取，再次释放返回ErrLockNotHeld
+		t.Run("Unlock:Released", func(t *testing.T) {
+			key := "Unlock:Released"
+			_, token, _ := impl.TryLock(context.Background(), key, time.Second)
+			_ = impl.Unlock(context.Background(), key, token)
+			if err := impl.Unlock(context.Background(), key, token); !errors.Is(err, cache.ErrLockNotHeld) {
+				t.Errorf("Unlock:Released case failed: unlock err %v, want ErrLockNotHeld", err)
+			}
+			acquired, token, _ := impl.TryLock(context.Background(), key, time.Second)
+			if !acquired {
+				t.Errorf("Unlock:Released case failed: lock can not be acquired after released")
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+
+		// 续期后锁不会在原来的过期时间释放
+		t.Run("Unlock:Extend", func(t *testing.T) {
+			key := "Unlock:Extend"
+			_, token, _ := impl.TryLock(context.Background(), key, time.Millisecond*200)
+			if err := impl.Extend(context.Background(), key, token, time.Second); err != nil {
+				t.Fatalf("Unlock:Extend case failed: extend err %v", err)
+			}
+			time.Sleep(time.Millisecond * 400)
+			if acquired, _, _ := impl.TryLock(context.Background(), key, time.Second); acquired {
+				t.Errorf("Unlock:Extend case failed: lock expired after extended")
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+	}
+}
+
+func LockFunction(impl cache.Locker) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 阻塞直到锁被释放
+		t.Run("Lock:WaitRelease", func(t *testing.T) {
+			key := "Lock:WaitRelease"
+			_, token, _ := impl.TryLock(context.Background(), key, time.Second*10)
+			go func() {
+				time.Sleep(time.Millisecond * 200)
+				_ = impl.Unlock(context.Background(), key, token)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			defer cancel()
+			newToken, err := impl.Lock(ctx, key, time.Second)
+			if err != nil || newToken == "" || newToken == token {
+				t.Errorf("Lock:WaitRelease case failed: token %q, err %v", newToken, err)
+			}
+			_ = impl.Unlock(context.Background(), key, newToken)
+		})
+
+		// 超时后返回错误
+		t.Run("Lock:Timeout", func(t *testing.T) {
+			key := "Lock:Timeout"
+			_, token, _ := impl.TryLock(context.Background(), key, time.Second*10)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+			defer cancel()
+			if _, err := impl.Lock(ctx, key, time.Second); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Lock:Timeout case failed: err %v, want deadline exceeded", err)
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+	}
+}
+
+func LeaseFunction(impl cache.Locker) func(t *testing.T) {
+	return func(t *testing.T) {
+		// 租约自动续期，超过ttl后仍然持有锁
+		t.Run("Lease:Renew", func(t *testing.T) {
+			key := "Lease:Renew"
+			lease, err := cache.AcquireLease(context.Background(), impl, key, time.Millisecond*300)
+			if err != nil {
+				t.Fatalf("Lease:Renew case failed: acquire err %v", err)
+			}
+
+			time.Sleep(time.Millisecond * 900)
+			if acquired, _, _ := impl.TryLock(context.Background(), key, time.Second); acquired {
+				t.Errorf("Lease:Renew case failed: lock is not renewed")
+			}
+			select {
+			case <-lease.Lost():
+				t.Errorf("Lease:Renew case failed: lease is lost")
+			default:
+			}
+
+			if err = lease.Release(context.Background()); err != nil {
+				t.Errorf("Lease:Renew case failed: release err %v", err)
+			}
+			acquired, token, _ := impl.TryLock(context.Background(), key, time.Second)
+			if !acquired {
+				t.Errorf("Lease:Renew case failed: lock is held after released")
+			}
+			_ = impl.Unlock(context.Background(), key, token)
+		})
+
+		// 过短的ttl无法续期，直接返回错误
+		t.Run("Lease:InvalidTTL", func(t *testing.T) {
+			key := "Lease:InvalidTTL"
+			for _, ttl := range []time.Duration{0, -time.Second, time.Nanosecond} {
+				if _, err := cache.AcquireLease(context.Background(), impl, key, ttl); !errors.Is(err, cache.ErrInvalidLeaseTTL) {
+					t.Errorf("Lease:InvalidTTL case failed: ttl %v, err %v", ttl, err)
+				}
+			}
+			if acquired, token, _ := impl.TryLock(context.Background(), key, time.Second); !acquired {
+				t.Errorf("Lease:InvalidTTL case failed: lock is held by the rejected lease")
+			} else {
+				_ = impl.Unlock(context.Background(), key, token)
+			}
+		})
+
+		t.Run("Lease:Lost", func(t *testing.T) {
+			key := "Lease:Lost"
+			lease, err := cache.AcquireLease(context.Background(), impl, key, time.Millisecond*300)
+			if err != nil {
+				t.Fatalf("Lease:Lost case failed: acquire err %v", err)
+			}
+			_ = impl.Unlock(context.Background(), key, lease.Token())
+
+			select {
+			case <-lease.Lost():
+			case <-time.After(time.Second):
+				t.Errorf("Lease:Lost case failed: lost is not notified")
+			}
+		})
+	}
+}
+
+func RunLockerTestCases(t *testing.T, impl cache.Locker) {
+	for _, v := range BaseLockerUnitTestCaseList {
+		t.Run(v.CaseName, v.TestFunction(impl))
+	}
+}
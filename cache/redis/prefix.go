@@ -40,3 +40,32 @@ func (kb keyBuilder) BuildKey(keys ...string) (result string) {
 func (kb keyBuilder) BuildError(operation string, err error, keys ...string) (result error) {
 	return fmt.Errorf("%s of key %s: %w", operation, kb.BuildKey(keys...), err)
 }
+
+// keyPrefix returns everything BuildKey writes before a single key.
+func (kb keyBuilder) keyPrefix() string {
+	return kb.BuildKey() + kb.redisKeySeparator
+}
+
+// TrimKey returns the original key of a key built by BuildKey.
+func (kb keyBuilder) TrimKey(builtKey string) (key string) {
+	return strings.TrimPrefix(builtKey, kb.keyPrefix())
+}
+
+// BuildPattern builds a glob-style pattern matching the keys built by BuildKey, the prefix is
+// escaped so that the special characters in it are matched literally.
+func (kb keyBuilder) BuildPattern(pattern string) (result string) {
+	builder := strings.Builder{}
+	for _, c := range kb.keyPrefix() {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			builder.WriteByte('\\')
+		}
+		builder.WriteRune(c)
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	builder.WriteString(pattern)
+
+	return builder.String()
+}
@@ -0,0 +1,143 @@
+package redis
+
+import "github.com/go-redis/redis/v8"
+
+var (
+	// unlockScript deletes the lock only if it is held by the token.
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+	// extendScript resets the expiration of the lock only if it is held by the token.
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// The rate limit scripts share the arguments ARGV[1] = limit, ARGV[2] = window in milliseconds
+// and ARGV[3] = n, and reply {allowed, remaining, retry after in milliseconds}. The time of the
+// redis server is used so that the clocks of clients do not need to be synchronized.
+const currentMillisecondsScript = `
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local limit, window, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+`
+
+var (
+	fixedWindowScript = redis.NewScript(currentMillisecondsScript + `
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+if current + n > limit then
+	local ttl = redis.call("PTTL", KEYS[1])
+	if ttl <= 0 then
+		ttl = window
+	end
+	return {0, math.max(limit - current, 0), ttl}
+end
+
+current = redis.call("INCRBY", KEYS[1], n)
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], window)
+end
+return {1, limit - current, 0}
+`)
+
+	// slidingLogScript uses ARGV[4] as the unique prefix of the members recording the requests.
+	slidingLogScript = redis.NewScript(currentMillisecondsScript + `
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count + n > limit then
+	local retry = window
+	local oldest = redis.call("ZRANGE", KEYS[1], count + n - limit - 1, count + n - limit - 1, "WITHSCORES")
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + window - now
+	end
+	return {0, math.max(limit - count, 0), math.max(retry, 1)}
+end
+
+for i = 1, n do
+	redis.call("ZADD", KEYS[1], now, ARGV[4] .. ":" .. i)
+end
+redis.call("PEXPIRE", KEYS[1], window)
+return {1, limit - count - n, 0}
+`)
+
+	slidingWindowScript = redis.NewScript(currentMillisecondsScript + `
+local index = math.floor(now / window)
+local elapsed = now - index * window
+local stored = redis.call("HMGET", KEYS[1], "window", "current", "previous")
+local storedIndex, current, previous = tonumber(stored[1]), tonumber(stored[2]) or 0, tonumber(stored[3]) or 0
+if storedIndex ~= index then
+	if storedIndex == index - 1 then
+		previous = current
+	else
+		previous = 0
+	end
+	current = 0
+end
+
+local estimated = previous * (window - elapsed) / window + current
+if estimated + n > limit then
+	local retry
+	if current + n <= limit then
+		retry = math.ceil(window * (1 - (limit - current - n) / previous)) - elapsed
+	else
+		retry = window - elapsed
+		if current > 0 then
+			retry = retry + math.max(math.ceil(window * (1 - (limit - n) / current)), 0)
+		end
+	end
+	return {0, math.max(math.floor(limit - estimated), 0), math.max(retry, 1)}
+end
+
+redis.call("HSET", KEYS[1], "window", index, "current", current + n, "previous", previous)
+redis.call("PEXPIRE", KEYS[1], window * 2)
+return {1, math.floor(limit - estimated - n), 0}
+`)
+
+	tokenBucketScript = redis.NewScript(currentMillisecondsScript + `
+local rate = limit / window
+local stored = redis.call("HMGET", KEYS[1], "tokens", "timestamp")
+local tokens, timestamp = tonumber(stored[1]), tonumber(stored[2])
+if tokens == nil or timestamp == nil then
+	tokens, timestamp = limit, now
+end
+tokens = math.min(limit, tokens + math.max(now - timestamp, 0) * rate)
+
+if tokens < n then
+	return {0, math.floor(tokens), math.max(math.ceil((n - tokens) / rate), 1)}
+end
+
+tokens = tokens - n
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "timestamp", now)
+redis.call("PEXPIRE", KEYS[1], window)
+return {1, math.floor(tokens), 0}
+`)
+)
+
+var (
+	// increaseWithLimitScript uses ARGV[1] = delta and ARGV[2] = limit, and replies {increased, value}.
+	increaseWithLimitScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1]) or "0"
+if tonumber(current) + tonumber(ARGV[1]) > tonumber(ARGV[2]) then
+	return {0, current}
+end
+return {1, tostring(redis.call("INCRBY", KEYS[1], ARGV[1]))}
+`)
+
+	// compareAndSwapScript uses ARGV[1] = old and ARGV[2] = new, and replies {swapped, value}, the
+	// values are compared as strings so that no precision is lost in lua numbers.
+	compareAndSwapScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1]) or "0"
+if current ~= ARGV[1] then
+	return {0, current}
+end
+redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+return {1, ARGV[2]}
+`)
+)
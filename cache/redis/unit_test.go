@@ -3,6 +3,8 @@ package redis
 import (
 	"os"
 	"testing"
+
+	"github.com/alioth-center/infrastructure/cache"
 )
 
 func TestRedisCache(t *testing.T) {
@@ -34,3 +36,112 @@ func TestRedisCounter(t *testing.T) {
 
 	RunCounterTestCases(t, impl)
 }
+
+func TestRedisLocker(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_TEST") != "true" {
+		t.Skip("skip redis test")
+	}
+
+	impl, initErr := NewRedisLocker(Config{
+		Address: "localhost:6379",
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunLockerTestCases(t, impl)
+}
+
+func TestRedisLimiter(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_TEST") != "true" {
+		t.Skip("skip redis test")
+	}
+
+	impl, initErr := NewRedisLimiterStorage(Config{
+		Address: "localhost:6379",
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunLimiterTestCases(t, impl)
+}
+
+func TestRedisBatcher(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_TEST") != "true" {
+		t.Skip("skip redis test")
+	}
+
+	impl, initErr := NewRedisCache(Config{
+		Address: "localhost:6379",
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunBatchTestCases(t, impl.(cache.Batcher))
+}
+
+func TestRedisSentinelCache(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_SENTINEL_TEST") != "true" {
+		t.Skip("skip redis sentinel test")
+	}
+
+	impl, initErr := NewRedisCache(Config{
+		Mode:               ModeSentinel,
+		SentinelMasterName: "mymaster",
+		SentinelAddresses:  []string{"localhost:26379"},
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunCacheTestCases(t, impl)
+}
+
+func TestRedisClusterCache(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_CLUSTER_TEST") != "true" {
+		t.Skip("skip redis cluster test")
+	}
+
+	impl, initErr := NewRedisCache(Config{
+		Mode:             ModeCluster,
+		ClusterAddresses: []string{"localhost:7000", "localhost:7001", "localhost:7002"},
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunCacheTestCases(t, impl)
+}
+
+func TestRedisLoader(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_TEST") != "true" {
+		t.Skip("skip redis test")
+	}
+
+	impl, initErr := NewRedisCache(Config{
+		Address: "localhost:6379",
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunLoaderTestCases(t, impl)
+}
+
+func TestRedisEvent(t *testing.T) {
+	if os.Getenv("ENABLE_REDIS_TEST") != "true" {
+		t.Skip("skip redis test")
+	}
+
+	impl, initErr := NewRedisCache(Config{
+		Address:              "localhost:6379",
+		NotifyKeyspaceEvents: "KA",
+	})
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	RunEventTestCases(t, impl)
+}
@@ -0,0 +1,570 @@
+package tiered
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+)
+
+// invalidation is the message broadcast to other instances when a key is changed.
+type invalidation struct {
+	Source  string `json:"source"`
+	Key     string `json:"key"`
+	Pattern bool   `json:"pattern,omitempty"`
+}
+
+// accessor serves reads from the local layer when possible and falls back to the remote layer,
+// string and hash values are populated into the local layer on remote hits, set, sorted set and
+// list values are always served by the remote layer.
+type accessor struct {
+	local       cache.Cache
+	remote      cache.Cache
+	broadcaster Broadcaster
+	localExpire time.Duration
+	instance    string
+	codec       cache.Codec
+}
+
+// localExpiration bounds the expiration of a local entry with the remote expiration, so that the
+// local layer never keeps a value longer than the remote layer does.
+func (ta *accessor) localExpiration(remoteExpiration time.Duration) time.Duration {
+	if remoteExpiration <= 0 || remoteExpiration > ta.localExpire {
+		return ta.localExpire
+	}
+
+	return remoteExpiration
+}
+
+func (ta *accessor) storeLocal(ctx context.Context, key string, value string, remoteExpiration time.Duration) {
+	// 本地缓存只是加速层，写入失败时删除本地值即可
+	_ = ta.local.Delete(ctx, key)
+	if ta.local.StoreEX(ctx, key, value, ta.localExpiration(remoteExpiration)) != nil {
+		_ = ta.local.Delete(ctx, key)
+	}
+}
+
+func (ta *accessor) handleInvalidation(message string) {
+	payload := invalidation{}
+	if json.Unmarshal([]byte(message), &payload) != nil || payload.Source == ta.instance {
+		// 无法解析的消息或者自己发出的消息，直接忽略
+		return
+	}
+
+	if payload.Pattern {
+		_, _ = ta.local.DeleteByPattern(context.Background(), payload.Key)
+		return
+	}
+
+	_ = ta.local.Delete(context.Background(), payload.Key)
+}
+
+// publish notifies other instances that the key is changed, the local layer of current instance
+// is maintained by the caller.
+func (ta *accessor) publish(ctx context.Context, key string) (err error) {
+	return ta.broadcast(ctx, invalidation{Source: ta.instance, Key: key})
+}
+
+func (ta *accessor) broadcast(ctx context.Context, payload invalidation) (err error) {
+	if ta.broadcaster == nil {
+		return nil
+	}
+
+	message, _ := json.Marshal(payload)
+	if publishErr := ta.broadcaster.Publish(ctx, string(message)); publishErr != nil {
+		return fmt.Errorf("publish invalidation of key %s: %w", payload.Key, publishErr)
+	}
+
+	return nil
+}
+
+// invalidate removes the key from the local layer of every instance.
+func (ta *accessor) invalidate(ctx context.Context, key string) (err error) {
+	_ = ta.local.Delete(ctx, key)
+	return ta.publish(ctx, key)
+}
+
+func (ta *accessor) loadHash(ctx context.Context, key string) (resultMap map[string]string, err error) {
+	if exist, _ := ta.local.ExistKey(ctx, key); exist {
+		if localMap, localErr := ta.local.HGetAll(ctx, key); localErr == nil {
+			return localMap, nil
+		}
+	}
+
+	remoteMap, remoteErr := ta.remote.HGetAll(ctx, key)
+	if remoteErr != nil {
+		return map[string]string{}, remoteErr
+	}
+	if len(remoteMap) == 0 {
+		// 远程不存在，不需要写入本地
+		return remoteMap, nil
+	}
+
+	remoteExpiration := time.Duration(0)
+	if exist, expiredAt, _ := ta.remote.GetExpiredTime(ctx, key); exist && !expiredAt.IsZero() {
+		remoteExpiration = time.Until(expiredAt)
+	}
+
+	_ = ta.local.Delete(ctx, key)
+	if ta.local.HSetValues(ctx, key, remoteMap) != nil || ta.local.Expire(ctx, key, ta.localExpiration(remoteExpiration)) != nil {
+		_ = ta.local.Delete(ctx, key)
+	}
+
+	return remoteMap, nil
+}
+
+func (ta *accessor) copySenderToReceiver(key string, senderPtr, receiverPtr any) error {
+	rv := reflect.ValueOf(receiverPtr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		// 如果接收者不是指针，或者是空指针，返回错误
+		return fmt.Errorf("copy sender to receiver of key %s: receiver is not pointer or is nil", key)
+	}
+
+	rv.Elem().Set(reflect.ValueOf(senderPtr))
+	return nil
+}
+
+func (ta *accessor) DriverName() string {
+	return DriverName
+}
+
+func (ta *accessor) ExistKey(ctx context.Context, key string) (exist bool, err error) {
+	if localExist, localErr := ta.local.ExistKey(ctx, key); localErr == nil && localExist {
+		return true, nil
+	}
+
+	return ta.remote.ExistKey(ctx, key)
+}
+
+func (ta *accessor) GetExpiredTime(ctx context.Context, key string) (exist bool, expiredAt time.Time, err error) {
+	// 本地过期时间是被截断过的，只有远程的过期时间是准确的
+	return ta.remote.GetExpiredTime(ctx, key)
+}
+
+func (ta *accessor) Load(ctx context.Context, key string) (exist bool, value string, err error) {
+	if localExist, localValue, localErr := ta.local.Load(ctx, key); localErr == nil && localExist {
+		return true, localValue, nil
+	}
+
+	exist, _, value, err = ta.LoadWithEX(ctx, key)
+	return exist, value, err
+}
+
+func (ta *accessor) LoadWithEX(ctx context.Context, key string) (loaded bool, expiredTime time.Duration, value string, err error) {
+	loaded, expiredTime, value, err = ta.remote.LoadWithEX(ctx, key)
+	if err != nil || !loaded {
+		return loaded, expiredTime, value, err
+	}
+
+	ta.storeLocal(ctx, key, value, expiredTime)
+	return true, expiredTime, value, nil
+}
+
+func (ta *accessor) LoadJson(ctx context.Context, key string, receiverPtr any) (exist bool, err error) {
+	exist, value, loadErr := ta.Load(ctx, key)
+	if loadErr != nil || !exist {
+		return exist, loadErr
+	}
+
+	if unmarshalErr := ta.codec.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+		return true, fmt.Errorf("load json failed for key %s: %w", key, unmarshalErr)
+	}
+
+	return true, nil
+}
+
+func (ta *accessor) LoadJsonWithEX(ctx context.Context, key string, receiverPtr any) (exist bool, expiredTime time.Duration, err error) {
+	exist, expiredTime, value, loadErr := ta.LoadWithEX(ctx, key)
+	if loadErr != nil || !exist {
+		return exist, expiredTime, loadErr
+	}
+
+	if unmarshalErr := ta.codec.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+		return true, expiredTime, fmt.Errorf("load json failed for key %s: %w", key, unmarshalErr)
+	}
+
+	return true, expiredTime, nil
+}
+
+func (ta *accessor) Store(ctx context.Context, key string, value string) (err error) {
+	if storeErr := ta.remote.Store(ctx, key, value); storeErr != nil {
+		return storeErr
+	}
+
+	ta.storeLocal(ctx, key, value, 0)
+	return ta.publish(ctx, key)
+}
+
+func (ta *accessor) StoreEX(ctx context.Context, key string, value string, expiration time.Duration) (err error) {
+	if storeErr := ta.remote.StoreEX(ctx, key, value, expiration); storeErr != nil {
+		return storeErr
+	}
+
+	ta.storeLocal(ctx, key, value, expiration)
+	return ta.publish(ctx, key)
+}
+
+func (ta *accessor) StoreJson(ctx context.Context, key string, senderPtr any) (err error) {
+	marshaled, marshalErr := ta.codec.Marshal(senderPtr)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal json failed for key %s: %w", key, marshalErr)
+	}
+
+	return ta.Store(ctx, key, string(marshaled))
+}
+
+func (ta *accessor) StoreJsonEX(ctx context.Context, key string, senderPtr any, expiration time.Duration) (err error) {
+	marshaled, marshalErr := ta.codec.Marshal(senderPtr)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal json failed for key %s: %w", key, marshalErr)
+	}
+
+	return ta.StoreEX(ctx, key, string(marshaled), expiration)
+}
+
+func (ta *accessor) Delete(ctx context.Context, key string) (err error) {
+	if deleteErr := ta.remote.Delete(ctx, key); deleteErr != nil {
+		return deleteErr
+	}
+
+	return ta.invalidate(ctx, key)
+}
+
+func (ta *accessor) LoadAndDelete(ctx context.Context, key string) (loaded bool, value string, err error) {
+	loaded, value, err = ta.remote.LoadAndDelete(ctx, key)
+	if err != nil {
+		return loaded, value, err
+	}
+
+	return loaded, value, ta.invalidate(ctx, key)
+}
+
+func (ta *accessor) LoadAndDeleteJson(ctx context.Context, key string, receivePtr any) (loaded bool, err error) {
+	loaded, value, loadErr := ta.LoadAndDelete(ctx, key)
+	if loadErr != nil || !loaded {
+		return loaded, loadErr
+	}
+
+	if unmarshalErr := ta.codec.Unmarshal([]byte(value), receivePtr); unmarshalErr != nil {
+		return true, fmt.Errorf("load json failed for key %s: %w", key, unmarshalErr)
+	}
+
+	return true, nil
+}
+
+func (ta *accessor) LoadOrStore(ctx context.Context, key string, storeValue string) (loaded bool, value string, err error) {
+	loaded, value, err = ta.remote.LoadOrStore(ctx, key, storeValue)
+	if err != nil || loaded {
+		return loaded, value, err
+	}
+
+	ta.storeLocal(ctx, key, value, 0)
+	return false, value, ta.publish(ctx, key)
+}
+
+func (ta *accessor) LoadOrStoreEX(ctx context.Context, key string, storeValue string, expiration time.Duration) (loaded bool, value string, err error) {
+	loaded, value, err = ta.remote.LoadOrStoreEX(ctx, key, storeValue, expiration)
+	if err != nil || loaded {
+		return loaded, value, err
+	}
+
+	ta.storeLocal(ctx, key, value, expiration)
+	return false, value, ta.publish(ctx, key)
+}
+
+func (ta *accessor) LoadOrStoreJson(ctx context.Context, key string, senderPtr any, receiverPtr any) (loaded bool, err error) {
+	payload, marshalErr := ta.codec.Marshal(senderPtr)
+	if marshalErr != nil {
+		return false, fmt.Errorf("marshal json failed for key %s: %w", key, marshalErr)
+	}
+
+	loaded, value, loadErr := ta.LoadOrStore(ctx, key, string(payload))
+	if loadErr != nil {
+		return loaded, loadErr
+	}
+	if !loaded {
+		return false, ta.copySenderToReceiver(key, senderPtr, receiverPtr)
+	}
+
+	if unmarshalErr := ta.codec.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+		return true, fmt.Errorf("load json failed for key %s: %w", key, unmarshalErr)
+	}
+
+	return true, nil
+}
+
+func (ta *accessor) LoadOrStoreJsonEX(ctx context.Context, key string, senderPtr any, receiverPtr any, expiration time.Duration) (loaded bool, err error) {
+	payload, marshalErr := ta.codec.Marshal(senderPtr)
+	if marshalErr != nil {
+		return false, fmt.Errorf("marshal json failed for key %s: %w", key, marshalErr)
+	}
+
+	loaded, value, loadErr := ta.LoadOrStoreEX(ctx, key, string(payload), expiration)
+	if loadErr != nil {
+		return loaded, loadErr
+	}
+	if !loaded {
+		return false, ta.copySenderToReceiver(key, senderPtr, receiverPtr)
+	}
+
+	if unmarshalErr := ta.codec.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+		return true, fmt.Errorf("load json failed for key %s: %w", key, unmarshalErr)
+	}
+
+	return true, nil
+}
+
+func (ta *accessor) IsMember(ctx context.Context, key string, member string) (isMember bool, err error) {
+	return ta.remote.IsMember(ctx, key, member)
+}
+
+func (ta *accessor) IsMembers(ctx context.Context, key string, members ...string) (isMembers bool, err error) {
+	return ta.remote.IsMembers(ctx, key, members...)
+}
+
+func (ta *accessor) AddMember(ctx context.Context, key string, member string) (err error) {
+	return ta.remote.AddMember(ctx, key, member)
+}
+
+func (ta *accessor) AddMembers(ctx context.Context, key string, members ...string) (err error) {
+	return ta.remote.AddMembers(ctx, key, members...)
+}
+
+func (ta *accessor) RemoveMember(ctx context.Context, key string, member string) (err error) {
+	return ta.remote.RemoveMember(ctx, key, member)
+}
+
+func (ta *accessor) GetMembers(ctx context.Context, key string) (members []string, err error) {
+	return ta.remote.GetMembers(ctx, key)
+}
+
+func (ta *accessor) GetRandomMember(ctx context.Context, key string) (member string, err error) {
+	return ta.remote.GetRandomMember(ctx, key)
+}
+
+func (ta *accessor) GetRandomMembers(ctx context.Context, key string, count int64) (members []string, err error) {
+	return ta.remote.GetRandomMembers(ctx, key, count)
+}
+
+func (ta *accessor) HGetValue(ctx context.Context, key string, field string) (exist bool, value string, err error) {
+	resultMap, loadErr := ta.loadHash(ctx, key)
+	if loadErr != nil {
+		return false, "", loadErr
+	}
+
+	value, exist = resultMap[field]
+	return exist, value, nil
+}
+
+func (ta *accessor) HGetValues(ctx context.Context, key string, fields ...string) (resultMap map[string]string, err error) {
+	if len(fields) == 0 {
+		return map[string]string{}, nil
+	}
+
+	allFields, loadErr := ta.loadHash(ctx, key)
+	if loadErr != nil {
+		return map[string]string{}, loadErr
+	}
+
+	resultMap = make(map[string]string, len(fields))
+	for _, field := range fields {
+		resultMap[field] = allFields[field]
+	}
+
+	return resultMap, nil
+}
+
+func (ta *accessor) HGetJson(ctx context.Context, key string, field string, receiverPtr any) (exist bool, err error) {
+	exist, value, loadErr := ta.HGetValue(ctx, key, field)
+	if loadErr != nil || !exist {
+		return exist, loadErr
+	}
+
+	if unmarshalErr := ta.codec.Unmarshal([]byte(value), receiverPtr); unmarshalErr != nil {
+		return true, fmt.Errorf("unmarshal hash key %s field %s error: %w", key, field, unmarshalErr)
+	}
+
+	return true, nil
+}
+
+func (ta *accessor) HGetAll(ctx context.Context, key string) (resultMap map[string]string, err error) {
+	return ta.loadHash(ctx, key)
+}
+
+func (ta *accessor) HGetAllJson(ctx context.Context, key string, receiverPtr any) (err error) {
+	result, loadErr := ta.loadHash(ctx, key)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	buffer, marshalErr := json.Marshal(&result)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal hash key %s json data: %w", key, marshalErr)
+	}
+	if unmarshalErr := json.Unmarshal(buffer, receiverPtr); unmarshalErr != nil {
+		return fmt.Errorf("unmarshal hash key %s json data: %w", key, unmarshalErr)
+	}
+
+	return nil
+}
+
+func (ta *accessor) HSetValue(ctx context.Context, key string, field string, value string) (err error) {
+	if setErr := ta.remote.HSetValue(ctx, key, field, value); setErr != nil {
+		return setErr
+	}
+
+	// 本地只保存完整的hash，不存在时等待下次读取时填充
+	if exist, _ := ta.local.ExistKey(ctx, key); exist && ta.local.HSetValue(ctx, key, field, value) != nil {
+		_ = ta.local.Delete(ctx, key)
+	}
+
+	return ta.publish(ctx, key)
+}
+
+func (ta *accessor) HSetValues(ctx context.Context, key string, values map[string]string) (err error) {
+	if setErr := ta.remote.HSetValues(ctx, key, values); setErr != nil {
+		return setErr
+	}
+
+	// 本地只保存完整的hash，不存在时等待下次读取时填充
+	if exist, _ := ta.local.ExistKey(ctx, key); exist && ta.local.HSetValues(ctx, key, values) != nil {
+		_ = ta.local.Delete(ctx, key)
+	}
+
+	return ta.publish(ctx, key)
+}
+
+func (ta *accessor) HRemoveValue(ctx context.Context, key string, field string) (err error) {
+	if removeErr := ta.remote.HRemoveValue(ctx, key, field); removeErr != nil {
+		return removeErr
+	}
+
+	return ta.invalidate(ctx, key)
+}
+
+func (ta *accessor) HRemoveValues(ctx context.Context, key string, fields ...string) (err error) {
+	if removeErr := ta.remote.HRemoveValues(ctx, key, fields...); removeErr != nil {
+		return removeErr
+	}
+
+	return ta.invalidate(ctx, key)
+}
+
+func (ta *accessor) Expire(ctx context.Context, key string, expire time.Duration) (err error) {
+	if expireErr := ta.remote.Expire(ctx, key, expire); expireErr != nil {
+		return expireErr
+	}
+
+	return ta.invalidate(ctx, key)
+}
+
+func (ta *accessor) Persist(ctx context.Context, key string) (err error) {
+	if persistErr := ta.remote.Persist(ctx, key); persistErr != nil {
+		return persistErr
+	}
+
+	// 本地值的过期时间由本地缓存决定，只需要通知其他实例重新读取
+	return ta.invalidate(ctx, key)
+}
+
+func (ta *accessor) Type(ctx context.Context, key string) (exist bool, keyType string, err error) {
+	return ta.remote.Type(ctx, key)
+}
+
+func (ta *accessor) Scan(ctx context.Context, cursor uint64, pattern string, count int64) (keys []string, nextCursor uint64, err error) {
+	return ta.remote.Scan(ctx, cursor, pattern, count)
+}
+
+func (ta *accessor) DeleteByPattern(ctx context.Context, pattern string) (deleted int64, err error) {
+	deleted, err = ta.remote.DeleteByPattern(ctx, pattern)
+	if err != nil {
+		return deleted, err
+	}
+
+	_, _ = ta.local.DeleteByPattern(ctx, pattern)
+	return deleted, ta.broadcast(ctx, invalidation{Source: ta.instance, Key: pattern, Pattern: true})
+}
+
+func (ta *accessor) ZAdd(ctx context.Context, key string, members ...cache.ZMember) (added int64, err error) {
+	return ta.remote.ZAdd(ctx, key, members...)
+}
+
+func (ta *accessor) ZIncrBy(ctx context.Context, key string, member string, increment float64) (score float64, err error) {
+	return ta.remote.ZIncrBy(ctx, key, member, increment)
+}
+
+func (ta *accessor) ZScore(ctx context.Context, key string, member string) (exist bool, score float64, err error) {
+	return ta.remote.ZScore(ctx, key, member)
+}
+
+func (ta *accessor) ZRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error) {
+	return ta.remote.ZRank(ctx, key, member)
+}
+
+func (ta *accessor) ZRevRank(ctx context.Context, key string, member string) (exist bool, rank int64, err error) {
+	return ta.remote.ZRevRank(ctx, key, member)
+}
+
+func (ta *accessor) ZRange(ctx context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	return ta.remote.ZRange(ctx, key, start, stop)
+}
+
+func (ta *accessor) ZRevRange(ctx context.Context, key string, start, stop int64) (members []cache.ZMember, err error) {
+	return ta.remote.ZRevRange(ctx, key, start, stop)
+}
+
+func (ta *accessor) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) (members []cache.ZMember, err error) {
+	return ta.remote.ZRangeByScore(ctx, key, min, max, offset, count)
+}
+
+func (ta *accessor) ZRem(ctx context.Context, key string, members ...string) (removed int64, err error) {
+	return ta.remote.ZRem(ctx, key, members...)
+}
+
+func (ta *accessor) ZCard(ctx context.Context, key string) (count int64, err error) {
+	return ta.remote.ZCard(ctx, key)
+}
+
+func (ta *accessor) ZPopMin(ctx context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	return ta.remote.ZPopMin(ctx, key, count)
+}
+
+func (ta *accessor) ZPopMax(ctx context.Context, key string, count int64) (members []cache.ZMember, err error) {
+	return ta.remote.ZPopMax(ctx, key, count)
+}
+
+func (ta *accessor) LPush(ctx context.Context, key string, values ...string) (length int64, err error) {
+	return ta.remote.LPush(ctx, key, values...)
+}
+
+func (ta *accessor) RPush(ctx context.Context, key string, values ...string) (length int64, err error) {
+	return ta.remote.RPush(ctx, key, values...)
+}
+
+func (ta *accessor) LPop(ctx context.Context, key string) (exist bool, value string, err error) {
+	return ta.remote.LPop(ctx, key)
+}
+
+func (ta *accessor) RPop(ctx context.Context, key string) (exist bool, value string, err error) {
+	return ta.remote.RPop(ctx, key)
+}
+
+func (ta *accessor) LRange(ctx context.Context, key string, start, stop int64) (values []string, err error) {
+	return ta.remote.LRange(ctx, key, start, stop)
+}
+
+func (ta *accessor) LLen(ctx context.Context, key string) (length int64, err error) {
+	return ta.remote.LLen(ctx, key)
+}
+
+func (ta *accessor) BLPop(ctx context.Context, keys ...string) (popped bool, key string, value string, err error) {
+	return ta.remote.BLPop(ctx, keys...)
+}
+
+// Subscribe emits the events of the remote layer, which is shared by all the instances.
+func (ta *accessor) Subscribe(ctx context.Context, pattern string) <-chan cache.CacheEvent {
+	return ta.remote.Subscribe(ctx, pattern)
+}
@@ -0,0 +1,119 @@
+package tiered
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/memory"
+	"github.com/alioth-center/infrastructure/cache/redis"
+	"github.com/alioth-center/infrastructure/exit"
+	"github.com/alioth-center/infrastructure/utils/values"
+	"github.com/google/uuid"
+)
+
+const (
+	DriverName = "tiered"
+
+	defaultLocalExpireSecond   = 60
+	defaultInvalidationChannel = "tiered_cache_invalidation"
+)
+
+type Config struct {
+	Memory              memory.Config `json:"memory,omitempty" yaml:"memory,omitempty" xml:"memory,omitempty"`
+	Redis               redis.Config  `json:"redis,omitempty" yaml:"redis,omitempty" xml:"redis,omitempty"`
+	LocalExpireSecond   int           `json:"local_expire_second,omitempty" yaml:"local_expire_second,omitempty" xml:"local_expire_second,omitempty"`
+	InvalidationChannel string        `json:"invalidation_channel,omitempty" yaml:"invalidation_channel,omitempty" xml:"invalidation_channel,omitempty"`
+
+	// Codec is the name of the cache.Codec used by the *Json methods, the values are encoded by
+	// the tiered cache before they are stored into the layers, it is json when empty.
+	Codec string `json:"codec,omitempty" yaml:"codec,omitempty" xml:"codec,omitempty"`
+}
+
+// Broadcaster delivers invalidation messages between cache instances, redis.Broadcaster is the
+// implementation used in production.
+type Broadcaster interface {
+	Publish(ctx context.Context, message string) (err error)
+	Subscribe(ctx context.Context, handler func(message string)) (err error)
+}
+
+func newTieredCache(local, remote cache.Cache, broadcaster Broadcaster, localExpire time.Duration, codecName string) (tc *accessor, err error) {
+	if localExpire <= 0 {
+		localExpire = time.Second * defaultLocalExpireSecond
+	}
+	codec, codecErr := cache.GetCodec(codecName)
+	if codecErr != nil {
+		return values.Nil[*accessor](), codecErr
+	}
+
+	tieredCache := &accessor{
+		local:       local,
+		remote:      remote,
+		broadcaster: broadcaster,
+		localExpire: localExpire,
+		instance:    uuid.NewString(),
+		codec:       codec,
+	}
+
+	if broadcaster != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		if subscribeErr := broadcaster.Subscribe(ctx, tieredCache.handleInvalidation); subscribeErr != nil {
+			cancel()
+			return values.Nil[*accessor](), fmt.Errorf("failed to subscribe invalidation messages: %w", subscribeErr)
+		}
+
+		// 订阅成功，需要注册退出函数
+		exit.RegisterExitEvent(func(_ os.Signal) {
+			cancel()
+			fmt.Println("closed tiered cache")
+		}, "CLOSE_TIERED_CACHE")
+	}
+
+	return tieredCache, nil
+}
+
+// NewTieredCache creates a cache with a memory layer in front of a redis layer, the memory layer
+// of every instance is invalidated through redis pub/sub when a key is changed by any of them.
+func NewTieredCache(cfg Config) (tc cache.Cache, err error) {
+	if _, codecErr := cache.GetCodec(cfg.Codec); codecErr != nil {
+		return nil, codecErr
+	}
+	if validateErr := cfg.Memory.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+
+	remote, remoteErr := redis.NewRedisCache(cfg.Redis)
+	if remoteErr != nil {
+		return nil, remoteErr
+	}
+
+	channel := cfg.InvalidationChannel
+	if channel == "" {
+		channel = defaultInvalidationChannel
+	}
+	broadcaster, broadcasterErr := redis.NewRedisBroadcaster(cfg.Redis, channel)
+	if broadcasterErr != nil {
+		return nil, broadcasterErr
+	}
+
+	tieredCache, initErr := newTieredCache(memory.NewMemoryCache(cfg.Memory), remote, broadcaster, time.Second*time.Duration(cfg.LocalExpireSecond), cfg.Codec)
+	if initErr != nil {
+		return nil, initErr
+	}
+
+	return tieredCache, nil
+}
+
+// NewTieredCacheWithLayers composes a tiered cache from existing layers, broadcaster can be nil
+// when there is only one instance sharing the remote layer. The values of the *Json methods are
+// encoded with json.
+func NewTieredCacheWithLayers(local, remote cache.Cache, broadcaster Broadcaster, localExpire time.Duration) (tc cache.Cache, err error) {
+	tieredCache, initErr := newTieredCache(local, remote, broadcaster, localExpire, cache.CodecJson)
+	if initErr != nil {
+		return nil, initErr
+	}
+
+	return tieredCache, nil
+}
@@ -0,0 +1,144 @@
+package tiered
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/cache"
+	"github.com/alioth-center/infrastructure/cache/memory"
+)
+
+type localBroadcaster struct {
+	mtx      sync.RWMutex
+	handlers []func(message string)
+}
+
+func (b *localBroadcaster) Publish(_ context.Context, message string) error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	for _, handler := range b.handlers {
+		handler(message)
+	}
+
+	return nil
+}
+
+func (b *localBroadcaster) Subscribe(_ context.Context, handler func(message string)) error {
+	b.mtx.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mtx.Unlock()
+	return nil
+}
+
+func newTestInstance(t *testing.T, remote cache.Cache, broadcaster Broadcaster) (tc cache.Cache, local cache.Cache) {
+	local = memory.NewMemoryCache(memory.Config{})
+	tc, initErr := NewTieredCacheWithLayers(local, remote, broadcaster, time.Minute)
+	if initErr != nil {
+		t.Fatalf("create tiered cache failed: %v", initErr)
+	}
+
+	return tc, local
+}
+
+func TestTieredCache(t *testing.T) {
+	remote, broadcaster := memory.NewMemoryCache(memory.Config{}), &localBroadcaster{}
+	impl, local := newTestInstance(t, remote, broadcaster)
+	another, anotherLocal := newTestInstance(t, remote, broadcaster)
+
+	// 远程命中时写入本地，并且本地过期时间被截断
+	t.Run("Tiered:ReadThrough", func(t *testing.T) {
+		key := "Tiered:ReadThrough"
+		if storeErr := remote.StoreEX(context.Background(), key, "value", time.Hour); storeErr != nil {
+			t.Fatalf("Tiered:ReadThrough case failed when storing remote: %v", storeErr)
+		}
+
+		exist, value, loadErr := impl.Load(context.Background(), key)
+		if loadErr != nil || !exist || value != "value" {
+			t.Errorf("Tiered:ReadThrough case failed: exist %v, value %s, error %v", exist, value, loadErr)
+		}
+
+		localExist, expiredAt, _ := local.GetExpiredTime(context.Background(), key)
+		if !localExist {
+			t.Errorf("Tiered:ReadThrough case failed: local layer is not populated")
+		}
+		if time.Until(expiredAt) > time.Minute {
+			t.Errorf("Tiered:ReadThrough case failed: local expiration is not bounded")
+		}
+	})
+
+	// 写入时同时写入两层
+	t.Run("Tiered:WriteThrough", func(t *testing.T) {
+		key := "Tiered:WriteThrough"
+		if storeErr := impl.Store(context.Background(), key, "value"); storeErr != nil {
+			t.Fatalf("Tiered:WriteThrough case failed when storing: %v", storeErr)
+		}
+
+		if exist, value, _ := local.Load(context.Background(), key); !exist || value != "value" {
+			t.Errorf("Tiered:WriteThrough case failed: local layer is not written")
+		}
+		if exist, value, _ := remote.Load(context.Background(), key); !exist || value != "value" {
+			t.Errorf("Tiered:WriteThrough case failed: remote layer is not written")
+		}
+	})
+
+	// 删除时通知其他实例删除本地值
+	t.Run("Tiered:Invalidation", func(t *testing.T) {
+		key := "Tiered:Invalidation"
+		if storeErr := impl.Store(context.Background(), key, "value"); storeErr != nil {
+			t.Fatalf("Tiered:Invalidation case failed when storing: %v", storeErr)
+		}
+		if exist, _, _ := another.Load(context.Background(), key); !exist {
+			t.Fatalf("Tiered:Invalidation case failed: another instance can not load value")
+		}
+
+		if deleteErr := impl.Delete(context.Background(), key); deleteErr != nil {
+			t.Fatalf("Tiered:Invalidation case failed when deleting: %v", deleteErr)
+		}
+		if exist, _, _ := anotherLocal.Load(context.Background(), key); exist {
+			t.Errorf("Tiered:Invalidation case failed: local layer of another instance is not invalidated")
+		}
+		if exist, _, _ := another.Load(context.Background(), key); exist {
+			t.Errorf("Tiered:Invalidation case failed: value still exists after deleting")
+		}
+	})
+
+	// 其他实例更新hash后，本地缓存的hash被淘汰
+	t.Run("Tiered:Hash", func(t *testing.T) {
+		key := "Tiered:Hash"
+		if setErr := impl.HSetValue(context.Background(), key, "first", "1"); setErr != nil {
+			t.Fatalf("Tiered:Hash case failed when setting value: %v", setErr)
+		}
+		if all, _ := another.HGetAll(context.Background(), key); len(all) != 1 {
+			t.Fatalf("Tiered:Hash case failed: incorrect hash %v", all)
+		}
+
+		if setErr := impl.HSetValue(context.Background(), key, "second", "2"); setErr != nil {
+			t.Fatalf("Tiered:Hash case failed when setting value: %v", setErr)
+		}
+		exist, value, getErr := another.HGetValue(context.Background(), key, "second")
+		if getErr != nil || !exist || value != "2" {
+			t.Errorf("Tiered:Hash case failed: exist %v, value %s, error %v", exist, value, getErr)
+		}
+	})
+
+	// 按模式删除时通知其他实例删除匹配的本地值
+	t.Run("Tiered:DeleteByPattern", func(t *testing.T) {
+		keys := []string{"Tiered:DeleteByPattern:1", "Tiered:DeleteByPattern:2"}
+		for _, key := range keys {
+			_ = impl.Store(context.Background(), key, "value")
+			_, _, _ = another.Load(context.Background(), key)
+		}
+
+		deleted, deleteErr := impl.DeleteByPattern(context.Background(), "Tiered:DeleteByPattern:*")
+		if deleteErr != nil || deleted != 2 {
+			t.Fatalf("Tiered:DeleteByPattern case failed: deleted %d, error %v", deleted, deleteErr)
+		}
+		for _, key := range keys {
+			if exist, _, _ := anotherLocal.Load(context.Background(), key); exist {
+				t.Errorf("Tiered:DeleteByPattern case failed: local value of %s is not invalidated", key)
+			}
+		}
+	})
+}
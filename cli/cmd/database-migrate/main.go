@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	flags := &migrateFlags{}
+	rootCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply and roll back versioned SQL migrations",
+	}
+	rootCmd.PersistentFlags().StringVar(&flags.driver, "driver", "", "database driver, one of mysql, postgres and sqlite")
+	rootCmd.PersistentFlags().StringVar(&flags.dsn, "dsn", "", "data source name of the database")
+	rootCmd.PersistentFlags().StringVar(&flags.dir, "dir", "migrations", "directory of the migration files")
+	rootCmd.PersistentFlags().StringVar(&flags.table, "table", "schema_migrations", "name of the migration table")
+	rootCmd.PersistentFlags().BoolVar(&flags.dryRun, "dry-run", false, "print the migrations without executing them")
+	rootCmd.PersistentFlags().DurationVar(&flags.lockTimeout, "lock-timeout", time.Minute, "time to wait for the other migrators")
+
+	var target int64
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending migrations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUp(cmd.Context(), flags, target); err != nil {
+				log.Fatalf("Error applying migrations: %v", err)
+			}
+		},
+	}
+	upCmd.Flags().Int64Var(&target, "to", 0, "apply the migrations up to this version, all by default")
+
+	var steps int
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the last applied migrations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if steps < 0 {
+				log.Fatalf("Invalid steps %d: must not be negative", steps)
+			}
+			if err := runDown(cmd.Context(), flags, steps); err != nil {
+				log.Fatalf("Error rolling back migrations: %v", err)
+			}
+		},
+	}
+	downCmd.Flags().IntVar(&steps, "steps", 1, "number of the migrations to roll back")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the states of the migrations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runStatus(cmd.Context(), flags); err != nil {
+				log.Fatalf("Error reading migration status: %v", err)
+			}
+		},
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create the up and down files of a new migration",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := createMigration(flags.dir, args[0], time.Now()); err != nil {
+				log.Fatalf("Error creating migration: %v", err)
+			}
+		},
+	}
+
+	unlockCmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "Remove the lock left by a crashed migrator",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUnlock(cmd.Context(), flags); err != nil {
+				log.Fatalf("Error removing migration lock: %v", err)
+			}
+		},
+	}
+
+	rootCmd.AddCommand(upCmd, downCmd, statusCmd, createCmd, unlockCmd)
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Error executing command: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/alioth-center/infrastructure/database"
+	"github.com/alioth-center/infrastructure/database/migration"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const versionLayout = "20060102150405"
+
+var migrationNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type migrateFlags struct {
+	driver      string
+	dsn         string
+	dir         string
+	table       string
+	dryRun      bool
+	lockTimeout time.Duration
+}
+
+func openDatabase(driver, dsn string) (database.DatabaseV2, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case "mysql":
+		dialector = mysql.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	case "sqlite":
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported driver %q, expected mysql, postgres or sqlite", driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("open %s database error: %w", driver, err)
+	}
+
+	return &database.BaseDatabaseImplementV2{Db: db}, nil
+}
+
+func newMigrator(flags *migrateFlags) (*migration.Migrator, error) {
+	db, err := openDatabase(flags.driver, flags.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := migration.LoadSqlMigrations(os.DirFS(flags.dir), ".", migration.WithDraftHandlerOpts(func(version int64, name string) {
+		fmt.Printf("warning: skipped migration %d_%s without up statements\n", version, name)
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []migration.Option{migration.WithTableOpts(flags.table), migration.WithLockTimeoutOpts(flags.lockTimeout)}
+	if flags.dryRun {
+		opts = append(opts, migration.WithDryRunOpts())
+	}
+
+	return migration.NewMigrator(db, migrations, opts...)
+}
+
+func runUp(ctx context.Context, flags *migrateFlags, target int64) error {
+	migrator, err := newMigrator(flags)
+	if err != nil {
+		return err
+	}
+
+	steps, err := migrator.UpTo(ctx, target)
+	printSteps(steps, flags.dryRun)
+	return err
+}
+
+func runDown(ctx context.Context, flags *migrateFlags, n int) error {
+	migrator, err := newMigrator(flags)
+	if err != nil {
+		return err
+	}
+
+	steps, err := migrator.Down(ctx, n)
+	printSteps(steps, flags.dryRun)
+	return err
+}
+
+func runStatus(ctx context.Context, flags *migrateFlags) error {
+	migrator, err := newMigrator(flags)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.State != migration.StatePending {
+			appliedAt = status.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Printf("%-16d %-18s %-20s %s\n", status.Version, status.State, appliedAt, status.Name)
+	}
+
+	return nil
+}
+
+func runUnlock(ctx context.Context, flags *migrateFlags) error {
+	migrator, err := newMigrator(flags)
+	if err != nil {
+		return err
+	}
+
+	return migrator.ForceUnlock(ctx)
+}
+
+func printSteps(steps []migration.Step, dryRun bool) {
+	for _, step := range steps {
+		if !dryRun {
+			fmt.Printf("%s %d_%s (%s)\n", step.Direction, step.Version, step.Name, step.Duration)
+			continue
+		}
+
+		fmt.Printf("[dry-run] %s %d_%s\n", step.Direction, step.Version, step.Name)
+		if step.Sql != "" {
+			fmt.Println(step.Sql)
+		}
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("no migrations to execute")
+	}
+}
+
+func createMigration(dir, name string, now time.Time) error {
+	if !migrationNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid migration name %q, only letters, digits and underscores are allowed", name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var version int64
+	_, _ = fmt.Sscan(now.Format(versionLayout), &version)
+	up, down := migration.FileNames(version, name)
+	var created []string
+	for _, fileName := range []string{up, down} {
+		path := filepath.Join(dir, fileName)
+		if err := createStub(path, "-- "+fileName+"\n-- the migration is skipped until the up file has statements\n"); err != nil {
+			// 不保留只创建了一半的迁移
+			for _, createdPath := range created {
+				_ = os.Remove(createdPath)
+			}
+			return err
+		}
+		created = append(created, path)
+	}
+	for _, path := range created {
+		fmt.Println("created", path)
+	}
+
+	return nil
+}
+
+// createStub creates the file with the content, an existing file is never overwritten, such as
+// the migration of the same name created in the same second.
+func createStub(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,286 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultBatchSize = 500
+
+type upsertKind int
+
+const (
+	upsertNone upsertKind = iota
+	upsertDoNothing
+	upsertUpdateColumns
+	upsertUpdateAll
+)
+
+// UpsertStrategy decides how the conflicting rows are handled by the upserting, it is created by
+// UpsertDoNothing, UpsertUpdateColumns or UpsertUpdateAll. The rows conflict on the conflict
+// columns set by WithConflictColumnsOpts, which are the primary keys by default. MySQL can not
+// choose the conflict columns, the rows conflict on any of the primary keys and unique indexes
+// there, and the affected rows of an updated row are 2 on MySQL.
+type UpsertStrategy struct {
+	kind    upsertKind
+	columns []string
+}
+
+// UpsertDoNothing keeps the existing rows on conflict.
+func UpsertDoNothing() UpsertStrategy {
+	return UpsertStrategy{kind: upsertDoNothing}
+}
+
+// UpsertUpdateColumns updates the columns of the existing rows with the new values on conflict.
+func UpsertUpdateColumns(columns ...string) UpsertStrategy {
+	return UpsertStrategy{kind: upsertUpdateColumns, columns: columns}
+}
+
+// UpsertUpdateAll updates all the columns of the existing rows with the new values on conflict,
+// except the primary keys and the columns not updatable, such as the created_by of AuditModel.
+func UpsertUpdateAll() UpsertStrategy {
+	return UpsertStrategy{kind: upsertUpdateAll}
+}
+
+func (s UpsertStrategy) valid() bool {
+	switch s.kind {
+	case upsertDoNothing, upsertUpdateAll:
+		return true
+	case upsertUpdateColumns:
+		return len(s.columns) > 0
+	default:
+		return false
+	}
+}
+
+type BatchOption func(options *batchOptions)
+
+type batchOptions struct {
+	batchSize int
+	conflicts []string
+	upsert    UpsertStrategy
+}
+
+// WithBatchSizeOpts sets the number of the rows of a chunk, the default is 500.
+func WithBatchSizeOpts(size int) BatchOption {
+	return func(options *batchOptions) {
+		if size > 0 {
+			options.batchSize = size
+		}
+	}
+}
+
+// WithConflictColumnsOpts sets the conflict columns of the upserting, they must be the columns of
+// a unique index. It is ignored by MySQL.
+func WithConflictColumnsOpts(columns ...string) BatchOption {
+	return func(options *batchOptions) {
+		options.conflicts = columns
+	}
+}
+
+func newBatchOptions(opts []BatchOption) batchOptions {
+	options := batchOptions{batchSize: defaultBatchSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// StreamInsert inserts the rows produced by the iterator chunk by chunk, so the rows need not be
+// held in memory at once. The chunks are committed separately unless ctx carries a transaction,
+// the failed chunk is reported by BatchError.
+//
+// example:
+//
+//	affected, err := database.StreamInsert(ctx, db, database.ChannelRows(ctx, users), database.WithBatchSizeOpts(1000))
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	db (DatabaseV2): The database to insert into.
+//	rows (func(yield func(T) bool)): The iterator of the rows, an iter.Seq[T] is accepted.
+//	opts (...BatchOption): Optional batch size of the chunks.
+//
+// Returns:
+//
+//	affected (int64): The number of the rows inserted by the succeeded chunks.
+//	err (error): An error if the operation fails, otherwise nil.
+func StreamInsert[T any](ctx context.Context, db DatabaseV2, rows func(yield func(T) bool), opts ...BatchOption) (affected int64, err error) {
+	return streamExecute(ctx, db, rows, newBatchOptions(opts))
+}
+
+// StreamUpsert upserts the rows produced by the iterator chunk by chunk with the strategy, it is
+// the streaming variant of DatabaseV2.BatchUpsert, see StreamInsert.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	db (DatabaseV2): The database to upsert into.
+//	rows (func(yield func(T) bool)): The iterator of the rows, an iter.Seq[T] is accepted.
+//	strategy (UpsertStrategy): How the conflicting rows are handled.
+//	opts (...BatchOption): Optional batch size of the chunks and the conflict columns.
+//
+// Returns:
+//
+//	affected (int64): The number of the rows affected by the succeeded chunks.
+//	err (error): An error if the operation fails, otherwise nil.
+func StreamUpsert[T any](ctx context.Context, db DatabaseV2, rows func(yield func(T) bool), strategy UpsertStrategy, opts ...BatchOption) (affected int64, err error) {
+	if !strategy.valid() {
+		return 0, ErrInvalidUpsertStrategy
+	}
+
+	options := newBatchOptions(opts)
+	options.upsert = strategy
+	return streamExecute(ctx, db, rows, options)
+}
+
+// ChannelRows adapts the channel to the iterator of StreamInsert and StreamUpsert, the iteration
+// ends when the channel is closed or ctx is done. The producer should stop sending when ctx is
+// done or the streaming returns, otherwise it blocks forever.
+func ChannelRows[T any](ctx context.Context, rows <-chan T) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case row, ok := <-rows:
+				if !ok || !yield(row) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func streamExecute[T any](ctx context.Context, db DatabaseV2, rows func(yield func(T) bool), options batchOptions) (affected int64, err error) {
+	chunk, index, offset := make([]T, 0, options.batchSize), 0, 0
+	flush := func() bool {
+		rowsAffected, executeErr := executeChunk(ctx, db, chunk, &options)
+		affected += rowsAffected
+		if executeErr != nil {
+			err = NewBatchError(index, offset, len(chunk), executeErr)
+			return false
+		}
+
+		index, offset, chunk = index+1, offset+len(chunk), chunk[:0]
+		return true
+	}
+
+	rows(func(row T) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		chunk = append(chunk, row)
+		return len(chunk) < options.batchSize || flush()
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil && len(chunk) > 0 {
+		flush()
+	}
+
+	return affected, err
+}
+
+func batchExecute(ctx context.Context, db DatabaseV2, data any, options batchOptions) (affected int64, err error) {
+	if options.upsert.kind != upsertNone && !options.upsert.valid() {
+		return 0, ErrInvalidUpsertStrategy
+	}
+
+	rows := reflect.Indirect(reflect.ValueOf(data))
+	if rows.Kind() != reflect.Slice {
+		return 0, ErrInvalidBatchData
+	}
+
+	for index, offset := 0, 0; offset < rows.Len(); index, offset = index+1, offset+options.batchSize {
+		end := min(offset+options.batchSize, rows.Len())
+		rowsAffected, executeErr := executeChunk(ctx, db, rows.Slice(offset, end).Interface(), &options)
+		affected += rowsAffected
+		if executeErr != nil {
+			return affected, NewBatchError(index, offset, end-offset, executeErr)
+		}
+	}
+
+	return affected, nil
+}
+
+func executeChunk(ctx context.Context, db DatabaseV2, rows any, options *batchOptions) (int64, error) {
+	session := db.GetGormCore(ctx)
+	if options.upsert.kind != upsertNone {
+		onConflict, err := upsertClause(session, rows, options)
+		if err != nil {
+			return 0, err
+		}
+		session = session.Clauses(onConflict)
+	}
+
+	result := session.Create(rows)
+	return result.RowsAffected, result.Error
+}
+
+// upsertClause builds the conflict handling of the strategy, the dialectors render it as ON
+// CONFLICT on PostgreSQL and SQLite, and as ON DUPLICATE KEY UPDATE on MySQL.
+func upsertClause(session *gorm.DB, rows any, options *batchOptions) (onConflict clause.OnConflict, err error) {
+	statement := &gorm.Statement{DB: session}
+	if err = statement.Parse(rows); err != nil {
+		return onConflict, err
+	}
+
+	for _, column := range options.conflicts {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	if options.upsert.kind == upsertDoNothing {
+		onConflict.DoNothing = true
+		return onConflict, nil
+	}
+
+	// PostgreSQL 和 SQLite 的 DO UPDATE 必须指定冲突列
+	if len(onConflict.Columns) == 0 {
+		for _, field := range statement.Schema.PrimaryFields {
+			onConflict.Columns = append(onConflict.Columns, clause.Column{Name: field.DBName})
+		}
+	}
+
+	versionFields := taggedFields(statement.Schema, tagOptimisticLock, "")
+	isVersion := func(column string) bool {
+		return len(versionFields) > 0 && versionFields[0].DBName == column
+	}
+
+	var columns []string
+	switch options.upsert.kind {
+	case upsertUpdateColumns:
+		for _, column := range options.upsert.columns {
+			if !isVersion(column) {
+				columns = append(columns, column)
+			}
+		}
+	case upsertUpdateAll:
+		for _, field := range statement.Schema.Fields {
+			if field.DBName == "" || field.PrimaryKey || !field.Creatable || !field.Updatable || field.AutoCreateTime > 0 || isVersion(field.DBName) {
+				continue
+			}
+			columns = append(columns, field.DBName)
+		}
+	}
+	onConflict.DoUpdates = clause.AssignmentColumns(columns)
+
+	// 更新已有数据时递增乐观锁的版本号
+	if len(versionFields) > 0 && len(columns) > 0 {
+		column := clause.Column{Table: clause.CurrentTable, Name: versionFields[0].DBName}
+		onConflict.DoUpdates = append(onConflict.DoUpdates, clause.Assignment{
+			Column: clause.Column{Name: versionFields[0].DBName},
+			Value:  clause.Expr{SQL: "? + 1", Vars: []any{column}},
+		})
+	}
+	if len(onConflict.DoUpdates) == 0 {
+		onConflict.DoNothing = true
+	}
+
+	return onConflict, nil
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestBatchInsert(t *testing.T) {
+	ctx := context.Background()
+	db := newRepositoryTestDatabase(t, "batch_insert", &repositoryUser{})
+	users := NewRepository[repositoryUser](db)
+
+	t.Run("Chunked", func(t *testing.T) {
+		rows := []*repositoryUser{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}, {Name: "f"}, {Name: "g"}}
+		affected, err := db.BatchInsert(ctx, rows, WithBatchSizeOpts(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if affected != 7 || rows[6].ID == 0 {
+			t.Fatalf("expected 7 inserted with the primary keys written back, got %d, %+v", affected, rows[6])
+		}
+
+		if _, err = db.BatchInsert(ctx, &repositoryUser{Name: "single"}); !errors.Is(err, ErrInvalidBatchData) {
+			t.Fatalf("expected ErrInvalidBatchData, got %v", err)
+		}
+	})
+
+	t.Run("ChunkFailed", func(t *testing.T) {
+		// 第二个分块中的主键冲突
+		rows := []repositoryUser{{ID: 101}, {ID: 102}, {ID: 103}, {ID: 104}, {ID: 1}, {ID: 105}}
+		affected, err := db.BatchInsert(ctx, rows, WithBatchSizeOpts(3))
+		batchErr := &BatchError{}
+		if !errors.As(err, &batchErr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if batchErr.Chunk != 1 || batchErr.Offset != 3 || batchErr.Rows != 3 || affected != 3 {
+			t.Fatalf("unexpected batch error: %+v, affected: %d", batchErr, affected)
+		}
+		if exist, _ := users.Exists(ctx, NewCondition().Eq(RepositoryUserCols.ID, 103)); !exist {
+			t.Fatal("expected the first chunk committed")
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		rows := make(chan repositoryUser)
+		go func() {
+			defer close(rows)
+			for i := range 5 {
+				rows <- repositoryUser{ID: 201 + i, Name: "stream"}
+			}
+		}()
+
+		affected, err := StreamInsert(ctx, db, ChannelRows(ctx, rows), WithBatchSizeOpts(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, _ := users.Count(ctx, NewCondition().Eq(RepositoryUserCols.Name, "stream"))
+		if affected != 5 || count != 5 {
+			t.Fatalf("expected 5 inserted, got %d, %d", affected, count)
+		}
+
+		// 迭代器提前失败的分块之后不再读取数据
+		produced := 0
+		seq := func(yield func(*repositoryUser) bool) {
+			for _, id := range []int{301, 302, 201, 303, 304} {
+				produced++
+				if !yield(&repositoryUser{ID: id}) {
+					return
+				}
+			}
+		}
+		affected, err = StreamInsert(ctx, db, seq, WithBatchSizeOpts(2))
+		batchErr := &BatchError{}
+		if !errors.As(err, &batchErr) || batchErr.Chunk != 1 || batchErr.Offset != 2 || affected != 2 || produced != 4 {
+			t.Fatalf("unexpected stream result: %v, affected: %d, produced: %d", err, affected, produced)
+		}
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err = StreamInsert(cancelled, db, ChannelRows(cancelled, make(chan repositoryUser))); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestBatchUpsert(t *testing.T) {
+	ctx := context.Background()
+	db := newRepositoryTestDatabase(t, "batch_upsert", &conventionUser{})
+	users := NewRepository[conventionUser](db)
+	if _, err := db.BatchInsert(ctx, []conventionUser{{ID: 1, Name: "a", Age: 10}, {ID: 2, Name: "b", Age: 20}}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(t *testing.T, id int) conventionUser {
+		user, err := users.Get(ctx, NewCondition().Eq("id", id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	t.Run("DoNothing", func(t *testing.T) {
+		if _, err := db.BatchUpsert(ctx, []conventionUser{{ID: 1, Name: "x"}, {ID: 3, Name: "c"}}, UpsertDoNothing()); err != nil {
+			t.Fatal(err)
+		}
+		if user := get(t, 1); user.Name != "a" || user.Version != 1 {
+			t.Fatalf("expected the existing row kept, got %+v", user)
+		}
+		if user := get(t, 3); user.Name != "c" {
+			t.Fatalf("expected the new row inserted, got %+v", user)
+		}
+	})
+
+	t.Run("UpdateColumns", func(t *testing.T) {
+		if _, err := db.BatchUpsert(ctx, []conventionUser{{ID: 1, Name: "x", Age: 11}}, UpsertUpdateColumns("age")); err != nil {
+			t.Fatal(err)
+		}
+		if user := get(t, 1); user.Name != "a" || user.Age != 11 || user.Version != 2 {
+			t.Fatalf("expected only age updated and version bumped, got %+v", user)
+		}
+	})
+
+	t.Run("UpdateAll", func(t *testing.T) {
+		rows := []conventionUser{{ID: 2, Name: "y", Age: 0}, {ID: 4, Name: "d", Age: 40}}
+		if _, err := db.BatchUpsert(ctx, rows, UpsertUpdateAll(), WithBatchSizeOpts(1), WithConflictColumnsOpts("id")); err != nil {
+			t.Fatal(err)
+		}
+		if user := get(t, 2); user.Name != "y" || user.Age != 0 || user.Version != 2 {
+			t.Fatalf("expected all columns updated and version bumped, got %+v", user)
+		}
+		if user := get(t, 4); user.Name != "d" || user.Version != 1 {
+			t.Fatalf("expected the new row inserted, got %+v", user)
+		}
+	})
+
+	t.Run("InvalidStrategy", func(t *testing.T) {
+		if _, err := db.BatchUpsert(ctx, []conventionUser{{ID: 1}}, UpsertUpdateColumns()); !errors.Is(err, ErrInvalidUpsertStrategy) {
+			t.Fatalf("expected ErrInvalidUpsertStrategy, got %v", err)
+		}
+		if _, err := db.BatchUpsert(ctx, []conventionUser{{ID: 1}}, UpsertStrategy{}); !errors.Is(err, ErrInvalidUpsertStrategy) {
+			t.Fatalf("expected ErrInvalidUpsertStrategy, got %v", err)
+		}
+		rows := func(yield func(conventionUser) bool) { yield(conventionUser{ID: 1}) }
+		if _, err := StreamUpsert(ctx, db, rows, UpsertUpdateColumns()); !errors.Is(err, ErrInvalidUpsertStrategy) {
+			t.Fatalf("expected ErrInvalidUpsertStrategy, got %v", err)
+		}
+	})
+}
+
+func TestUpsertDialects(t *testing.T) {
+	dialectors := map[string]gorm.Dialector{
+		"mysql":    mysql.New(mysql.Config{DSN: "root:root@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		"postgres": postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=postgres dbname=test"}),
+	}
+
+	testCases := []struct {
+		name     string
+		dialect  string
+		strategy UpsertStrategy
+		expected string
+	}{
+		{name: "MysqlDoNothing", dialect: "mysql", strategy: UpsertDoNothing(), expected: "ON DUPLICATE KEY UPDATE `id`=`id`"},
+		{name: "MysqlUpdateColumns", dialect: "mysql", strategy: UpsertUpdateColumns("age"), expected: "ON DUPLICATE KEY UPDATE `age`=VALUES(`age`),`version`=`convention_users`.`version` + 1"},
+		{name: "PostgresDoNothing", dialect: "postgres", strategy: UpsertDoNothing(), expected: `ON CONFLICT DO NOTHING`},
+		{name: "PostgresUpdateColumns", dialect: "postgres", strategy: UpsertUpdateColumns("age"), expected: `ON CONFLICT ("id") DO UPDATE SET "age"="excluded"."age","version"="convention_users"."version" + 1`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db, err := gorm.Open(dialectors[testCase.dialect], &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows := []conventionUser{{ID: 1, Name: "a"}}
+			onConflict, err := upsertClause(db, rows, &batchOptions{upsert: testCase.strategy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Clauses(onConflict).Create(rows)
+			})
+			if !strings.Contains(sql, testCase.expected) {
+				t.Fatalf("expected %s in %s", testCase.expected, sql)
+			}
+		})
+	}
+}
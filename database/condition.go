@@ -0,0 +1,183 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Condition is a fluent builder of the query conditions, the columns can be passed as plain
+// strings or as the fields of the column structs generated by the database-column command.
+// All the conditions are joined with AND, use Or to join a group of conditions with OR.
+//
+// example:
+//
+//	cond := database.NewCondition().Eq(UserCols.Name, "alice").Gte(UserCols.Age, 18).OrderBy(UserCols.ID, true).Limit(10)
+//	users, err := repository.List(ctx, cond)
+type Condition struct {
+	expressions []clause.Expression
+	orders      []clause.OrderByColumn
+	fields      []string
+	offset      int
+	limit       int
+}
+
+func NewCondition() *Condition {
+	return &Condition{}
+}
+
+func (c *Condition) add(expression clause.Expression) *Condition {
+	c.expressions = append(c.expressions, expression)
+	return c
+}
+
+// Eq adds the condition column = value, a nil value is built as column IS NULL.
+func (c *Condition) Eq(column string, value any) *Condition {
+	return c.add(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Ne adds the condition column <> value, a nil value is built as column IS NOT NULL.
+func (c *Condition) Ne(column string, value any) *Condition {
+	return c.add(clause.Neq{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Gt adds the condition column > value.
+func (c *Condition) Gt(column string, value any) *Condition {
+	return c.add(clause.Gt{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Gte adds the condition column >= value.
+func (c *Condition) Gte(column string, value any) *Condition {
+	return c.add(clause.Gte{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Lt adds the condition column < value.
+func (c *Condition) Lt(column string, value any) *Condition {
+	return c.add(clause.Lt{Column: clause.Column{Name: column}, Value: value})
+}
+
+// Lte adds the condition column <= value.
+func (c *Condition) Lte(column string, value any) *Condition {
+	return c.add(clause.Lte{Column: clause.Column{Name: column}, Value: value})
+}
+
+// In adds the condition column IN (values...), no values matches no rows.
+func (c *Condition) In(column string, values ...any) *Condition {
+	return c.add(clause.IN{Column: clause.Column{Name: column}, Values: values})
+}
+
+// NotIn adds the condition column NOT IN (values...).
+func (c *Condition) NotIn(column string, values ...any) *Condition {
+	return c.add(clause.Not(clause.IN{Column: clause.Column{Name: column}, Values: values}))
+}
+
+// Like adds the condition column LIKE pattern, the pattern is not escaped.
+func (c *Condition) Like(column string, pattern string) *Condition {
+	return c.add(clause.Like{Column: clause.Column{Name: column}, Value: pattern})
+}
+
+// Between adds the condition column BETWEEN lower AND upper.
+func (c *Condition) Between(column string, lower, upper any) *Condition {
+	return c.add(clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{clause.Column{Name: column}, lower, upper}})
+}
+
+// IsNull adds the condition column IS NULL.
+func (c *Condition) IsNull(column string) *Condition {
+	return c.Eq(column, nil)
+}
+
+// NotNull adds the condition column IS NOT NULL.
+func (c *Condition) NotNull(column string) *Condition {
+	return c.Ne(column, nil)
+}
+
+// Raw adds a raw sql condition with ? placeholders, it is the escape hatch of the conditions
+// which the builder can not express.
+func (c *Condition) Raw(sql string, args ...any) *Condition {
+	return c.add(clause.Expr{SQL: sql, Vars: args})
+}
+
+// Or joins the conditions added before and the conditions of each group with OR, the groups
+// are joined with AND inside.
+//
+// example:
+//
+//	// (name = 'alice' AND age >= 18) OR (name = 'bob')
+//	cond := database.NewCondition().Eq("name", "alice").Gte("age", 18).Or(database.NewCondition().Eq("name", "bob"))
+func (c *Condition) Or(groups ...*Condition) *Condition {
+	alternatives := make([]clause.Expression, 0, len(groups)+1)
+	if len(c.expressions) > 0 {
+		alternatives = append(alternatives, clause.And(c.expressions...))
+	}
+	for _, group := range groups {
+		if group != nil && len(group.expressions) > 0 {
+			alternatives = append(alternatives, clause.And(group.expressions...))
+		}
+	}
+	if len(alternatives) == 0 {
+		return c
+	}
+
+	c.expressions = []clause.Expression{clause.Or(alternatives...)}
+	return c
+}
+
+// OrderBy appends an order column, it only works on the queries returning rows.
+func (c *Condition) OrderBy(column string, desc bool) *Condition {
+	c.orders = append(c.orders, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	return c
+}
+
+// Select limits the selected fields, it only works on the queries returning rows.
+func (c *Condition) Select(fields ...string) *Condition {
+	c.fields = append(c.fields, fields...)
+	return c
+}
+
+// Offset skips the first offset rows, it only works on the queries returning rows.
+func (c *Condition) Offset(offset int) *Condition {
+	c.offset = offset
+	return c
+}
+
+// Limit limits the number of the rows, it only works on the queries returning rows.
+func (c *Condition) Limit(limit int) *Condition {
+	c.limit = limit
+	return c
+}
+
+// Empty reports whether the condition filters nothing.
+func (c *Condition) Empty() bool {
+	return c == nil || len(c.expressions) == 0
+}
+
+// apply adds the filters to the query.
+func (c *Condition) apply(db *gorm.DB) *gorm.DB {
+	if c.Empty() {
+		return db
+	}
+
+	return db.Where(clause.And(c.expressions...))
+}
+
+// applyQuery adds the filters, orders, selected fields, offset and limit to the query.
+func (c *Condition) applyQuery(db *gorm.DB) *gorm.DB {
+	db = c.apply(db)
+	if c == nil {
+		return db
+	}
+
+	if len(c.fields) > 0 {
+		db = db.Select(c.fields)
+	}
+	for _, order := range c.orders {
+		db = db.Order(order)
+	}
+	if c.offset > 0 {
+		db = db.Offset(c.offset)
+	}
+	if c.limit > 0 {
+		db = db.Limit(c.limit)
+	}
+
+	return db
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/alioth-center/infrastructure/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	conventionsName = "database:conventions"
+
+	// tagOptimisticLock marks the version field of the optimistic locking.
+	tagOptimisticLock = "OPTIMISTIC_LOCK"
+
+	// tagAudit marks the audit fields, the value is auditCreated or auditUpdated.
+	tagAudit     = "AUDIT"
+	auditCreated = "created"
+	auditUpdated = "updated"
+
+	settingVersion = "database:version"
+)
+
+// SoftDeleteModel makes the model soft deleted, the deleting sets deleted_at instead of removing
+// the row, and the queries skip the deleted rows. Use WithDeleted to read the deleted rows and
+// Repository.HardDelete to remove the rows.
+type SoftDeleteModel struct {
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deleted_at,omitempty"`
+}
+
+// VersionModel enables the optimistic locking of the model, the updating bumps the version, and
+// fails with ErrStaleVersion if the version of the updates is not the version in the database.
+// The updates with zero version bump the version without checking it.
+type VersionModel struct {
+	Version int64 `gorm:"column:version;not null;default:1;optimistic_lock" json:"version"`
+}
+
+// AuditModel records the operators of the model, created_by and updated_by are filled with the
+// user ID carried by the context, see trace.WithUserID.
+type AuditModel struct {
+	CreatedBy string `gorm:"column:created_by;type:varchar(64);<-:create;audit:created" json:"created_by,omitempty"`
+	UpdatedBy string `gorm:"column:updated_by;type:varchar(64);audit:updated" json:"updated_by,omitempty"`
+}
+
+// deletedKey is the context key of the flag including the soft deleted rows.
+type deletedKey struct{}
+
+// WithDeleted returns a context including the soft deleted rows in the queries.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey{}, true)
+}
+
+// unscoped removes the soft delete scope of db if ctx is returned by WithDeleted.
+func unscoped(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx == nil {
+		return db
+	}
+
+	if deleted, _ := ctx.Value(deletedKey{}).(bool); deleted {
+		return db.Unscoped()
+	}
+
+	return db
+}
+
+// Conventions is the gorm plugin implementing the optimistic locking of VersionModel and the audit
+// fields of AuditModel, the drivers register it on opening. The custom fields can join the
+// conventions by the tags optimistic_lock, audit:created and audit:updated.
+type Conventions struct{}
+
+func (c *Conventions) Name() string {
+	return conventionsName
+}
+
+func (c *Conventions) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register(conventionsName+":audit_create", auditCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register(conventionsName+":audit_update", auditUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").After(conventionsName+":audit_update").Register(conventionsName+":version", bumpVersion); err != nil {
+		return err
+	}
+
+	return db.Callback().Update().After("gorm:update").Register(conventionsName+":check_version", checkVersion)
+}
+
+func taggedFields(s *schema.Schema, tag, value string) (fields []*schema.Field) {
+	for _, field := range s.Fields {
+		if setting, exist := field.TagSettings[tag]; exist && (value == "" || setting == value) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func auditCreate(db *gorm.DB) {
+	userID := trace.GetUserID(db.Statement.Context)
+	if db.Error != nil || db.Statement.Schema == nil || userID == "" {
+		return
+	}
+
+	fields := append(taggedFields(db.Statement.Schema, tagAudit, auditCreated), taggedFields(db.Statement.Schema, tagAudit, auditUpdated)...)
+	for _, field := range fields {
+		switch dest := db.Statement.Dest.(type) {
+		case map[string]any:
+			if _, exist := dest[field.DBName]; !exist {
+				dest[field.DBName] = userID
+			}
+		case []map[string]any:
+			for _, row := range dest {
+				if _, exist := row[field.DBName]; !exist {
+					row[field.DBName] = userID
+				}
+			}
+		default:
+			// 只填充未设置的审计字段
+			setIfZero := func(row reflect.Value) {
+				if _, zero := field.ValueOf(db.Statement.Context, row); zero {
+					db.AddError(field.Set(db.Statement.Context, row, userID))
+				}
+			}
+
+			switch db.Statement.ReflectValue.Kind() {
+			case reflect.Slice, reflect.Array:
+				for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+					setIfZero(reflect.Indirect(db.Statement.ReflectValue.Index(i)))
+				}
+			case reflect.Struct:
+				if db.Statement.ReflectValue.CanAddr() {
+					setIfZero(db.Statement.ReflectValue)
+				}
+			}
+		}
+	}
+}
+
+func auditUpdate(db *gorm.DB) {
+	userID := trace.GetUserID(db.Statement.Context)
+	if db.Error != nil || db.Statement.Schema == nil || userID == "" || !updatable(db.Statement) {
+		return
+	}
+
+	for _, field := range taggedFields(db.Statement.Schema, tagAudit, auditUpdated) {
+		db.Statement.SetColumn(field.DBName, userID, true)
+	}
+}
+
+// updatable reports whether the updates are a map or the model, the other updates are left to gorm.
+func updatable(statement *gorm.Statement) bool {
+	if _, isMap := statement.Dest.(map[string]any); isMap {
+		return true
+	}
+
+	dest := reflect.ValueOf(statement.Dest)
+	return dest.Kind() == reflect.Ptr && dest.Elem().Kind() == reflect.Struct && dest.Elem().Type() == statement.Schema.ModelType
+}
+
+// bumpVersion adds the version checking to the conditions and the version bumping to the updates,
+// the assignments are built here since gorm skips the zero version of the struct updates.
+func bumpVersion(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.SQL.Len() > 0 || !updatable(db.Statement) {
+		return
+	}
+	versionFields := taggedFields(db.Statement.Schema, tagOptimisticLock, "")
+	if len(versionFields) == 0 {
+		return
+	}
+	if _, exist := db.Statement.Clauses["SET"]; exist {
+		return
+	}
+
+	field := versionFields[0]
+	current := int64(0)
+	if dest, isMap := db.Statement.Dest.(map[string]any); isMap {
+		for _, key := range []string{field.DBName, field.Name} {
+			if value, exist := dest[key]; exist {
+				current = versionOf(value)
+			}
+		}
+	} else {
+		value, _ := field.ValueOf(db.Statement.Context, reflect.ValueOf(db.Statement.Dest).Elem())
+		current = versionOf(value)
+	}
+
+	set := callbacks.ConvertToAssignments(db.Statement)
+	if len(set) == 0 || db.Error != nil {
+		return
+	}
+
+	bumped := make(clause.Set, 0, len(set)+1)
+	for _, assignment := range set {
+		if assignment.Column.Name != field.DBName {
+			bumped = append(bumped, assignment)
+		}
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	if current > 0 {
+		bumped = append(bumped, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: current + 1})
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: column, Value: current}}})
+	} else {
+		bumped = append(bumped, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: clause.Expr{SQL: "? + 1", Vars: []any{column}}})
+	}
+
+	db.Statement.AddClause(bumped)
+	db.InstanceSet(settingVersion, current)
+}
+
+func versionOf(value any) int64 {
+	version := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case version.CanInt():
+		return version.Int()
+	case version.CanUint():
+		return int64(version.Uint())
+	default:
+		return 0
+	}
+}
+
+// checkVersion reports ErrStaleVersion if no row is updated with the version checking, otherwise
+// writes the bumped version back to the updates.
+func checkVersion(db *gorm.DB) {
+	value, exist := db.InstanceGet(settingVersion)
+	if !exist {
+		return
+	}
+
+	// 移除本次更新添加的赋值，避免影响复用的会话
+	delete(db.Statement.Clauses, "SET")
+	current := value.(int64)
+	if db.Error != nil || current == 0 {
+		return
+	}
+	if db.RowsAffected == 0 {
+		db.AddError(ErrStaleVersion)
+		return
+	}
+
+	field := taggedFields(db.Statement.Schema, tagOptimisticLock, "")[0]
+	if dest, isMap := db.Statement.Dest.(map[string]any); isMap {
+		dest[field.DBName] = current + 1
+		return
+	}
+	db.AddError(field.Set(db.Statement.Context, reflect.ValueOf(db.Statement.Dest).Elem(), current+1))
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alioth-center/infrastructure/trace"
+)
+
+type conventionUser struct {
+	ID   int    `gorm:"column:id;primaryKey;autoIncrement"`
+	Name string `gorm:"column:name"`
+	Age  int    `gorm:"column:age"`
+	SoftDeleteModel
+	VersionModel
+	AuditModel
+}
+
+func (conventionUser) TableName() string {
+	return "convention_users"
+}
+
+func TestConventions(t *testing.T) {
+	ctx := trace.WithUserID(context.Background(), "creator")
+	db := newRepositoryTestDatabase(t, "conventions", &conventionUser{})
+	users := NewRepository[conventionUser](db)
+
+	t.Run("Audit", func(t *testing.T) {
+		alice := &conventionUser{Name: "alice", Age: 18}
+		if err := users.Create(ctx, alice); err != nil {
+			t.Fatal(err)
+		}
+		if alice.CreatedBy != "creator" || alice.UpdatedBy != "creator" || alice.Version != 1 {
+			t.Fatalf("unexpected created user: %+v", alice)
+		}
+
+		// 更新只修改 updated_by
+		alice.Age = 19
+		if _, err := users.Update(trace.WithUserID(ctx, "updater"), NewCondition().Eq("id", alice.ID), alice); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := users.Get(ctx, NewCondition().Eq("id", alice.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.CreatedBy != "creator" || stored.UpdatedBy != "updater" || stored.Age != 19 {
+			t.Fatalf("unexpected updated user: %+v", stored)
+		}
+	})
+
+	t.Run("OptimisticLock", func(t *testing.T) {
+		bob := &conventionUser{Name: "bob", Age: 20}
+		if err := users.Create(ctx, bob); err != nil {
+			t.Fatal(err)
+		}
+
+		stale := *bob
+		bob.Age = 21
+		if _, err := users.Update(ctx, NewCondition().Eq("id", bob.ID), bob); err != nil {
+			t.Fatal(err)
+		}
+		if bob.Version != 2 {
+			t.Fatalf("expected version 2 written back, got %d", bob.Version)
+		}
+
+		stale.Age = 22
+		if _, err := users.Update(ctx, NewCondition().Eq("id", stale.ID), &stale); !errors.Is(err, ErrStaleVersion) {
+			t.Fatalf("expected ErrStaleVersion, got %v", err)
+		}
+		if err := db.UpdateDataBySingleCondition(ctx, &conventionUser{Age: 23, VersionModel: VersionModel{Version: 1}}, "id", bob.ID); !errors.Is(err, ErrStaleVersion) {
+			t.Fatalf("expected ErrStaleVersion, got %v", err)
+		}
+
+		updates := &conventionUser{Age: 24, VersionModel: VersionModel{Version: 2}}
+		if err := db.UpdateDataBySingleCondition(ctx, updates, "id", bob.ID); err != nil {
+			t.Fatal(err)
+		}
+		if updates.Version != 3 {
+			t.Fatalf("expected version 3 written back, got %d", updates.Version)
+		}
+
+		// 零版本号不做检查，只递增版本号
+		if _, err := users.UpdateValues(ctx, NewCondition().Eq("id", bob.ID), map[string]any{"age": 25}); err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]any{"age": 26, "version": 4}
+		if _, err := users.UpdateValues(ctx, NewCondition().Eq("id", bob.ID), values); err != nil {
+			t.Fatal(err)
+		}
+		if values["version"] != int64(5) {
+			t.Fatalf("expected version 5 written back, got %v", values["version"])
+		}
+
+		stored, err := users.Get(ctx, NewCondition().Eq("id", bob.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Age != 26 || stored.Version != 5 {
+			t.Fatalf("unexpected stored user: %+v", stored)
+		}
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		carol := &conventionUser{Name: "carol", Age: 30}
+		if err := users.Create(ctx, carol); err != nil {
+			t.Fatal(err)
+		}
+		condition := NewCondition().Eq("id", carol.ID)
+		if affected, err := users.Delete(ctx, condition); err != nil || affected != 1 {
+			t.Fatalf("expected 1 deleted, got %d, %v", affected, err)
+		}
+
+		if _, err := users.Get(ctx, condition); !errors.Is(err, ErrRecordNotFound) {
+			t.Fatalf("expected ErrRecordNotFound, got %v", err)
+		}
+		var receiver []conventionUser
+		if err := db.GetDataBySingleCondition(ctx, &receiver, "id", carol.ID); err != nil || len(receiver) != 0 {
+			t.Fatalf("expected no user, got %+v, %v", receiver, err)
+		}
+
+		// WithDeleted 可以读取软删除的数据
+		deleted, err := users.Get(WithDeleted(ctx), condition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !deleted.DeletedAt.Valid {
+			t.Fatalf("expected deleted_at set, got %+v", deleted)
+		}
+		if err = db.GetDataBySingleCondition(WithDeleted(ctx), &receiver, "id", carol.ID); err != nil || len(receiver) != 1 {
+			t.Fatalf("expected the deleted user, got %+v, %v", receiver, err)
+		}
+
+		if affected, err := users.HardDelete(ctx, condition); err != nil || affected != 1 {
+			t.Fatalf("expected 1 hard deleted, got %d, %v", affected, err)
+		}
+		if exist, _ := users.Exists(WithDeleted(ctx), condition); exist {
+			t.Fatal("expected the user removed")
+		}
+		if _, err = users.HardDelete(ctx, nil); !errors.Is(err, ErrInvalidCondition) {
+			t.Fatalf("expected ErrInvalidCondition, got %v", err)
+		}
+	})
+}
@@ -16,6 +16,18 @@ type Options struct {
 	MaxLife    time.Duration
 	Timeout    time.Duration
 	Logger     logger.Logger
+
+	// Replicas are the data sources of the read replicas, ReplicaPolicy and HealthCheckInterval
+	// configure the replica set of them, see NewReplicaSet.
+	Replicas            []string
+	ReplicaPolicy       string
+	HealthCheckInterval time.Duration
+
+	// SlowThreshold, SensitiveColumns and SampleRate configure the DBLogger of the database, see
+	// NewDBLoggerWithOptions, the zero values mean the defaults.
+	SlowThreshold    time.Duration
+	SensitiveColumns []string
+	SampleRate       float64
 }
 
 // Database is the interface that wraps the basic database operations.
@@ -70,8 +82,33 @@ type DatabaseV2 interface {
 	//	*gorm.DB: The GORM database instance with the provided context.
 	GetGormCore(ctx context.Context) *gorm.DB
 
+	// GetGormReader retrieves a *gorm.DB instance for the reads with the provided context, it is a
+	// healthy read replica if the replicas are configured, otherwise the core instance. The core
+	// instance is returned in a transaction or with the context returned by UsePrimary.
+	//
+	// Parameters:
+	//	ctx (context.Context): The context for the database operation.
+	//
+	// Returns:
+	//	*gorm.DB: The GORM database instance for the reads with the provided context.
+	GetGormReader(ctx context.Context) *gorm.DB
+
+	// WithTransaction runs fn in a transaction, the transaction is carried by the context passed to fn,
+	// so all the methods and the GetGormCore called with this context run in the transaction. The
+	// transaction is committed if fn returns nil, otherwise it is rolled back. Calling WithTransaction
+	// with a context carrying a transaction runs fn in a savepoint, the options are ignored then.
+	//
+	// Parameters:
+	//	ctx (context.Context): The context for the database operation.
+	//	fn (func(ctx context.Context) error): The function to run in the transaction.
+	//	opts (...TransactionOption): Optional isolation level and read-only mode of the transaction.
+	//
+	// Returns:
+	//	error: The error returned by fn, or an error if the transaction fails, otherwise nil.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...TransactionOption) error
+
 	// GetDataBySingleCondition retrieves data from the database based on a single column condition.
-	// The result is stored in the receiver.
+	// The result is stored in the receiver. The query goes to the read replicas if they are configured.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
@@ -85,7 +122,7 @@ type DatabaseV2 interface {
 	GetDataBySingleCondition(ctx context.Context, receiver any, column string, condition any, needFields ...string) error
 
 	// GetDataByCustomCondition retrieves data from the database based on a custom condition.
-	// The result is stored in the receiver.
+	// The result is stored in the receiver. The query goes to the read replicas if they are configured.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
@@ -98,7 +135,7 @@ type DatabaseV2 interface {
 	GetDataByCustomCondition(ctx context.Context, receiver, condition any, needFields ...string) error
 
 	// ListDataWithPage retrieves a paginated list of data from the database based on the provided filter and ordering.
-	// The result is stored in the receiver.
+	// The result is stored in the receiver. The query goes to the read replicas if they are configured.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
@@ -137,7 +174,37 @@ type DatabaseV2 interface {
 	//	error: An error if the operation fails, otherwise nil.
 	CreateDataOnDuplicateKeyUpdate(ctx context.Context, data any, indexKeys, updateFields []string) error
 
+	// BatchInsert inserts the rows in chunks of the batch size instead of one giant statement. The
+	// chunks are committed separately unless ctx carries a transaction, the failed chunk is reported
+	// by BatchError. See StreamInsert for the rows produced by an iterator or a channel.
+	//
+	// Parameters:
+	//	ctx (context.Context): The context for the database operation.
+	//	data (any): The slice of the rows to be inserted.
+	//	opts (...BatchOption): Optional batch size of the chunks.
+	//
+	// Returns:
+	//	affected (int64): The number of the rows inserted by the succeeded chunks.
+	//	err (error): An error if the operation fails, otherwise nil.
+	BatchInsert(ctx context.Context, data any, opts ...BatchOption) (affected int64, err error)
+
+	// BatchUpsert inserts the rows in chunks like BatchInsert, the rows conflicting with the existing
+	// rows are handled by the strategy, which is UpsertDoNothing, UpsertUpdateColumns or UpsertUpdateAll.
+	// It is rendered as ON CONFLICT on PostgreSQL and SQLite, and as ON DUPLICATE KEY UPDATE on MySQL.
+	//
+	// Parameters:
+	//	ctx (context.Context): The context for the database operation.
+	//	data (any): The slice of the rows to be upserted.
+	//	strategy (UpsertStrategy): How the conflicting rows are handled.
+	//	opts (...BatchOption): Optional batch size of the chunks and the conflict columns.
+	//
+	// Returns:
+	//	affected (int64): The number of the rows affected by the succeeded chunks.
+	//	err (error): An error if the operation fails, otherwise nil.
+	BatchUpsert(ctx context.Context, data any, strategy UpsertStrategy, opts ...BatchOption) (affected int64, err error)
+
 	// UpdateDataBySingleCondition updates data in the database based on a single column condition.
+	// For the models with VersionModel, ErrStaleVersion is returned if the version of the updates is stale.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
@@ -150,6 +217,7 @@ type DatabaseV2 interface {
 	UpdateDataBySingleCondition(ctx context.Context, updates any, column string, condition any) error
 
 	// UpdateDataByCustomCondition updates data in the database based on a custom condition.
+	// For the models with VersionModel, ErrStaleVersion is returned if the version of the updates is stale.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
@@ -161,29 +229,35 @@ type DatabaseV2 interface {
 	UpdateDataByCustomCondition(ctx context.Context, updates, condition any) error
 
 	// ExecuteRawSqlTemplateQuery executes a raw SQL template query with the provided context.
+	// The ${name} placeholders are bound as the parameters of the dialect, see ParseSqlTemplate.
+	// A template which is not a SqlTemplateParams renders the SQL by itself, which is deprecated.
+	// The query goes to the read replicas if they are configured.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
 	//	receiver (any): The destination where the query result will be stored.
 	//  sql (string): The raw SQL template to execute.
-	//	template (RawSqlTemplate): The raw SQL template value to execute.
+	//	template (RawSqlTemplate): The values of the placeholders, such as NamedParams, nil binds no values.
 	//
 	// Returns:
-	//	error: An error if the operation fails, otherwise nil.
+	//	error: A SqlTemplateError if the template can not be bound, or an error if the operation fails, otherwise nil.
 	ExecuteRawSqlTemplateQuery(ctx context.Context, receiver any, sql string, template RawSqlTemplate) error
 
 	// ExecuteRawSqlTemplate executes a raw SQL template with the provided context.
+	// The ${name} placeholders are bound as the parameters of the dialect, see ParseSqlTemplate.
+	// A template which is not a SqlTemplateParams renders the SQL by itself, which is deprecated.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
 	//  sql (string): The raw SQL template to execute.
-	//	template (RawSqlTemplate): The raw SQL template value to execute.
+	//	template (RawSqlTemplate): The values of the placeholders, such as NamedParams, nil binds no values.
 	//
 	// Returns:
-	//	error: An error if the operation fails, otherwise nil.
+	//	error: A SqlTemplateError if the template can not be bound, or an error if the operation fails, otherwise nil.
 	ExecuteRawSqlTemplate(ctx context.Context, sql string, template RawSqlTemplate) error
 
 	// ExecuteRawSqlQuery executes a raw SQL query with the provided context.
+	// The query goes to the read replicas if they are configured.
 	//
 	// Parameters:
 	//	ctx (context.Context): The context for the database operation.
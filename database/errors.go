@@ -1,6 +1,10 @@
 package database
 
-import "github.com/alioth-center/infrastructure/utils/values"
+import (
+	"strconv"
+
+	"github.com/alioth-center/infrastructure/utils/values"
+)
 
 type TemplateFormatError struct {
 	Template string `json:"template"`
@@ -18,6 +22,23 @@ func NewTemplateFormatError(template []rune) error {
 	return &TemplateFormatError{Template: string(template[:100])}
 }
 
+type SqlTemplateError struct {
+	Template string `json:"template"`
+	Reason   string `json:"reason"`
+}
+
+func (err *SqlTemplateError) Error() string {
+	return values.BuildStrings("sql template error: ", err.Reason, ", template: ", err.Template)
+}
+
+func NewSqlTemplateError(template, reason string) error {
+	if runes := []rune(template); len(runes) > 100 {
+		return &SqlTemplateError{Template: values.BuildStrings(string(runes[:100]), "..."), Reason: reason}
+	}
+
+	return &SqlTemplateError{Template: template, Reason: reason}
+}
+
 type ExecuteSqlError struct {
 	Sql           string `json:"sql"`
 	ErrorOccurred error  `json:"error"`
@@ -30,3 +51,22 @@ func (err *ExecuteSqlError) Error() string {
 func NewExecuteSqlError(sql string, errorOccurred error) error {
 	return &ExecuteSqlError{Sql: sql, ErrorOccurred: errorOccurred}
 }
+
+type BatchError struct {
+	Chunk         int   `json:"chunk"`
+	Offset        int   `json:"offset"`
+	Rows          int   `json:"rows"`
+	ErrorOccurred error `json:"error"`
+}
+
+func (err *BatchError) Error() string {
+	return values.BuildStrings("execute chunk ", strconv.Itoa(err.Chunk), " of rows [", strconv.Itoa(err.Offset), ", ", strconv.Itoa(err.Offset+err.Rows), ") error: ", err.ErrorOccurred.Error())
+}
+
+func (err *BatchError) Unwrap() error {
+	return err.ErrorOccurred
+}
+
+func NewBatchError(chunk, offset, rows int, errorOccurred error) error {
+	return &BatchError{Chunk: chunk, Offset: offset, Rows: rows, ErrorOccurred: errorOccurred}
+}
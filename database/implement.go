@@ -334,11 +334,28 @@ func (s *BaseDatabaseImplement) SetLogger(logger logger.Logger) {
 }
 
 type BaseDatabaseImplementV2 struct {
-	Db *gorm.DB
+	Db       *gorm.DB
+	Replicas *ReplicaSet
 }
 
 func (v2 *BaseDatabaseImplementV2) GetGormCore(ctx context.Context) *gorm.DB {
-	return v2.Db.WithContext(ctx)
+	return session(ctx, v2.Db)
+}
+
+func (v2 *BaseDatabaseImplementV2) GetGormReader(ctx context.Context) *gorm.DB {
+	if v2.Replicas == nil || primaryRequired(ctx) || inTransaction(ctx, v2.Db) {
+		return v2.GetGormCore(ctx)
+	}
+
+	if replica, ok := v2.Replicas.Pick(); ok {
+		return unscoped(ctx, replica.WithContext(ctx))
+	}
+
+	return v2.GetGormCore(ctx)
+}
+
+func (v2 *BaseDatabaseImplementV2) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...TransactionOption) error {
+	return withTransaction(ctx, v2.Db, fn, opts)
 }
 
 func (v2 *BaseDatabaseImplementV2) GetDataBySingleCondition(ctx context.Context, receiver any, column string, condition any, needFields ...string) error {
@@ -350,7 +367,7 @@ func (v2 *BaseDatabaseImplementV2) GetDataBySingleCondition(ctx context.Context,
 		needFields = append(needFields, "*")
 	}
 
-	return v2.Db.WithContext(ctx).Model(receiver).Where(column, condition).Select(needFields).Scan(receiver).Error
+	return v2.GetGormReader(ctx).Model(receiver).Where(column, condition).Select(needFields).Scan(receiver).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) GetDataByCustomCondition(ctx context.Context, receiver, condition any, needFields ...string) error {
@@ -362,7 +379,7 @@ func (v2 *BaseDatabaseImplementV2) GetDataByCustomCondition(ctx context.Context,
 		needFields = append(needFields, "*")
 	}
 
-	return v2.Db.WithContext(ctx).Model(receiver).Where(condition).Select(needFields).Scan(receiver).Error
+	return v2.GetGormReader(ctx).Model(receiver).Where(condition).Select(needFields).Scan(receiver).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) ListDataWithPage(ctx context.Context, receiver any, filter any, order string, desc bool, offset, limit int, needFields ...string) error {
@@ -374,7 +391,7 @@ func (v2 *BaseDatabaseImplementV2) ListDataWithPage(ctx context.Context, receive
 		needFields = append(needFields, "*")
 	}
 
-	return v2.Db.WithContext(ctx).Model(receiver).Where(filter).Order(clause.OrderByColumn{
+	return v2.GetGormReader(ctx).Model(receiver).Where(filter).Order(clause.OrderByColumn{
 		Column: clause.Column{Name: order}, Desc: desc,
 	}).Limit(limit).Offset(offset * limit).Select(needFields).Scan(receiver).Error
 }
@@ -384,7 +401,7 @@ func (v2 *BaseDatabaseImplementV2) CreateSingleDataIfNotExist(ctx context.Contex
 		return false, ErrInvalidSingleData
 	}
 
-	session := v2.Db.WithContext(ctx).Model(data).Clauses(clause.OnConflict{DoNothing: true}).Create(data)
+	session := v2.GetGormCore(ctx).Model(data).Clauses(clause.OnConflict{DoNothing: true}).Create(data)
 	if session.Error != nil {
 		return false, session.Error
 	}
@@ -402,18 +419,32 @@ func (v2 *BaseDatabaseImplementV2) CreateDataOnDuplicateKeyUpdate(ctx context.Co
 		duplicatedColumns[i] = clause.Column{Name: key}
 	}
 
-	return v2.Db.WithContext(ctx).Model(data).Clauses(clause.OnConflict{
+	return v2.GetGormCore(ctx).Model(data).Clauses(clause.OnConflict{
 		Columns:   duplicatedColumns,
 		DoUpdates: clause.AssignmentColumns(updateFields),
 	}).Create(data).Error
 }
 
+func (v2 *BaseDatabaseImplementV2) BatchInsert(ctx context.Context, data any, opts ...BatchOption) (affected int64, err error) {
+	return batchExecute(ctx, v2, data, newBatchOptions(opts))
+}
+
+func (v2 *BaseDatabaseImplementV2) BatchUpsert(ctx context.Context, data any, strategy UpsertStrategy, opts ...BatchOption) (affected int64, err error) {
+	if !strategy.valid() {
+		return 0, ErrInvalidUpsertStrategy
+	}
+
+	options := newBatchOptions(opts)
+	options.upsert = strategy
+	return batchExecute(ctx, v2, data, options)
+}
+
 func (v2 *BaseDatabaseImplementV2) UpdateDataBySingleCondition(ctx context.Context, updates any, column string, condition any) error {
 	if column == "" || condition == nil || EmptySlice(condition) {
 		return ErrInvalidCondition
 	}
 
-	return v2.Db.WithContext(ctx).Model(updates).Where(column, condition).Updates(updates).Error
+	return v2.GetGormCore(ctx).Model(updates).Where(column, condition).Updates(updates).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) UpdateDataByCustomCondition(ctx context.Context, updates, condition any) error {
@@ -421,26 +452,55 @@ func (v2 *BaseDatabaseImplementV2) UpdateDataByCustomCondition(ctx context.Conte
 		return ErrInvalidCondition
 	}
 
-	return v2.Db.WithContext(ctx).Model(updates).Where(condition).Updates(updates).Error
+	return v2.GetGormCore(ctx).Model(updates).Where(condition).Updates(updates).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) ExecuteRawSqlTemplateQuery(ctx context.Context, receiver any, sql string, template RawSqlTemplate) error {
-	return v2.Db.WithContext(ctx).Raw(template.ParseTemplate(sql)).Scan(receiver).Error
+	session := v2.GetGormReader(ctx)
+	params, isParams := template.(SqlTemplateParams)
+	if !isParams && template != nil {
+		// 兼容自行渲染的模板
+		return session.Raw(template.ParseTemplate(sql)).Scan(receiver).Error
+	}
+
+	bound, err := bindSqlTemplate(session, sql, params)
+	if err != nil {
+		return err
+	}
+
+	return session.Raw("?", bound).Scan(receiver).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) ExecuteRawSqlTemplate(ctx context.Context, sql string, template RawSqlTemplate) error {
-	return v2.Db.WithContext(ctx).Exec(template.ParseTemplate(sql)).Error
+	session := v2.GetGormCore(ctx)
+	params, isParams := template.(SqlTemplateParams)
+	if !isParams && template != nil {
+		// 兼容自行渲染的模板
+		return session.Exec(template.ParseTemplate(sql)).Error
+	}
+
+	bound, err := bindSqlTemplate(session, sql, params)
+	if err != nil {
+		return err
+	}
+
+	return session.Exec("?", bound).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) ExecuteRawSqlQuery(ctx context.Context, receiver any, sql string) error {
-	return v2.Db.WithContext(ctx).Raw(sql).Scan(receiver).Error
+	return v2.GetGormReader(ctx).Raw(sql).Scan(receiver).Error
 }
 
 func (v2 *BaseDatabaseImplementV2) ExecuteRawSql(ctx context.Context, sql string) error {
-	return v2.Db.WithContext(ctx).Exec(sql).Error
+	return v2.GetGormCore(ctx).Exec(sql).Error
 }
 
 var (
-	ErrInvalidCondition  = errors.New("invalid condition")
-	ErrInvalidSingleData = errors.New("invalid single data")
+	ErrInvalidCondition      = errors.New("invalid condition")
+	ErrInvalidSingleData     = errors.New("invalid single data")
+	ErrRecordNotFound        = gorm.ErrRecordNotFound
+	ErrInvalidCursor         = errors.New("invalid cursor")
+	ErrStaleVersion          = errors.New("stale version, the data is modified or deleted by others")
+	ErrInvalidBatchData      = errors.New("invalid batch data, a slice is required")
+	ErrInvalidUpsertStrategy = errors.New("invalid upsert strategy")
 )
@@ -2,44 +2,203 @@ package database
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alioth-center/infrastructure/logger"
+	"gorm.io/gorm"
 	glog "gorm.io/gorm/logger"
 )
 
+const (
+	defaultSlowThreshold = time.Millisecond * 200
+	defaultSampleRate    = 0.1
+
+	redactedValue = "[REDACTED]"
+)
+
+var (
+	// gormSourceDir and databaseSourceDir are skipped when looking up the caller of a statement.
+	gormSourceDir     = sourceDir(gorm.Open)
+	databaseSourceDir = sourceDir(NewDBLogger)
+)
+
+func sourceDir(fn any) string {
+	pc := reflect.ValueOf(fn).Pointer()
+	file, _ := runtime.FuncForPC(pc).FileLine(pc)
+	return filepath.Dir(file)
+}
+
+// DBLogger is the gorm logger writing to the logger of the infrastructure, it also records the
+// latency and the errors of the statements into SqlMetrics. The failed statements are logged at
+// error level, the statements slower than the slow threshold at warn level, and the sampled
+// successful statements at debug level, which are filtered by the LogMode of gorm.
 type DBLogger struct {
-	log logger.Logger
+	log           logger.Logger
+	level         glog.LogLevel
+	slowThreshold time.Duration
+	sampleRate    float64
+	sensitive     map[string]struct{}
+	metrics       *SqlMetrics
 }
 
-func NewDBLogger(log logger.Logger) *DBLogger {
-	return &DBLogger{log: log}
+type DBLoggerOption func(dl *DBLogger)
+
+// WithSlowThresholdOpts sets the threshold of the slow statements, it is 200ms by default, the
+// non-positive threshold is ignored.
+func WithSlowThresholdOpts(threshold time.Duration) DBLoggerOption {
+	return func(dl *DBLogger) {
+		if threshold > 0 {
+			dl.slowThreshold = threshold
+		}
+	}
 }
 
-func (dl *DBLogger) LogMode(level glog.LogLevel) glog.Interface {
+// WithSensitiveColumnsOpts redacts the parameters bound to the columns in the logged statements,
+// the columns are matched case-insensitively without the table.
+func WithSensitiveColumnsOpts(columns ...string) DBLoggerOption {
+	return func(dl *DBLogger) {
+		for _, column := range columns {
+			dl.sensitive[strings.ToLower(column)] = struct{}{}
+		}
+	}
+}
+
+// WithSampleRateOpts sets the rate of the successful statements to log, which is between 0 and 1,
+// it is 0.1 by default, the rate out of the range is ignored. Use the LogMode of gorm to stop
+// logging the successful statements.
+func WithSampleRateOpts(rate float64) DBLoggerOption {
+	return func(dl *DBLogger) {
+		if rate > 0 && rate <= 1 {
+			dl.sampleRate = rate
+		}
+	}
+}
+
+// WithSqlMetricsOpts records the statistics into metrics instead of DefaultSqlMetrics.
+func WithSqlMetricsOpts(metrics *SqlMetrics) DBLoggerOption {
+	return func(dl *DBLogger) {
+		if metrics != nil {
+			dl.metrics = metrics
+		}
+	}
+}
+
+func NewDBLogger(log logger.Logger, opts ...DBLoggerOption) *DBLogger {
+	dl := &DBLogger{
+		log:           log,
+		level:         glog.Info,
+		slowThreshold: defaultSlowThreshold,
+		sampleRate:    defaultSampleRate,
+		sensitive:     map[string]struct{}{},
+		metrics:       DefaultSqlMetrics(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(dl)
+		}
+	}
+
 	return dl
 }
 
+// NewDBLoggerWithOptions creates the logger of the drivers with the logging settings of the options.
+func NewDBLoggerWithOptions(options Options) *DBLogger {
+	return NewDBLogger(
+		options.Logger,
+		WithSlowThresholdOpts(options.SlowThreshold),
+		WithSensitiveColumnsOpts(options.SensitiveColumns...),
+		WithSampleRateOpts(options.SampleRate),
+	)
+}
+
+func (dl *DBLogger) LogMode(level glog.LogLevel) glog.Interface {
+	copied := *dl
+	copied.level = level
+	return &copied
+}
+
 func (dl *DBLogger) Info(ctx context.Context, s string, i ...interface{}) {
-	dl.log.Infof(logger.NewFields(ctx), s, i...)
+	if dl.level >= glog.Info {
+		dl.log.Infof(logger.NewFields(ctx), s, i...)
+	}
 }
 
 func (dl *DBLogger) Warn(ctx context.Context, s string, i ...interface{}) {
-	dl.log.Warnf(logger.NewFields(ctx), s, i...)
+	if dl.level >= glog.Warn {
+		dl.log.Warnf(logger.NewFields(ctx), s, i...)
+	}
 }
 
 func (dl *DBLogger) Error(ctx context.Context, s string, i ...interface{}) {
-	dl.log.Errorf(logger.NewFields(ctx), s, i...)
+	if dl.level >= glog.Error {
+		dl.log.Errorf(logger.NewFields(ctx), s, i...)
+	}
+}
+
+// ParamsFilter redacts the parameters of the sensitive columns before the statement is rendered,
+// it is called by gorm.
+func (dl *DBLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if len(dl.sensitive) == 0 || len(params) == 0 {
+		return sql, params
+	}
+
+	filtered, copied := params, false
+	for i, column := range placeholderColumns(sql, len(params)) {
+		if _, sensitive := dl.sensitive[strings.ToLower(column)]; !sensitive {
+			continue
+		}
+		if !copied {
+			// 复制参数，避免修改执行语句使用的参数
+			filtered, copied = append([]interface{}{}, params...), true
+		}
+		filtered[i] = redactedValue
+	}
+
+	return sql, filtered
 }
 
 func (dl *DBLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	duration := time.Since(begin)
 	sql, rows := fc()
-	if err != nil {
-		logMessage := map[string]any{"sql": sql, "error": err.Error(), "rows": rows}
+	operation, table := parseStatement(sql)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := duration >= dl.slowThreshold
+	dl.metrics.record(table, operation, duration, failed, slow)
+
+	logMessage := map[string]any{"sql": sql, "rows": rows, "duration": duration.String()}
+	switch {
+	case failed && dl.level >= glog.Error:
+		logMessage["error"], logMessage["caller"] = err.Error(), statementCaller()
 		dl.log.Error(logger.NewFields(ctx).WithMessage("tracing sql with error").WithData(logMessage).WithCallTime(begin))
-		return
+	case slow && dl.level >= glog.Warn:
+		logMessage["caller"] = statementCaller()
+		dl.log.Warn(logger.NewFields(ctx).WithMessage("slow sql").WithData(logMessage).WithCallTime(begin))
+	case !failed && dl.level >= glog.Info && rand.Float64() < dl.sampleRate:
+		dl.log.Debug(logger.NewFields(ctx).WithMessage("tracing sql").WithData(logMessage).WithCallTime(begin))
 	}
+}
 
-	logMessage := map[string]any{"sql": sql, "rows": rows}
-	dl.log.Debug(logger.NewFields(ctx).WithMessage("tracing sql").WithData(logMessage).WithCallTime(begin))
+// statementCaller returns the file and line of the code issuing the statement, the frames of gorm
+// and this package are skipped, except the tests.
+func statementCaller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		inGorm := strings.HasPrefix(frame.File, gormSourceDir)
+		inDatabase := filepath.Dir(frame.File) == databaseSourceDir && !strings.HasSuffix(frame.File, "_test.go")
+		if frame.File != "" && !inGorm && !inDatabase {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
 }
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alioth-center/infrastructure/logger"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
+)
+
+// captureLogger records the entries by level, only Debug, Warn and Error are used by DBLogger.
+type captureLogger struct {
+	logger.Logger
+	mtx     sync.Mutex
+	entries map[string][]*logger.Entry
+}
+
+func (l *captureLogger) capture(level string, fields logger.Fields) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.entries[level] = append(l.entries[level], fields.Export())
+}
+
+func (l *captureLogger) take(level string) []*logger.Entry {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	entries := l.entries[level]
+	delete(l.entries, level)
+	return entries
+}
+
+func (l *captureLogger) Debug(fields logger.Fields) { l.capture("debug", fields) }
+func (l *captureLogger) Warn(fields logger.Fields)  { l.capture("warn", fields) }
+func (l *captureLogger) Error(fields logger.Fields) { l.capture("error", fields) }
+
+type loggerAccount struct {
+	ID       int    `gorm:"column:id;primaryKey"`
+	Name     string `gorm:"column:name"`
+	Password string `gorm:"column:password"`
+}
+
+func (loggerAccount) TableName() string {
+	return "logger_accounts"
+}
+
+func TestParseStatement(t *testing.T) {
+	testCases := []struct {
+		sql       string
+		operation string
+		table     string
+	}{
+		{sql: "SELECT * FROM `users` WHERE id = 1", operation: "select", table: "users"},
+		{sql: `SELECT count(*) FROM "public"."users"`, operation: "select", table: "users"},
+		{sql: "INSERT INTO users (id) VALUES (1)", operation: "insert", table: "users"},
+		{sql: "UPDATE `users` SET `name`='a' WHERE id = 1", operation: "update", table: "users"},
+		{sql: "delete from users where id = 1", operation: "delete", table: "users"},
+		{sql: "WITH recent AS (SELECT 1) SELECT * FROM orders", operation: "select", table: "orders"},
+		{sql: "SELECT 1", operation: "select", table: ""},
+		{sql: "CREATE TABLE users (id int)", operation: "other", table: ""},
+	}
+
+	for _, testCase := range testCases {
+		if operation, table := parseStatement(testCase.sql); operation != testCase.operation || table != testCase.table {
+			t.Errorf("expected %s %s of %s, got %s %s", testCase.operation, testCase.table, testCase.sql, operation, table)
+		}
+	}
+}
+
+func TestPlaceholderColumns(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		count    int
+		expected []string
+	}{
+		{sql: "INSERT INTO `users` (`name`,`password`) VALUES (?,?),(?,?)", count: 4, expected: []string{"name", "password", "name", "password"}},
+		{sql: `UPDATE "users" SET "password"=$1,"name"=$2 WHERE "users"."id" = $3`, count: 3, expected: []string{"password", "name", "id"}},
+		{sql: "SELECT * FROM users WHERE lower(email) = ? AND password = md5(?) AND id IN (?,?) AND note = '?' LIMIT ?", count: 5, expected: []string{"email", "password", "id", "id", ""}},
+		{sql: "SELECT * FROM users WHERE age BETWEEN ? AND ?", count: 2, expected: []string{"age", "age"}},
+	}
+
+	for _, testCase := range testCases {
+		if actual := placeholderColumns(testCase.sql, testCase.count); !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("expected %v of %s, got %v", testCase.expected, testCase.sql, actual)
+		}
+	}
+}
+
+func TestDBLogger(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open("file:db_logger?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDb, dbErr := db.DB(); dbErr == nil {
+			_ = sqlDb.Close()
+		}
+	})
+	if err = db.AutoMigrate(&loggerAccount{}); err != nil {
+		t.Fatal(err)
+	}
+
+	log, metrics := &captureLogger{entries: map[string][]*logger.Entry{}}, NewSqlMetrics()
+	newLogger := func(opts ...DBLoggerOption) glog.Interface {
+		return NewDBLogger(log, append(opts, WithSqlMetricsOpts(metrics), WithSensitiveColumnsOpts("PASSWORD"))...)
+	}
+
+	t.Run("Slow", func(t *testing.T) {
+		session := db.Session(&gorm.Session{Logger: newLogger(WithSlowThresholdOpts(time.Nanosecond))}).WithContext(ctx)
+		if err := session.Create(&loggerAccount{ID: 1, Name: "alice", Password: "secret"}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		entries := log.take("warn")
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 slow entry, got %d", len(entries))
+		}
+		data := entries[0].Data.(map[string]any)
+		sql, caller := data["sql"].(string), data["caller"].(string)
+		if strings.Contains(sql, "secret") || !strings.Contains(sql, redactedValue) || !strings.Contains(sql, "alice") {
+			t.Fatalf("expected the password redacted, got %s", sql)
+		}
+		if !strings.Contains(caller, "logger_unit_test.go") || data["rows"] != int64(1) || data["duration"] == "" {
+			t.Fatalf("unexpected slow entry: %v", data)
+		}
+
+		// 执行的参数没有被修改
+		var account loggerAccount
+		if err := db.Take(&account, 1).Error; err != nil || account.Password != "secret" {
+			t.Fatalf("expected the password stored, got %+v, %v", account, err)
+		}
+	})
+
+	t.Run("Sampled", func(t *testing.T) {
+		sampled := db.Session(&gorm.Session{Logger: newLogger(WithSlowThresholdOpts(time.Hour), WithSampleRateOpts(1))})
+		for range 3 {
+			sampled.Find(&[]loggerAccount{})
+		}
+		if entries := log.take("debug"); len(entries) != 3 {
+			t.Fatalf("expected 3 sampled entries, got %d", len(entries))
+		}
+
+		// 日志级别为 Warn 时不记录成功的语句
+		quiet := db.Session(&gorm.Session{Logger: newLogger(WithSlowThresholdOpts(time.Hour), WithSampleRateOpts(1)).LogMode(glog.Warn)})
+		quiet.Find(&[]loggerAccount{})
+		if entries := log.take("debug"); len(entries) != 0 {
+			t.Fatalf("expected no sampled entry, got %d", len(entries))
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		session := db.Session(&gorm.Session{Logger: newLogger(WithSlowThresholdOpts(time.Hour))})
+		session.Exec("SELECT * FROM missing_accounts")
+		session.Take(&loggerAccount{}, 404)
+		if entries := log.take("error"); len(entries) != 1 || entries[0].Data.(map[string]any)["error"] == nil {
+			t.Fatalf("expected 1 error entry, got %v", entries)
+		}
+
+		silent := db.Session(&gorm.Session{Logger: newLogger().LogMode(glog.Silent)})
+		silent.Exec("SELECT * FROM missing_accounts")
+		if entries := log.take("error"); len(entries) != 0 {
+			t.Fatalf("expected no error entry, got %d", len(entries))
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		if err := metrics.Write(buffer); err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range []string{
+			`database_queries_total{table="logger_accounts",operation="insert",result="ok"} 1`,
+			`database_queries_total{table="logger_accounts",operation="select",result="ok"} 5`,
+			`database_queries_total{table="missing_accounts",operation="select",result="error"} 2`,
+			`database_slow_queries_total{table="logger_accounts",operation="insert"} 1`,
+			`database_query_duration_seconds_count{table="logger_accounts",operation="select"} 5`,
+			`database_query_duration_seconds_bucket{table="logger_accounts",operation="select",le="+Inf"} 5`,
+		} {
+			if !strings.Contains(buffer.String(), line+"\n") {
+				t.Errorf("metrics do not contain %s:\n%s", line, buffer.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTrackedTables limits the number of tables tracked by a SqlMetrics, the statements of the
+	// other tables are counted under the table otherTable.
+	maxTrackedTables = 1000
+
+	otherTable = "_other"
+)
+
+// queryDurationBuckets are the upper bounds of the latency histogram in seconds.
+var queryDurationBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type queryLabels struct {
+	table     string
+	operation string
+}
+
+type queryStats struct {
+	ok, errors, slow uint64
+	buckets          []uint64
+	count            uint64
+	sum              float64
+}
+
+// SqlMetrics collects the statistics of the statements traced by DBLogger, it serves them in the
+// prometheus text format, so it can be mounted on any http server.
+//
+// example:
+//
+//	engine.AddEndPoints(http.NewRawEndPoint(http.GET, http.NewRouter("/metrics"), database.DefaultSqlMetrics()))
+type SqlMetrics struct {
+	mtx     sync.Mutex
+	queries map[queryLabels]*queryStats
+	tables  map[string]struct{}
+}
+
+var defaultSqlMetrics = NewSqlMetrics()
+
+// DefaultSqlMetrics returns the metrics used by the loggers created without WithSqlMetricsOpts.
+func DefaultSqlMetrics() *SqlMetrics {
+	return defaultSqlMetrics
+}
+
+func NewSqlMetrics() *SqlMetrics {
+	return &SqlMetrics{
+		queries: map[queryLabels]*queryStats{},
+		tables:  map[string]struct{}{},
+	}
+}
+
+func (m *SqlMetrics) record(table, operation string, duration time.Duration, failed, slow bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, tracked := m.tables[table]; !tracked {
+		if len(m.tables) >= maxTrackedTables {
+			table = otherTable
+		}
+		m.tables[table] = struct{}{}
+	}
+
+	labels := queryLabels{table: table, operation: operation}
+	stats, exist := m.queries[labels]
+	if !exist {
+		stats = &queryStats{buckets: make([]uint64, len(queryDurationBuckets))}
+		m.queries[labels] = stats
+	}
+
+	if failed {
+		stats.errors++
+	} else {
+		stats.ok++
+	}
+	if slow {
+		stats.slow++
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range queryDurationBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+	stats.count++
+	stats.sum += seconds
+}
+
+// Write writes the metrics in the prometheus text exposition format.
+func (m *SqlMetrics) Write(w io.Writer) (err error) {
+	m.mtx.Lock()
+	queries := make([]queryLabels, 0, len(m.queries))
+	for labels := range m.queries {
+		queries = append(queries, labels)
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].table != queries[j].table {
+			return queries[i].table < queries[j].table
+		}
+		return queries[i].operation < queries[j].operation
+	})
+
+	// 先写入缓冲区，避免持有锁时等待网络写入
+	writer := &bytes.Buffer{}
+	_, _ = writer.WriteString("# HELP database_queries_total Number of sql statements by result.\n")
+	_, _ = writer.WriteString("# TYPE database_queries_total counter\n")
+	for _, labels := range queries {
+		stats := m.queries[labels]
+		for _, counted := range []struct {
+			result string
+			value  uint64
+		}{{"ok", stats.ok}, {"error", stats.errors}} {
+			if counted.value > 0 {
+				_, _ = fmt.Fprintf(writer, "database_queries_total{table=%s,operation=%s,result=%q} %d\n", quoteLabel(labels.table), quoteLabel(labels.operation), counted.result, counted.value)
+			}
+		}
+	}
+
+	_, _ = writer.WriteString("# HELP database_slow_queries_total Number of sql statements slower than the slow threshold.\n")
+	_, _ = writer.WriteString("# TYPE database_slow_queries_total counter\n")
+	for _, labels := range queries {
+		if stats := m.queries[labels]; stats.slow > 0 {
+			_, _ = fmt.Fprintf(writer, "database_slow_queries_total{table=%s,operation=%s} %d\n", quoteLabel(labels.table), quoteLabel(labels.operation), stats.slow)
+		}
+	}
+
+	_, _ = writer.WriteString("# HELP database_query_duration_seconds Latency of sql statements.\n")
+	_, _ = writer.WriteString("# TYPE database_query_duration_seconds histogram\n")
+	for _, labels := range queries {
+		stats, table, operation := m.queries[labels], quoteLabel(labels.table), quoteLabel(labels.operation)
+		for i, bound := range queryDurationBuckets {
+			_, _ = fmt.Fprintf(writer, "database_query_duration_seconds_bucket{table=%s,operation=%s,le=%q} %d\n", table, operation, strconv.FormatFloat(bound, 'g', -1, 64), stats.buckets[i])
+		}
+		_, _ = fmt.Fprintf(writer, "database_query_duration_seconds_bucket{table=%s,operation=%s,le=\"+Inf\"} %d\n", table, operation, stats.count)
+		_, _ = fmt.Fprintf(writer, "database_query_duration_seconds_sum{table=%s,operation=%s} %s\n", table, operation, strconv.FormatFloat(stats.sum, 'g', -1, 64))
+		_, _ = fmt.Fprintf(writer, "database_query_duration_seconds_count{table=%s,operation=%s} %d\n", table, operation, stats.count)
+	}
+	m.mtx.Unlock()
+
+	_, err = writer.WriteTo(w)
+	return err
+}
+
+func (m *SqlMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.Write(w)
+}
+
+// quoteLabel quotes a label value, only the backslash, double quote and line feed are escaped in
+// the prometheus text format.
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,63 @@
+package migration
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/alioth-center/infrastructure/utils/values"
+)
+
+var (
+	ErrLockTimeout  = errors.New("timeout waiting for the migration lock")
+	ErrInvalidSteps = errors.New("number of the migrations to roll back must not be negative")
+)
+
+type DuplicateVersionError struct {
+	Version int64 `json:"version"`
+}
+
+func (err *DuplicateVersionError) Error() string {
+	return values.BuildStrings("duplicate migration version: ", strconv.FormatInt(err.Version, 10))
+}
+
+type ChecksumMismatchError struct {
+	Version  int64  `json:"version"`
+	Name     string `json:"name"`
+	Applied  string `json:"applied"`
+	Expected string `json:"expected"`
+}
+
+func (err *ChecksumMismatchError) Error() string {
+	return values.BuildStrings("checksum of applied migration ", strconv.FormatInt(err.Version, 10), "_", err.Name, " mismatch, applied: ", err.Applied, ", expected: ", err.Expected)
+}
+
+type IrreversibleError struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+}
+
+func (err *IrreversibleError) Error() string {
+	return values.BuildStrings("migration ", strconv.FormatInt(err.Version, 10), "_", err.Name, " can not be rolled back")
+}
+
+type UnknownMigrationError struct {
+	Version int64 `json:"version"`
+}
+
+func (err *UnknownMigrationError) Error() string {
+	return values.BuildStrings("applied migration ", strconv.FormatInt(err.Version, 10), " is not found in the migrations")
+}
+
+type LockedError struct {
+	Owner    string    `json:"owner"`
+	LockedAt time.Time `json:"locked_at"`
+}
+
+func (err *LockedError) Error() string {
+	return values.BuildStrings("migration is locked by ", err.Owner, " since ", err.LockedAt.Format(time.RFC3339), ": ", ErrLockTimeout.Error())
+}
+
+func (err *LockedError) Unwrap() error {
+	return ErrLockTimeout
+}
@@ -0,0 +1,287 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alioth-center/infrastructure/database"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// MigrateFunc runs a migration step, the context carries the transaction of the step, so the
+// methods of db called with it run in the transaction.
+type MigrateFunc func(ctx context.Context, db database.DatabaseV2) error
+
+// Migration is a versioned change of the database schema or data, the migrations are applied in
+// the ascending order of the versions and rolled back in the descending order.
+type Migration struct {
+	// Version orders the migrations, it is usually a timestamp such as 20240601120000.
+	Version int64
+
+	// Name describes the migration.
+	Name string
+
+	// Checksum detects the changes of an applied migration, it is filled by the sql migrations,
+	// an empty checksum is not validated.
+	Checksum string
+
+	// Up applies the migration.
+	Up MigrateFunc
+
+	// Down rolls back the migration, a nil Down makes the migration irreversible.
+	Down MigrateFunc
+
+	upSql, downSql string
+}
+
+// NewSqlMigration creates a migration running the sql statements, the statements are separated by
+// semicolons. A down without statements makes the migration irreversible.
+func NewSqlMigration(version int64, name, up, down string) Migration {
+	migration := Migration{
+		Version:  version,
+		Name:     name,
+		Checksum: checksum(up),
+		Up:       sqlMigrateFunc(up),
+		upSql:    up,
+		downSql:  down,
+	}
+	if hasStatements(down) {
+		migration.Down = sqlMigrateFunc(down)
+	}
+
+	return migration
+}
+
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	draftHandler func(version int64, name string)
+}
+
+// WithDraftHandlerOpts calls handler with each draft skipped by LoadSqlMigrations, such as to
+// warn about the migrations created but not written yet.
+func WithDraftHandlerOpts(handler func(version int64, name string)) LoadOption {
+	return func(options *loadOptions) {
+		options.draftHandler = handler
+	}
+}
+
+// LoadSqlMigrations loads the sql migrations from the directory dir of fsys, the files are named
+// as <version>_<name>.up.sql and <version>_<name>.down.sql, the down file is optional. The drafts,
+// whose up files have no statements such as the stubs just created, are skipped until they are
+// written, so that they are neither applied nor recorded.
+//
+// example:
+//
+//	//go:embed migrations/*.sql
+//	var migrationFiles embed.FS
+//
+//	migrations, err := migration.LoadSqlMigrations(migrationFiles, "migrations")
+func LoadSqlMigrations(fsys fs.FS, dir string, opts ...LoadOption) (migrations []Migration, err error) {
+	options := loadOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	entries, readErr := fs.ReadDir(fsys, dir)
+	if readErr != nil {
+		return nil, fmt.Errorf("read migration directory error: %w", readErr)
+	}
+
+	type files struct{ name, up, down string }
+	loaded := map[int64]*files{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(fileName, upSuffix) && !strings.HasSuffix(fileName, downSuffix)) {
+			continue
+		}
+
+		version, name, parseErr := parseFileName(fileName)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		content, contentErr := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if contentErr != nil {
+			return nil, fmt.Errorf("read migration file %s error: %w", fileName, contentErr)
+		}
+
+		file, exist := loaded[version]
+		if !exist {
+			file = &files{name: name}
+			loaded[version] = file
+		} else if file.name != name {
+			return nil, &DuplicateVersionError{Version: version}
+		}
+		if strings.HasSuffix(fileName, upSuffix) {
+			file.up = string(content)
+		} else {
+			file.down = string(content)
+		}
+	}
+
+	migrations = make([]Migration, 0, len(loaded))
+	for version, file := range loaded {
+		if !hasStatements(file.up) {
+			if options.draftHandler != nil {
+				options.draftHandler(version, file.name)
+			}
+			continue
+		}
+		migrations = append(migrations, NewSqlMigration(version, file.name, file.up, file.down))
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// FileNames returns the names of the up and down files of a sql migration.
+func FileNames(version int64, name string) (up, down string) {
+	prefix := strconv.FormatInt(version, 10) + "_" + name
+	return prefix + upSuffix, prefix + downSuffix
+}
+
+func parseFileName(fileName string) (version int64, name string, err error) {
+	base := strings.TrimSuffix(strings.TrimSuffix(fileName, upSuffix), downSuffix)
+	versionText, name, _ := strings.Cut(base, "_")
+	version, err = strconv.ParseInt(versionText, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", fmt.Errorf("invalid migration file name %s, expected <version>_<name>%s", fileName, upSuffix)
+	}
+
+	return version, name, nil
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func sqlMigrateFunc(sql string) MigrateFunc {
+	return func(ctx context.Context, db database.DatabaseV2) error {
+		// postgres 的标准字符串中反斜杠不是转义符
+		backslashEscapes := db.GetGormCore(ctx).Dialector.Name() != "postgres"
+		for _, statement := range splitStatements(sql, backslashEscapes) {
+			if err := db.GetGormCore(ctx).Exec(statement).Error; err != nil {
+				return database.NewExecuteSqlError(statement, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// hasStatements reports whether the sql has any statement other than the comments.
+func hasStatements(sql string) bool {
+	return len(splitStatements(sql, false)) > 0
+}
+
+// splitStatements splits the sql by the semicolons outside the quotes, the comments and the
+// dollar-quoted strings of postgres, since not all the drivers accept multiple statements. The
+// backslash escapes the quote if backslashEscapes is set, as mysql does, otherwise only in the
+// escape strings of postgres such as E'it\'s', the quote is always escaped by doubling it.
+func splitStatements(sql string, backslashEscapes bool) (statements []string) {
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			escapes := c != '`' && (backslashEscapes || (c == '\'' && isEscapeStringPrefix(sql, i)))
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == '\\' && escapes {
+					end += 2
+					continue
+				}
+				if sql[end] == c {
+					break
+				}
+				end++
+			}
+			end = min(end, len(sql)-1)
+			current.WriteString(sql[i : end+1])
+			i = end
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end
+			current.WriteByte('\n')
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == '$':
+			// $$ 或 $tag$ 包裹的函数体中可能包含分号
+			tagEnd := strings.IndexByte(sql[i+1:], '$')
+			if tagEnd < 0 || !isDollarTag(sql[i+1:i+1+tagEnd]) {
+				current.WriteByte(c)
+				continue
+			}
+			tag := sql[i : i+tagEnd+2]
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				current.WriteString(sql[i:])
+				i = len(sql)
+				continue
+			}
+			current.WriteString(sql[i : i+len(tag)+end+len(tag)])
+			i += len(tag) + end + len(tag) - 1
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// isEscapeStringPrefix reports whether the quote at i starts an escape string of postgres, which
+// is prefixed by E or e.
+func isEscapeStringPrefix(sql string, i int) bool {
+	if i == 0 || (sql[i-1] != 'E' && sql[i-1] != 'e') {
+		return false
+	}
+	if i == 1 {
+		return true
+	}
+
+	prev := sql[i-2]
+	return !(prev == '_' || prev >= 'a' && prev <= 'z' || prev >= 'A' && prev <= 'Z' || prev >= '0' && prev <= '9')
+}
+
+func isDollarTag(tag string) bool {
+	for i, c := range tag {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
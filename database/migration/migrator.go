@@ -0,0 +1,422 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/alioth-center/infrastructure/database"
+	"github.com/alioth-center/infrastructure/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+
+	StatePending          = "pending"
+	StateApplied          = "applied"
+	StateUnknown          = "unknown"
+	StateChecksumMismatch = "checksum_mismatch"
+)
+
+const (
+	defaultTable       = "schema_migrations"
+	defaultLockTimeout = time.Minute
+	lockRetryInterval  = time.Millisecond * 500
+	lockSuffix         = "_lock"
+	lockID             = 1
+)
+
+// appliedMigration is a row of the migration table, applied_at is stored as unix seconds so it
+// can be scanned without the parseTime option of mysql.
+type appliedMigration struct {
+	Version   int64  `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string `gorm:"column:name;type:varchar(255)"`
+	Checksum  string `gorm:"column:checksum;type:varchar(64)"`
+	AppliedAt int64  `gorm:"column:applied_at"`
+}
+
+// migrationLock is the only row of the lock table while a migrator holds the lock.
+type migrationLock struct {
+	ID       int    `gorm:"column:id;primaryKey;autoIncrement:false"`
+	Owner    string `gorm:"column:owner;type:varchar(255)"`
+	LockedAt int64  `gorm:"column:locked_at"`
+}
+
+// Step is a migration applied or rolled back by the migrator, the Sql is the statements of the sql
+// migrations, it is empty for the go migrations.
+type Step struct {
+	Version   int64         `json:"version"`
+	Name      string        `json:"name"`
+	Direction string        `json:"direction"`
+	Sql       string        `json:"sql,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+}
+
+// Status is the state of a migration, StateUnknown means the migration is applied but not found
+// in the migrations.
+type Status struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	AppliedAt time.Time `json:"applied_at,omitempty"`
+}
+
+type Option func(*Migrator)
+
+// WithTableOpts sets the name of the migration table, it is schema_migrations by default, the lock
+// table is named as the migration table with the suffix _lock.
+func WithTableOpts(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithLockTimeoutOpts sets the time to wait for the lock held by the other migrators, it is one
+// minute by default.
+func WithLockTimeoutOpts(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithDryRunOpts makes the migrator report the steps without executing them.
+func WithDryRunOpts() Option {
+	return func(m *Migrator) {
+		m.dryRun = true
+	}
+}
+
+// WithLoggerOpts logs the steps executed by the migrator.
+func WithLoggerOpts(logger logger.Logger) Option {
+	return func(m *Migrator) {
+		m.logger = logger
+	}
+}
+
+// Migrator applies and rolls back the versioned migrations, the applied versions are recorded in
+// the migration table. Each step runs in a transaction together with its record, note that mysql
+// commits the ddl statements implicitly, so a failed step of mysql may be applied partially.
+//
+// Concurrent migrators are serialized by a lock row in the lock table, a lock left by a crashed
+// migrator can be removed by ForceUnlock.
+type Migrator struct {
+	db          database.DatabaseV2
+	migrations  []Migration
+	table       string
+	lockTimeout time.Duration
+	dryRun      bool
+	logger      logger.Logger
+}
+
+// NewMigrator creates a migrator of the migrations, the versions of the migrations must be unique.
+//
+// example:
+//
+//	migrations, _ := migration.LoadSqlMigrations(migrationFiles, "migrations")
+//	migrator, _ := migration.NewMigrator(db, migrations)
+//	steps, err := migrator.Up(ctx)
+func NewMigrator(db database.DatabaseV2, migrations []Migration, opts ...Option) (*Migrator, error) {
+	m := &Migrator{
+		db:          db,
+		migrations:  append([]Migration{}, migrations...),
+		table:       defaultTable,
+		lockTimeout: defaultLockTimeout,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+	for i, migration := range m.migrations {
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up function", migration.Version, migration.Name)
+		}
+		if i > 0 && m.migrations[i-1].Version == migration.Version {
+			return nil, &DuplicateVersionError{Version: migration.Version}
+		}
+	}
+
+	return m, nil
+}
+
+// Up applies all the pending migrations.
+func (m *Migrator) Up(ctx context.Context) (steps []Step, err error) {
+	return m.UpTo(ctx, 0)
+}
+
+// UpTo applies the pending migrations whose versions are not greater than version, zero version
+// applies all the pending migrations. The pending migrations older than the applied ones are
+// applied as well.
+func (m *Migrator) UpTo(ctx context.Context, version int64) (steps []Step, err error) {
+	err = m.locked(ctx, func(applied map[int64]appliedMigration) error {
+		if validateErr := m.validate(applied); validateErr != nil {
+			return validateErr
+		}
+
+		for _, migration := range m.migrations {
+			if version > 0 && migration.Version > version {
+				break
+			}
+			if _, exist := applied[migration.Version]; exist {
+				continue
+			}
+
+			step, stepErr := m.run(ctx, migration, DirectionUp)
+			if stepErr != nil {
+				return stepErr
+			}
+			steps = append(steps, step)
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
+
+// Down rolls back the last n applied migrations in the descending order of the versions, a
+// negative n is rejected with ErrInvalidSteps.
+func (m *Migrator) Down(ctx context.Context, n int) (steps []Step, err error) {
+	if n < 0 {
+		return nil, ErrInvalidSteps
+	}
+
+	err = m.locked(ctx, func(applied map[int64]appliedMigration) error {
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions[:min(n, len(versions))] {
+			migration, exist := m.find(version)
+			if !exist {
+				return &UnknownMigrationError{Version: version}
+			}
+			if migration.Down == nil {
+				return &IrreversibleError{Version: migration.Version, Name: migration.Name}
+			}
+
+			step, stepErr := m.run(ctx, migration, DirectionDown)
+			if stepErr != nil {
+				return stepErr
+			}
+			steps = append(steps, step)
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
+
+// Status returns the states of the migrations and the applied versions not found in the migrations,
+// in the ascending order of the versions.
+func (m *Migrator) Status(ctx context.Context) (statuses []Status, err error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if record, exist := applied[migration.Version]; exist {
+			status.State, status.AppliedAt = StateApplied, time.Unix(record.AppliedAt, 0)
+			if record.Checksum != "" && migration.Checksum != "" && record.Checksum != migration.Checksum {
+				status.State = StateChecksumMismatch
+			}
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, State: StateUnknown, AppliedAt: time.Unix(record.AppliedAt, 0)})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// ForceUnlock removes the lock whoever holds it, it is used to recover from a crashed migrator.
+func (m *Migrator) ForceUnlock(ctx context.Context) error {
+	if err := m.prepare(ctx); err != nil {
+		return err
+	}
+
+	return m.db.GetGormCore(ctx).Table(m.lockTable()).Where(clause.Eq{Column: clause.Column{Name: "id"}, Value: lockID}).Delete(&migrationLock{}).Error
+}
+
+func (m *Migrator) lockTable() string {
+	return m.table + lockSuffix
+}
+
+func (m *Migrator) find(version int64) (migration Migration, exist bool) {
+	index := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if index < len(m.migrations) && m.migrations[index].Version == version {
+		return m.migrations[index], true
+	}
+
+	return Migration{}, false
+}
+
+func (m *Migrator) validate(applied map[int64]appliedMigration) error {
+	for _, migration := range m.migrations {
+		record, exist := applied[migration.Version]
+		if exist && record.Checksum != "" && migration.Checksum != "" && record.Checksum != migration.Checksum {
+			return &ChecksumMismatchError{Version: migration.Version, Name: migration.Name, Applied: record.Checksum, Expected: migration.Checksum}
+		}
+	}
+
+	return nil
+}
+
+// prepare creates the migration table and the lock table if they do not exist.
+func (m *Migrator) prepare(ctx context.Context) error {
+	db := m.db.GetGormCore(ctx)
+	if err := db.Table(m.table).AutoMigrate(&appliedMigration{}); err != nil {
+		return fmt.Errorf("create migration table error: %w", err)
+	}
+	if err := db.Table(m.lockTable()).AutoMigrate(&migrationLock{}); err != nil {
+		return fmt.Errorf("create migration lock table error: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (applied map[int64]appliedMigration, err error) {
+	applied = map[int64]appliedMigration{}
+	db := m.db.GetGormCore(ctx)
+	if !db.Migrator().HasTable(m.table) {
+		return applied, nil
+	}
+
+	var records []appliedMigration
+	if err = db.Table(m.table).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("read migration table error: %w", err)
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+// locked runs fn with the applied migrations while holding the lock, the dry run reads the applied
+// migrations without locking or creating the tables.
+func (m *Migrator) locked(ctx context.Context, fn func(applied map[int64]appliedMigration) error) error {
+	if m.dryRun {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		return fn(applied)
+	}
+
+	if err := m.prepare(ctx); err != nil {
+		return err
+	}
+	owner, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.unlock(ctx, owner)
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	return fn(applied)
+}
+
+func (m *Migrator) lock(ctx context.Context) (owner string, err error) {
+	host, _ := os.Hostname()
+	owner = fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano())
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		session := m.db.GetGormCore(ctx).Table(m.lockTable()).Clauses(clause.OnConflict{DoNothing: true}).Create(&migrationLock{ID: lockID, Owner: owner, LockedAt: time.Now().Unix()})
+		if session.Error != nil {
+			return "", fmt.Errorf("acquire migration lock error: %w", session.Error)
+		}
+		if session.RowsAffected > 0 {
+			return owner, nil
+		}
+
+		if time.Now().After(deadline) {
+			var holder migrationLock
+			if err = m.db.GetGormCore(ctx).Table(m.lockTable()).Take(&holder).Error; err != nil {
+				return "", ErrLockTimeout
+			}
+			return "", &LockedError{Owner: holder.Owner, LockedAt: time.Unix(holder.LockedAt, 0)}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func (m *Migrator) unlock(ctx context.Context, owner string) {
+	// 即使上下文已经取消也要释放锁
+	err := m.db.GetGormCore(context.WithoutCancel(ctx)).Table(m.lockTable()).Where(clause.Eq{Column: clause.Column{Name: "owner"}, Value: owner}).Delete(&migrationLock{}).Error
+	if err != nil && m.logger != nil {
+		m.logger.Error(logger.NewFields(ctx).WithMessage("release migration lock failed").WithData(err.Error()))
+	}
+}
+
+func (m *Migrator) run(ctx context.Context, migration Migration, direction string) (step Step, err error) {
+	step = Step{Version: migration.Version, Name: migration.Name, Direction: direction, Sql: migration.upSql}
+	migrate := migration.Up
+	if direction == DirectionDown {
+		step.Sql, migrate = migration.downSql, migration.Down
+	}
+	if m.dryRun {
+		return step, nil
+	}
+
+	startedAt := time.Now()
+	err = m.db.WithTransaction(ctx, func(ctx context.Context) error {
+		if migrateErr := migrate(ctx, m.db); migrateErr != nil {
+			return migrateErr
+		}
+
+		db := m.db.GetGormCore(ctx).Table(m.table)
+		if direction == DirectionDown {
+			return db.Where(clause.Eq{Column: clause.Column{Name: "version"}, Value: migration.Version}).Delete(&appliedMigration{}).Error
+		}
+		return db.Create(&appliedMigration{Version: migration.Version, Name: migration.Name, Checksum: migration.Checksum, AppliedAt: time.Now().Unix()}).Error
+	})
+	if err != nil {
+		return step, fmt.Errorf("migrate %s %d_%s error: %w", direction, migration.Version, migration.Name, err)
+	}
+
+	step.Duration = time.Since(startedAt)
+	if m.logger != nil {
+		m.logger.Info(logger.NewFields(ctx).WithMessage("migration step executed").WithData(step))
+	}
+
+	return step, nil
+}
+
+// UseGorm adapts a function of the gorm core to a MigrateFunc, it is convenient for the migrations
+// using the gorm migrator.
+//
+// example:
+//
+//	migration.Migration{Version: 2, Name: "drop_nickname", Up: migration.UseGorm(func(tx *gorm.DB) error {
+//		return tx.Migrator().DropColumn(&User{}, "nickname")
+//	})}
+func UseGorm(fn func(tx *gorm.DB) error) MigrateFunc {
+	return func(ctx context.Context, db database.DatabaseV2) error {
+		return fn(db.GetGormCore(ctx))
+	}
+}
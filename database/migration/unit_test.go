@@ -0,0 +1,300 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alioth-center/infrastructure/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDatabase(t *testing.T, name string) database.DatabaseV2 {
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	// 共享缓存的内存数据库在最后一个连接关闭后才会释放
+	t.Cleanup(func() {
+		if sqlDb, dbErr := db.DB(); dbErr == nil {
+			_ = sqlDb.Close()
+		}
+	})
+
+	return &database.BaseDatabaseImplementV2{Db: db}
+}
+
+var testMigrationFiles = fstest.MapFS{
+	"migrations/1_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);\n-- 注释中的分号; 不会切分语句\nCREATE INDEX idx_users_name ON users (name);")},
+	"migrations/1_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"migrations/2_seed_users.up.sql":     {Data: []byte("INSERT INTO users (name) VALUES ('a;b'), ('c');")},
+	"migrations/2_seed_users.down.sql":   {Data: []byte("DELETE FROM users;")},
+	"migrations/README.md":               {Data: []byte("not a migration")},
+}
+
+func TestSplitStatements(t *testing.T) {
+	testCases := []struct {
+		sql      string
+		postgres bool
+		expected []string
+	}{
+		{sql: "SELECT 1; SELECT 2;", expected: []string{"SELECT 1", "SELECT 2"}},
+		{sql: "INSERT INTO t VALUES ('a;b', \"c;d\", `e;f`)", expected: []string{"INSERT INTO t VALUES ('a;b', \"c;d\", `e;f`)"}},
+		{sql: "SELECT 'it\\'s;'; SELECT 1", expected: []string{"SELECT 'it\\'s;'", "SELECT 1"}},
+		{sql: "SELECT 'it''s;'; SELECT 1", postgres: true, expected: []string{"SELECT 'it''s;'", "SELECT 1"}},
+		{sql: "INSERT INTO t VALUES ('C:\\'); SELECT 1", postgres: true, expected: []string{"INSERT INTO t VALUES ('C:\\')", "SELECT 1"}},
+		{sql: "SELECT E'it\\'s;', name'\\'; SELECT 1", postgres: true, expected: []string{"SELECT E'it\\'s;', name'\\'", "SELECT 1"}},
+		{sql: "SELECT 1; -- comment; here\nSELECT 2 /* block; comment */;", expected: []string{"SELECT 1", "SELECT 2"}},
+		{sql: "CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql; SELECT $1", expected: []string{"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", "SELECT $1"}},
+		{sql: "DO $$ BEGIN PERFORM 1; END $$;", expected: []string{"DO $$ BEGIN PERFORM 1; END $$"}},
+		{sql: " ; \n", expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		if actual := splitStatements(testCase.sql, !testCase.postgres); !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("split %q, expected %q, got %q", testCase.sql, testCase.expected, actual)
+		}
+	}
+}
+
+func TestLoadSqlMigrations(t *testing.T) {
+	migrations, err := LoadSqlMigrations(testMigrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[0].Name != "create_users" || migrations[1].Version != 2 {
+		t.Fatalf("unexpected migrations: %+v", migrations)
+	}
+	if migrations[0].Checksum == "" || migrations[0].Down == nil {
+		t.Fatal("expected the checksum and the down function")
+	}
+
+	up, down := FileNames(20240601120000, "add_email")
+	if up != "20240601120000_add_email.up.sql" || down != "20240601120000_add_email.down.sql" {
+		t.Fatalf("unexpected file names: %s, %s", up, down)
+	}
+
+	// 只有注释的草稿被跳过
+	var drafts []string
+	migrations, err = LoadSqlMigrations(fstest.MapFS{
+		"m/1_a.up.sql":   {Data: []byte("SELECT 1")},
+		"m/2_b.up.sql":   {Data: []byte("-- 2_b.up.sql\n")},
+		"m/2_b.down.sql": {Data: []byte("-- 2_b.down.sql\n")},
+	}, "m", WithDraftHandlerOpts(func(version int64, name string) { drafts = append(drafts, name) }))
+	if err != nil || len(migrations) != 1 || migrations[0].Version != 1 || len(drafts) != 1 || drafts[0] != "b" {
+		t.Fatalf("expected the draft skipped, migrations: %+v, drafts: %v, error: %v", migrations, drafts, err)
+	}
+
+	_, err = LoadSqlMigrations(fstest.MapFS{"m/x_bad.up.sql": {Data: []byte("SELECT 1")}}, "m")
+	if err == nil {
+		t.Fatal("expected an error of the invalid file name")
+	}
+
+	_, err = LoadSqlMigrations(fstest.MapFS{"m/1_a.up.sql": {Data: []byte("SELECT 1")}, "m/1_b.up.sql": {Data: []byte("SELECT 2")}}, "m")
+	var duplicate *DuplicateVersionError
+	if !errors.As(err, &duplicate) || duplicate.Version != 1 {
+		t.Fatalf("expected DuplicateVersionError, got %v", err)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t, "migrator")
+	migrations, err := LoadSqlMigrations(testMigrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations = append(migrations, Migration{
+		Version: 3,
+		Name:    "add_age",
+		Up: UseGorm(func(tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE users ADD COLUMN age INTEGER").Error
+		}),
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		migrator, _ := NewMigrator(db, migrations, WithDryRunOpts())
+		steps, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 3 || steps[0].Sql == "" || steps[2].Sql != "" {
+			t.Fatalf("unexpected steps: %+v", steps)
+		}
+		if db.GetGormCore(ctx).Migrator().HasTable("users") || db.GetGormCore(ctx).Migrator().HasTable(defaultTable) {
+			t.Fatal("expected nothing executed in dry run")
+		}
+	})
+
+	t.Run("UpTo", func(t *testing.T) {
+		migrator, _ := NewMigrator(db, migrations)
+		steps, err := migrator.UpTo(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 2 {
+			t.Fatalf("unexpected steps: %+v", steps)
+		}
+
+		var count int64
+		db.GetGormCore(ctx).Table("users").Count(&count)
+		if count != 2 {
+			t.Fatalf("expected 2 users, got %d", count)
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		states := []string{statuses[0].State, statuses[1].State, statuses[2].State}
+		if !reflect.DeepEqual(states, []string{StateApplied, StateApplied, StatePending}) {
+			t.Fatalf("unexpected states: %v", states)
+		}
+	})
+
+	t.Run("Up", func(t *testing.T) {
+		migrator, _ := NewMigrator(db, migrations)
+		steps, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 1 || steps[0].Version != 3 {
+			t.Fatalf("unexpected steps: %+v", steps)
+		}
+
+		// 没有待执行的迁移
+		steps, err = migrator.Up(ctx)
+		if err != nil || len(steps) != 0 {
+			t.Fatalf("unexpected steps: %+v, error: %v", steps, err)
+		}
+	})
+
+	t.Run("Irreversible", func(t *testing.T) {
+		migrator, _ := NewMigrator(db, migrations)
+		_, err := migrator.Down(ctx, 1)
+		var irreversible *IrreversibleError
+		if !errors.As(err, &irreversible) || irreversible.Version != 3 {
+			t.Fatalf("expected IrreversibleError, got %v", err)
+		}
+	})
+
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		changed := append([]Migration{}, migrations...)
+		changed[1] = NewSqlMigration(2, "seed_users", "INSERT INTO users (name) VALUES ('changed');", "")
+		migrator, _ := NewMigrator(db, changed)
+		_, err := migrator.Up(ctx)
+		var mismatch *ChecksumMismatchError
+		if !errors.As(err, &mismatch) || mismatch.Version != 2 {
+			t.Fatalf("expected ChecksumMismatchError, got %v", err)
+		}
+
+		statuses, _ := migrator.Status(ctx)
+		if statuses[1].State != StateChecksumMismatch {
+			t.Fatalf("unexpected status: %+v", statuses[1])
+		}
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		migrator, _ := NewMigrator(db, migrations[:2])
+		statuses, _ := migrator.Status(ctx)
+		if len(statuses) != 3 || statuses[2].State != StateUnknown {
+			t.Fatalf("unexpected statuses: %+v", statuses)
+		}
+
+		// 回滚的数量不能为负数
+		if _, err := migrator.Down(ctx, -1); !errors.Is(err, ErrInvalidSteps) {
+			t.Fatalf("expected ErrInvalidSteps, got %v", err)
+		}
+
+		// 未知的迁移无法回滚
+		_, err := migrator.Down(ctx, 1)
+		var unknown *UnknownMigrationError
+		if !errors.As(err, &unknown) || unknown.Version != 3 {
+			t.Fatalf("expected UnknownMigrationError, got %v", err)
+		}
+
+		db.GetGormCore(ctx).Table(defaultTable).Where("version = ?", 3).Delete(&appliedMigration{})
+		steps, err := migrator.Down(ctx, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 2 || steps[0].Version != 2 || steps[1].Version != 1 {
+			t.Fatalf("unexpected steps: %+v", steps)
+		}
+		if db.GetGormCore(ctx).Migrator().HasTable("users") {
+			t.Fatal("expected the users table dropped")
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		failed := append([]Migration{}, migrations[:2]...)
+		failed = append(failed, NewSqlMigration(3, "broken", "INSERT INTO users (name) VALUES ('x'); INSERT INTO missing VALUES (1);", ""))
+		migrator, _ := NewMigrator(db, failed)
+		steps, err := migrator.Up(ctx)
+		if err == nil || len(steps) != 2 {
+			t.Fatalf("expected the third migration failed, steps: %+v, error: %v", steps, err)
+		}
+
+		// 失败的迁移整体回滚
+		var count int64
+		db.GetGormCore(ctx).Table("users").Count(&count)
+		if count != 2 {
+			t.Fatalf("expected 2 users, got %d", count)
+		}
+		statuses, _ := migrator.Status(ctx)
+		if statuses[2].State != StatePending {
+			t.Fatalf("unexpected status: %+v", statuses[2])
+		}
+	})
+}
+
+func TestMigratorLock(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t, "migrator_lock")
+	migrator, err := NewMigrator(db, []Migration{NewSqlMigration(1, "create", "CREATE TABLE t (id INTEGER)", "DROP TABLE t")}, WithTableOpts("migrations"), WithLockTimeoutOpts(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = migrator.prepare(ctx); err != nil {
+		t.Fatal(err)
+	}
+	db.GetGormCore(ctx).Table("migrations_lock").Create(&migrationLock{ID: lockID, Owner: "other"})
+
+	_, err = migrator.Up(ctx)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Owner != "other" || !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected LockedError, got %v", err)
+	}
+
+	if err = migrator.ForceUnlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	steps, err := migrator.Up(ctx)
+	if err != nil || len(steps) != 1 {
+		t.Fatalf("unexpected steps: %+v, error: %v", steps, err)
+	}
+
+	// 迁移结束后释放锁
+	var count int64
+	db.GetGormCore(ctx).Table("migrations_lock").Count(&count)
+	if count != 0 {
+		t.Fatalf("expected the lock released, got %d locks", count)
+	}
+}
+
+func TestNewMigrator(t *testing.T) {
+	db := newTestDatabase(t, "new_migrator")
+	_, err := NewMigrator(db, []Migration{NewSqlMigration(1, "a", "SELECT 1", ""), NewSqlMigration(1, "b", "SELECT 2", "")})
+	var duplicate *DuplicateVersionError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("expected DuplicateVersionError, got %v", err)
+	}
+
+	if _, err = NewMigrator(db, []Migration{{Version: 1, Name: "empty"}}); err == nil {
+		t.Fatal("expected an error of the migration without up function")
+	}
+}
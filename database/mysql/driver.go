@@ -41,7 +41,10 @@ func (s *mysqlDb) Init(options database.Options) error {
 	if openErr != nil {
 		return fmt.Errorf("open mysqlDb database error: %w", openErr)
 	}
-	db.Logger = database.NewDBLogger(options.Logger)
+	db.Logger = database.NewDBLoggerWithOptions(options)
+	if pluginErr := db.Use(&database.Conventions{}); pluginErr != nil {
+		return fmt.Errorf("register mysqlDb conventions error: %w", pluginErr)
+	}
 
 	// 设置数据库连接池
 	sqlDb, dbe := db.DB()
@@ -52,6 +55,19 @@ func (s *mysqlDb) Init(options database.Options) error {
 	s.BaseDatabaseImplement.SetRandCommand("rand()")
 	s.BaseDatabaseImplement.SetDriverName(DriverName)
 
+	// 连接只读副本
+	if len(options.Replicas) > 0 {
+		// 跳过版本查询，避免副本不可用时打开失败
+		replicas, replicaErr := database.OpenReplicaSet(options, func(dsn string) gorm.Dialector {
+			return mysql.New(mysql.Config{DSN: dsn, SkipInitializeWithVersion: true})
+		})
+		if replicaErr != nil {
+			_ = sqlDb.Close()
+			return fmt.Errorf("open mysqlDb replicas error: %w", replicaErr)
+		}
+		s.BaseDatabaseImplementV2.Replicas = replicas
+	}
+
 	// 连接成功
 	s.BaseDatabaseImplement.Db, s.BaseDatabaseImplementV2.Db = db, db
 	s.Logger.Info(logger.NewFields().WithMessage("successfully open mysqlDb database").WithData(dataSource))
@@ -59,6 +75,9 @@ func (s *mysqlDb) Init(options database.Options) error {
 	// 注册退出事件
 	exit.RegisterExitEvent(func(_ os.Signal) {
 		_ = sqlDb.Close()
+		if s.BaseDatabaseImplementV2.Replicas != nil {
+			_ = s.BaseDatabaseImplementV2.Replicas.Close()
+		}
 		fmt.Println("closed mysql database")
 	}, "CLOSE_MYSQL_DB_CONN")
 	return nil
@@ -42,3 +42,25 @@ func TestMysqlDb(t *testing.T) {
 		logger.Info(logger.NewFields(ctx).WithMessage("query result").WithData(v))
 	}
 }
+
+func TestReplicaConfig(t *testing.T) {
+	opts := convertConfigToOptions(Config{
+		Server:        "primary",
+		Username:      "root",
+		Password:      "123456",
+		Database:      "test_db",
+		ReplicaPolicy: "random",
+		Replicas:      []ReplicaConfig{{Server: "replica1"}, {Server: "replica2", Port: 3307, Username: "reader", Password: "654321"}},
+	})
+
+	expected := []string{
+		"root:123456@tcp(replica1:3306)/test_db?charset=utf8mb4&parseTime=False&loc=Local",
+		"reader:654321@tcp(replica2:3307)/test_db?charset=utf8mb4&parseTime=False&loc=Local",
+	}
+	if len(opts.Replicas) != len(expected) || opts.Replicas[0] != expected[0] || opts.Replicas[1] != expected[1] {
+		t.Fatalf("unexpected replicas: %v", opts.Replicas)
+	}
+	if opts.DataSource != "root:123456@tcp(primary:3306)/test_db?charset=utf8mb4&parseTime=False&loc=Local" || opts.ReplicaPolicy != "random" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
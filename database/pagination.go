@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Page is a page of the offset pagination.
+type Page[T any] struct {
+	Items   []T   `json:"items"`
+	Total   int64 `json:"total"`
+	HasMore bool  `json:"has_more"`
+}
+
+// CursorPage is a page of the keyset pagination, NextCursor is empty on the last page.
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// cursor is the decoded form of the opaque cursor, it keeps the order columns to reject the
+// cursors of the other orders.
+type cursor struct {
+	Columns []string          `json:"c"`
+	Values  []json.RawMessage `json:"v"`
+}
+
+// clone copies the condition, so the pagination does not change the condition of the caller.
+func (c *Condition) clone() *Condition {
+	if c == nil {
+		return NewCondition()
+	}
+
+	cloned := *c
+	cloned.expressions = append([]clause.Expression{}, c.expressions...)
+	cloned.orders = append([]clause.OrderByColumn{}, c.orders...)
+	cloned.fields = append([]string{}, c.fields...)
+	return &cloned
+}
+
+// ListPage retrieves a page of the rows matching the condition by offset, and counts the rows
+// matching the condition. The offset and limit of the condition are replaced by the page.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition and the orders of the query, nil matches all the rows.
+//	page (int): The index of the page, starting from 0.
+//	size (int): The number of the rows of a page.
+//
+// Returns:
+//
+//	result (Page[T]): The rows of the page, the total number of the rows and whether there are more pages.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) ListPage(ctx context.Context, condition *Condition, page, size int) (result Page[T], err error) {
+	if page < 0 || size <= 0 {
+		return result, ErrInvalidCondition
+	}
+
+	if result.Total, err = r.Count(ctx, condition); err != nil {
+		return result, err
+	}
+
+	if result.Items, err = r.List(ctx, condition.clone().Offset(page*size).Limit(size)); err != nil {
+		return result, err
+	}
+
+	result.HasMore = int64(page*size+len(result.Items)) < result.Total
+	return result, nil
+}
+
+// ListAfter retrieves the rows after the cursor in the orders of the condition, which is much
+// faster than the offset pagination on the large tables since it seeks by the order columns.
+// The primary key is appended to the orders if it is absent, so the orders are total and no row
+// is skipped or repeated, the order columns should not be null. The offset and limit of the
+// condition are ignored.
+//
+// example:
+//
+//	cond := database.NewCondition().Eq(UserCols.Status, "active").OrderBy(UserCols.CreatedAt, true)
+//	page, err := users.ListAfter(ctx, cond, "", 20)
+//	next, err := users.ListAfter(ctx, cond, page.NextCursor, 20)
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition and the orders of the query, nil matches all the rows.
+//	after (string): The NextCursor of the previous page, empty for the first page.
+//	size (int): The number of the rows of a page.
+//
+// Returns:
+//
+//	result (CursorPage[T]): The rows of the page and the cursor of the next page.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) ListAfter(ctx context.Context, condition *Condition, after string, size int) (result CursorPage[T], err error) {
+	if size <= 0 {
+		return result, ErrInvalidCondition
+	}
+
+	modelSchema, err := r.schema(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	query := condition.clone().Offset(0).Limit(size + 1)
+	orderFields, err := keysetOrders(query, modelSchema)
+	if err != nil {
+		return result, err
+	}
+
+	if after != "" {
+		seek, seekErr := seekExpression(after, query.orders, orderFields)
+		if seekErr != nil {
+			return result, seekErr
+		}
+		query.add(seek)
+	}
+
+	if result.Items, err = r.List(ctx, query); err != nil {
+		return result, err
+	}
+	if len(result.Items) <= size {
+		return result, nil
+	}
+
+	// 多查询的一行只用于判断是否还有下一页
+	result.Items, result.HasMore = result.Items[:size], true
+	result.NextCursor, err = encodeCursor(ctx, query.orders, orderFields, result.Items[size-1])
+	return result, err
+}
+
+func (r *Repository[T]) schema(ctx context.Context) (*schema.Schema, error) {
+	statement := &gorm.Statement{DB: r.db.GetGormReader(ctx)}
+	if err := statement.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	return statement.Schema, nil
+}
+
+// keysetOrders appends the primary key to the orders if it is absent, and returns the fields of
+// the order columns.
+func keysetOrders(query *Condition, modelSchema *schema.Schema) (fields []*schema.Field, err error) {
+	primary, hasPrimary := modelSchema.PrioritizedPrimaryField, false
+	for _, order := range query.orders {
+		field := lookUpColumn(modelSchema, order.Column.Name)
+		if field == nil {
+			return nil, ErrInvalidCondition
+		}
+		hasPrimary = hasPrimary || field == primary
+		fields = append(fields, field)
+	}
+
+	if !hasPrimary && primary != nil {
+		desc := len(query.orders) > 0 && query.orders[len(query.orders)-1].Desc
+		query.OrderBy(primary.DBName, desc)
+		fields = append(fields, primary)
+	}
+	if len(fields) == 0 {
+		return nil, ErrInvalidCondition
+	}
+
+	return fields, nil
+}
+
+func lookUpColumn(modelSchema *schema.Schema, column string) *schema.Field {
+	if index := strings.LastIndexByte(column, '.'); index >= 0 {
+		column = column[index+1:]
+	}
+
+	return modelSchema.LookUpField(column)
+}
+
+// seekExpression builds the condition of the rows after the cursor, for the orders a, b it is
+// (a > ?) OR (a = ? AND b > ?), the comparison is reversed for the descending columns.
+func seekExpression(after string, orders []clause.OrderByColumn, fields []*schema.Field) (clause.Expression, error) {
+	content, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	decoded := cursor{}
+	if err = json.Unmarshal(content, &decoded); err != nil || len(decoded.Columns) != len(orders) || len(decoded.Values) != len(orders) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]any, len(orders))
+	for i, order := range orders {
+		if decoded.Columns[i] != order.Column.Name {
+			return nil, ErrInvalidCursor
+		}
+
+		value := reflect.New(fields[i].FieldType)
+		if err = json.Unmarshal(decoded.Values[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+
+	alternatives := make([]clause.Expression, len(orders))
+	for i, order := range orders {
+		expressions := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			expressions = append(expressions, clause.Eq{Column: orders[j].Column, Value: values[j]})
+		}
+		if order.Desc {
+			expressions = append(expressions, clause.Lt{Column: order.Column, Value: values[i]})
+		} else {
+			expressions = append(expressions, clause.Gt{Column: order.Column, Value: values[i]})
+		}
+		alternatives[i] = clause.And(expressions...)
+	}
+
+	return clause.Or(alternatives...), nil
+}
+
+func encodeCursor(ctx context.Context, orders []clause.OrderByColumn, fields []*schema.Field, last any) (string, error) {
+	encoded := cursor{Columns: make([]string, len(orders)), Values: make([]json.RawMessage, len(orders))}
+	item := reflect.ValueOf(last)
+	for i, order := range orders {
+		value, _ := fields[i].ValueOf(ctx, item)
+		content, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		encoded.Columns[i], encoded.Values[i] = order.Column.Name, content
+	}
+
+	content, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(content), nil
+}
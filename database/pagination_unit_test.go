@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	users := NewRepository[repositoryUser](newRepositoryTestDatabase(t, "pagination", &repositoryUser{}))
+	err := users.Create(ctx,
+		&repositoryUser{ID: 1, Name: "a", Age: 20},
+		&repositoryUser{ID: 2, Name: "b", Age: 30},
+		&repositoryUser{ID: 3, Name: "c", Age: 20},
+		&repositoryUser{ID: 4, Name: "d", Age: 10},
+		&repositoryUser{ID: 5, Name: "e", Age: 30},
+		&repositoryUser{ID: 6, Name: "f", Age: 20},
+		&repositoryUser{ID: 7, Name: "g", Age: 40},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按游标逐页读取，返回所有页的 id
+	walk := func(t *testing.T, condition *Condition, size int) (ids []int) {
+		after := ""
+		for pages := 0; pages < 10; pages++ {
+			page, err := users.ListAfter(ctx, condition, after, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range page.Items {
+				ids = append(ids, item.ID)
+			}
+			if !page.HasMore {
+				if page.NextCursor != "" {
+					t.Fatal("expected no cursor on the last page")
+				}
+				return ids
+			}
+			after = page.NextCursor
+		}
+
+		t.Fatal("too many pages")
+		return nil
+	}
+
+	t.Run("ListAfter", func(t *testing.T) {
+		testCases := []struct {
+			name      string
+			condition *Condition
+			size      int
+			expected  []int
+		}{
+			{name: "PrimaryKey", condition: nil, size: 3, expected: []int{1, 2, 3, 4, 5, 6, 7}},
+			{name: "Desc", condition: NewCondition().OrderBy(RepositoryUserCols.Age, true), size: 2, expected: []int{7, 5, 2, 6, 3, 1, 4}},
+			{name: "Mixed", condition: NewCondition().OrderBy(RepositoryUserCols.Age, false).OrderBy(RepositoryUserCols.Name, true), size: 2, expected: []int{4, 6, 3, 1, 5, 2, 7}},
+			{name: "Filtered", condition: NewCondition().Gte(RepositoryUserCols.Age, 20).OrderBy(RepositoryUserCols.Age, false), size: 4, expected: []int{1, 3, 6, 2, 5, 7}},
+			{name: "ExactSize", condition: NewCondition().Eq(RepositoryUserCols.Age, 30), size: 2, expected: []int{2, 5}},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				if actual := walk(t, testCase.condition, testCase.size); !reflect.DeepEqual(actual, testCase.expected) {
+					t.Fatalf("expected %v, got %v", testCase.expected, actual)
+				}
+			})
+		}
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		page, err := users.ListAfter(ctx, NewCondition().OrderBy(RepositoryUserCols.Age, true), "", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 游标与排序不一致时拒绝
+		if _, err = users.ListAfter(ctx, NewCondition().OrderBy(RepositoryUserCols.Name, true), page.NextCursor, 2); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor, got %v", err)
+		}
+		if _, err = users.ListAfter(ctx, nil, "not a cursor", 2); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor, got %v", err)
+		}
+		if _, err = users.ListAfter(ctx, NewCondition().OrderBy("missing", false), "", 2); !errors.Is(err, ErrInvalidCondition) {
+			t.Fatalf("expected ErrInvalidCondition, got %v", err)
+		}
+	})
+
+	t.Run("ListPage", func(t *testing.T) {
+		condition := NewCondition().Gte(RepositoryUserCols.Age, 20).OrderBy(RepositoryUserCols.Age, true).OrderBy(RepositoryUserCols.ID, false)
+		page, err := users.ListPage(ctx, condition, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 6 || !page.HasMore || len(page.Items) != 2 || page.Items[0].ID != 5 || page.Items[1].ID != 1 {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+
+		page, err = users.ListPage(ctx, condition, 2, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 6 || page.HasMore || len(page.Items) != 2 {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+
+		// 分页不会修改调用方的条件
+		if condition.offset != 0 || condition.limit != 0 || len(condition.orders) != 2 {
+			t.Fatalf("expected the condition unchanged: %+v", condition)
+		}
+	})
+}
@@ -23,6 +23,26 @@ type Config struct {
 	MaxOpen       int    `yaml:"max_open,omitempty" json:"max_open,omitempty" xml:"max_open,omitempty"`
 	MaxLifeSecond int    `yaml:"max_life_second,omitempty" json:"max_life_second,omitempty" xml:"max_life_second,omitempty"`
 	TimeoutSecond int    `yaml:"timeout_second,omitempty" json:"timeout_second,omitempty" xml:"timeout_second,omitempty"`
+
+	// Replicas are the read replicas, the reads of DatabaseV2 are balanced among them by ReplicaPolicy,
+	// which is round_robin or random.
+	Replicas                  []ReplicaConfig `yaml:"replicas,omitempty" json:"replicas,omitempty" xml:"replicas,omitempty"`
+	ReplicaPolicy             string          `yaml:"replica_policy,omitempty" json:"replica_policy,omitempty" xml:"replica_policy,omitempty"`
+	HealthCheckIntervalSecond int             `yaml:"health_check_interval_second,omitempty" json:"health_check_interval_second,omitempty" xml:"health_check_interval_second,omitempty"`
+
+	// SlowThresholdMillisecond, SensitiveColumns and LogSampleRate configure the logging of the
+	// statements, the zero values mean the defaults of database.DBLogger.
+	SlowThresholdMillisecond int      `yaml:"slow_threshold_millisecond,omitempty" json:"slow_threshold_millisecond,omitempty" xml:"slow_threshold_millisecond,omitempty"`
+	SensitiveColumns         []string `yaml:"sensitive_columns,omitempty" json:"sensitive_columns,omitempty" xml:"sensitive_columns,omitempty"`
+	LogSampleRate            float64  `yaml:"log_sample_rate,omitempty" json:"log_sample_rate,omitempty" xml:"log_sample_rate,omitempty"`
+}
+
+// ReplicaConfig is the server of a read replica, the empty fields are the same as the primary.
+type ReplicaConfig struct {
+	Host     string `yaml:"host,omitempty" json:"host,omitempty" xml:"host,omitempty"`
+	Port     int    `yaml:"port,omitempty" json:"port,omitempty" xml:"port,omitempty"`
+	Username string `yaml:"username,omitempty" json:"username,omitempty" xml:"username,omitempty"`
+	Password string `yaml:"password,omitempty" json:"password,omitempty" xml:"password,omitempty"`
 }
 
 func convertConfigToOptions(cfg Config) (opt database.Options) {
@@ -35,12 +55,41 @@ func convertConfigToOptions(cfg Config) (opt database.Options) {
 	}
 
 	// host=localhost user=gorm password=gorm dbname=gorm port=9920 sslmode=disable TimeZone=Asia/Shanghai
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s", cfg.Host, cfg.Username, cfg.Password, cfg.Database, cfg.Port, ssl, cfg.Location)
+	buildDsn := func(host, username, password string, port int) string {
+		return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s", host, username, password, cfg.Database, port, ssl, cfg.Location)
+	}
+
+	replicas := make([]string, 0, len(cfg.Replicas))
+	for _, replica := range cfg.Replicas {
+		replica = inheritReplicaConfig(replica, cfg)
+		replicas = append(replicas, buildDsn(replica.Host, replica.Username, replica.Password, replica.Port))
+	}
+
 	return database.Options{
-		DataSource: dsn,
-		MaxIdle:    cfg.MaxIdle,
-		MaxOpen:    cfg.MaxOpen,
-		MaxLife:    time.Duration(cfg.MaxLifeSecond) * time.Second,
-		Timeout:    time.Duration(cfg.TimeoutSecond) * time.Second,
+		DataSource:          buildDsn(cfg.Host, cfg.Username, cfg.Password, cfg.Port),
+		MaxIdle:             cfg.MaxIdle,
+		MaxOpen:             cfg.MaxOpen,
+		MaxLife:             time.Duration(cfg.MaxLifeSecond) * time.Second,
+		Timeout:             time.Duration(cfg.TimeoutSecond) * time.Second,
+		Replicas:            replicas,
+		ReplicaPolicy:       cfg.ReplicaPolicy,
+		HealthCheckInterval: time.Duration(cfg.HealthCheckIntervalSecond) * time.Second,
+		SlowThreshold:       time.Duration(cfg.SlowThresholdMillisecond) * time.Millisecond,
+		SensitiveColumns:    cfg.SensitiveColumns,
+		SampleRate:          cfg.LogSampleRate,
+	}
+}
+
+func inheritReplicaConfig(replica ReplicaConfig, primary Config) ReplicaConfig {
+	if replica.Host == "" {
+		replica.Host = primary.Host
 	}
+	if replica.Port == 0 {
+		replica.Port = primary.Port
+	}
+	if replica.Username == "" {
+		replica.Username, replica.Password = primary.Username, primary.Password
+	}
+
+	return replica
 }
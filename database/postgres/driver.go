@@ -41,7 +41,10 @@ func (s *postgresDb) Init(options database.Options) error {
 	if openErr != nil {
 		return fmt.Errorf("open postgresDb database error: %w", openErr)
 	}
-	db.Logger = database.NewDBLogger(options.Logger)
+	db.Logger = database.NewDBLoggerWithOptions(options)
+	if pluginErr := db.Use(&database.Conventions{}); pluginErr != nil {
+		return fmt.Errorf("register postgresDb conventions error: %w", pluginErr)
+	}
 
 	// 设置数据库连接池
 	sqlDb, dbe := db.DB()
@@ -52,6 +55,16 @@ func (s *postgresDb) Init(options database.Options) error {
 	s.BaseDatabaseImplement.SetRandCommand("random()")
 	s.BaseDatabaseImplement.SetDriverName(DriverName)
 
+	// 连接只读副本
+	if len(options.Replicas) > 0 {
+		replicas, replicaErr := database.OpenReplicaSet(options, postgres.Open)
+		if replicaErr != nil {
+			_ = sqlDb.Close()
+			return fmt.Errorf("open postgresDb replicas error: %w", replicaErr)
+		}
+		s.BaseDatabaseImplementV2.Replicas = replicas
+	}
+
 	// 连接成功
 	s.BaseDatabaseImplement.Db, s.BaseDatabaseImplementV2.Db = db, db
 	s.Logger.Info(logger.NewFields().WithMessage("successfully open postgresDb database").WithData(dataSource))
@@ -59,6 +72,9 @@ func (s *postgresDb) Init(options database.Options) error {
 	// 注册退出事件
 	exit.RegisterExitEvent(func(_ os.Signal) {
 		_ = sqlDb.Close()
+		if s.BaseDatabaseImplementV2.Replicas != nil {
+			_ = s.BaseDatabaseImplementV2.Replicas.Close()
+		}
 		fmt.Println("closed postgres database")
 	}, "CLOSE_POSTGRES_DB_CONN")
 	return nil
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReplicaPolicyRoundRobin = "round_robin"
+	ReplicaPolicyRandom     = "random"
+)
+
+const (
+	defaultHealthCheckInterval = time.Second * 10
+	healthCheckTimeout         = time.Second * 3
+)
+
+// primaryKey is the context key of the flag forcing the reads to the primary.
+type primaryKey struct{}
+
+// UsePrimary returns a context forcing the reads to the primary, it is used to read the writes
+// just committed, which may not be replicated to the replicas yet.
+//
+// example:
+//
+//	_ = db.UpdateDataBySingleCondition(ctx, &User{Age: 18}, "id", 1)
+//	_ = db.GetDataBySingleCondition(database.UsePrimary(ctx), &user, "id", 1)
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func primaryRequired(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	required, _ := ctx.Value(primaryKey{}).(bool)
+	return required
+}
+
+type replica struct {
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet balances the reads among the read replicas, the replicas failing the health check
+// are skipped until they pass it again.
+type ReplicaSet struct {
+	replicas []*replica
+	policy   string
+	next     atomic.Uint64
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewReplicaSet creates a replica set of the replicas with the load balancing policy, which is one
+// of ReplicaPolicyRoundRobin and ReplicaPolicyRandom, an empty policy means round robin. The health
+// of the replicas is checked once before returning, then every interval in the background, the
+// interval is ten seconds if it is not positive.
+func NewReplicaSet(replicas []*gorm.DB, policy string, interval time.Duration) (*ReplicaSet, error) {
+	switch policy {
+	case "":
+		policy = ReplicaPolicyRoundRobin
+	case ReplicaPolicyRoundRobin, ReplicaPolicyRandom:
+	default:
+		return nil, fmt.Errorf("unknown replica policy: %s", policy)
+	}
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+
+	rs := &ReplicaSet{replicas: make([]*replica, len(replicas)), policy: policy, stop: make(chan struct{})}
+	for i, db := range replicas {
+		rs.replicas[i] = &replica{db: db}
+	}
+	rs.CheckHealth(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-rs.stop:
+				return
+			case <-ticker.C:
+				rs.CheckHealth(context.Background())
+			}
+		}
+	}()
+
+	return rs, nil
+}
+
+// OpenReplicaSet opens the replicas of the options with the dialector opener, the replicas share
+// the pool settings and the logger of the primary. The opener should not connect to the server,
+// so the replicas unreachable now are marked as unhealthy instead of failing the opening.
+func OpenReplicaSet(options Options, open func(dsn string) gorm.Dialector) (*ReplicaSet, error) {
+	replicas := make([]*gorm.DB, 0, len(options.Replicas))
+	closeOpened := func() {
+		for _, db := range replicas {
+			if sqlDb, err := db.DB(); err == nil {
+				_ = sqlDb.Close()
+			}
+		}
+	}
+
+	for i, dsn := range options.Replicas {
+		db, err := gorm.Open(open(dsn), &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			closeOpened()
+			return nil, fmt.Errorf("open replica %d error: %w", i, err)
+		}
+		if options.Logger != nil {
+			db.Logger = NewDBLoggerWithOptions(options)
+		}
+
+		sqlDb, err := db.DB()
+		if err != nil {
+			closeOpened()
+			return nil, fmt.Errorf("get replica %d error: %w", i, err)
+		}
+		if options.MaxIdle > 0 {
+			sqlDb.SetMaxIdleConns(options.MaxIdle)
+		}
+		if options.MaxOpen > 0 {
+			sqlDb.SetMaxOpenConns(options.MaxOpen)
+		}
+		if options.MaxLife > 0 {
+			sqlDb.SetConnMaxLifetime(options.MaxLife)
+		}
+		replicas = append(replicas, db)
+	}
+
+	return NewReplicaSet(replicas, options.ReplicaPolicy, options.HealthCheckInterval)
+}
+
+// CheckHealth pings all the replicas and updates their health.
+func (rs *ReplicaSet) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range rs.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+
+			timeout, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			sqlDb, err := r.db.DB()
+			if err == nil {
+				err = sqlDb.PingContext(timeout)
+			}
+			r.healthy.Store(err == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// Healthy returns the number of the healthy replicas.
+func (rs *ReplicaSet) Healthy() (count int) {
+	for _, r := range rs.replicas {
+		if r.healthy.Load() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Pick chooses a healthy replica by the policy, it returns false if no replica is healthy.
+func (rs *ReplicaSet) Pick() (db *gorm.DB, ok bool) {
+	if len(rs.replicas) == 0 {
+		return nil, false
+	}
+
+	start := rs.next.Add(1) - 1
+	if rs.policy == ReplicaPolicyRandom {
+		start = rand.Uint64()
+	}
+
+	// 从选中的副本开始依次查找健康的副本
+	for i := range uint64(len(rs.replicas)) {
+		r := rs.replicas[(start+i)%uint64(len(rs.replicas))]
+		if r.healthy.Load() {
+			return r.db, true
+		}
+	}
+
+	return nil, false
+}
+
+// Close stops the health check and closes the connections of the replicas.
+func (rs *ReplicaSet) Close() error {
+	rs.stopOnce.Do(func() { close(rs.stop) })
+
+	var errs []error
+	for _, r := range rs.replicas {
+		if sqlDb, err := r.db.DB(); err != nil {
+			errs = append(errs, err)
+		} else if err = sqlDb.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
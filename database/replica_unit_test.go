@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newReplicaTestGorm(t *testing.T, name string) *gorm.DB {
+	db := newRepositoryTestDatabase(t, name, &repositoryUser{}).GetGormCore(context.Background())
+	if err := db.Create(&repositoryUser{ID: 1, Name: name}).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	return db
+}
+
+func TestReplicaSet(t *testing.T) {
+	ctx := context.Background()
+	primary := newReplicaTestGorm(t, "replica_primary")
+	first, second := newReplicaTestGorm(t, "replica_first"), newReplicaTestGorm(t, "replica_second")
+
+	if _, err := NewReplicaSet([]*gorm.DB{first}, "weighted", time.Hour); err == nil {
+		t.Fatal("expected an error of the unknown policy")
+	}
+
+	replicas, err := NewReplicaSet([]*gorm.DB{first, second}, ReplicaPolicyRoundRobin, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = replicas.Close() })
+	db := &BaseDatabaseImplementV2{Db: primary, Replicas: replicas}
+	users := NewRepository[repositoryUser](db)
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		var names []string
+		for range 4 {
+			var user repositoryUser
+			if err := db.GetDataBySingleCondition(ctx, &user, "id", 1); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, user.Name)
+		}
+		if names[0] == names[1] || names[0] != names[2] || names[1] != names[3] || names[0] == "replica_primary" || names[1] == "replica_primary" {
+			t.Fatalf("expected the reads balanced between the replicas, got %v", names)
+		}
+	})
+
+	t.Run("Primary", func(t *testing.T) {
+		user, err := users.Get(UsePrimary(ctx), NewCondition().Eq(RepositoryUserCols.ID, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Name != "replica_primary" {
+			t.Fatalf("expected the primary, got %s", user.Name)
+		}
+
+		// 事务中的读取使用主库
+		err = db.WithTransaction(ctx, func(ctx context.Context) error {
+			var result []repositoryUser
+			if err := db.ExecuteRawSqlQuery(ctx, &result, "SELECT * FROM repository_users"); err != nil {
+				return err
+			}
+			if len(result) != 1 || result[0].Name != "replica_primary" {
+				t.Errorf("expected the primary in transaction, got %+v", result)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		if err := users.Create(ctx, &repositoryUser{ID: 2, Name: "written"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if exist, _ := users.Exists(ctx, NewCondition().Eq(RepositoryUserCols.ID, 2)); exist {
+			t.Fatal("expected the write not found in the replicas")
+		}
+		if exist, _ := users.Exists(UsePrimary(ctx), NewCondition().Eq(RepositoryUserCols.ID, 2)); !exist {
+			t.Fatal("expected the write found in the primary")
+		}
+	})
+
+	t.Run("Unhealthy", func(t *testing.T) {
+		sqlDb, _ := second.DB()
+		_ = sqlDb.Close()
+		replicas.CheckHealth(ctx)
+		if replicas.Healthy() != 1 {
+			t.Fatalf("expected 1 healthy replica, got %d", replicas.Healthy())
+		}
+
+		for range 3 {
+			user, err := users.Get(ctx, NewCondition().Eq(RepositoryUserCols.ID, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != "replica_first" {
+				t.Fatalf("expected the healthy replica, got %s", user.Name)
+			}
+		}
+
+		// 没有健康的副本时读取主库
+		_ = replicas.Close()
+		replicas.CheckHealth(ctx)
+		user, err := users.Get(ctx, NewCondition().Eq(RepositoryUserCols.ID, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Name != "replica_primary" {
+			t.Fatalf("expected the primary, got %s", user.Name)
+		}
+	})
+}
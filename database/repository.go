@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repository is a typed accessor of the table of the model T, it runs on the gorm core of the
+// DatabaseV2, so it works on all the drivers implementing DatabaseV2. The reads go to the read
+// replicas if they are configured.
+//
+// example:
+//
+//	users := database.NewRepository[User](db)
+//	user, err := users.Get(ctx, database.NewCondition().Eq(UserCols.Name, "alice"))
+type Repository[T any] struct {
+	db DatabaseV2
+}
+
+func NewRepository[T any](db DatabaseV2) *Repository[T] {
+	return &Repository[T]{db: db}
+}
+
+func (r *Repository[T]) model(ctx context.Context) *gorm.DB {
+	return r.db.GetGormCore(ctx).Model(new(T))
+}
+
+// reader returns the session of the reads, which go to the read replicas if they are configured.
+func (r *Repository[T]) reader(ctx context.Context) *gorm.DB {
+	return r.db.GetGormReader(ctx).Model(new(T))
+}
+
+// Get retrieves the first row matching the condition, ErrRecordNotFound is returned if no row
+// matches.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the query, nil matches all the rows.
+//
+// Returns:
+//
+//	result (T): The row matching the condition.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) Get(ctx context.Context, condition *Condition) (result T, err error) {
+	err = condition.applyQuery(r.reader(ctx)).Take(&result).Error
+	return result, err
+}
+
+// List retrieves all the rows matching the condition.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the query, nil matches all the rows.
+//
+// Returns:
+//
+//	results ([]T): The rows matching the condition.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) List(ctx context.Context, condition *Condition) (results []T, err error) {
+	results = []T{}
+	err = condition.applyQuery(r.reader(ctx)).Find(&results).Error
+	return results, err
+}
+
+// Create inserts the entities, the generated fields such as the auto increment primary keys are
+// written back into the entities.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	entities (...*T): The entities to insert.
+//
+// Returns:
+//
+//	error: An error if the operation fails, otherwise nil.
+func (r *Repository[T]) Create(ctx context.Context, entities ...*T) error {
+	switch len(entities) {
+	case 0:
+		return nil
+	case 1:
+		return r.model(ctx).Create(entities[0]).Error
+	default:
+		return r.model(ctx).Create(entities).Error
+	}
+}
+
+// Update updates the rows matching the condition with the non-zero fields of the entity, the
+// fields listed in fields are updated even if they are zero. For the models with VersionModel,
+// ErrStaleVersion is returned if the version of the entity is stale, and the bumped version is
+// written back to the entity.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the rows to update, it must not be empty.
+//	entity (*T): The values to update.
+//	fields (...string): Optional columns to update, including the zero values.
+//
+// Returns:
+//
+//	affected (int64): The number of the updated rows.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) Update(ctx context.Context, condition *Condition, entity *T, fields ...string) (affected int64, err error) {
+	if condition.Empty() || entity == nil {
+		return 0, ErrInvalidCondition
+	}
+
+	session := condition.apply(r.model(ctx))
+	if len(fields) > 0 {
+		session = session.Select(fields)
+	}
+
+	session = session.Updates(entity)
+	return session.RowsAffected, session.Error
+}
+
+// UpdateValues updates the columns of the rows matching the condition with the values.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the rows to update, it must not be empty.
+//	values (map[string]any): The columns and their new values.
+//
+// Returns:
+//
+//	affected (int64): The number of the updated rows.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) UpdateValues(ctx context.Context, condition *Condition, values map[string]any) (affected int64, err error) {
+	if condition.Empty() || len(values) == 0 {
+		return 0, ErrInvalidCondition
+	}
+
+	session := condition.apply(r.model(ctx)).Updates(values)
+	return session.RowsAffected, session.Error
+}
+
+// Delete deletes the rows matching the condition, the rows of the models with SoftDeleteModel
+// are soft deleted.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the rows to delete, it must not be empty.
+//
+// Returns:
+//
+//	affected (int64): The number of the deleted rows.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) Delete(ctx context.Context, condition *Condition) (affected int64, err error) {
+	if condition.Empty() {
+		return 0, ErrInvalidCondition
+	}
+
+	session := condition.apply(r.db.GetGormCore(ctx)).Delete(new(T))
+	return session.RowsAffected, session.Error
+}
+
+// HardDelete deletes the rows matching the condition permanently, including the soft deleted
+// rows, it is the same as Delete for the models without SoftDeleteModel.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the rows to delete, it must not be empty.
+//
+// Returns:
+//
+//	affected (int64): The number of the deleted rows.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) HardDelete(ctx context.Context, condition *Condition) (affected int64, err error) {
+	if condition.Empty() {
+		return 0, ErrInvalidCondition
+	}
+
+	session := condition.apply(r.db.GetGormCore(ctx).Unscoped()).Delete(new(T))
+	return session.RowsAffected, session.Error
+}
+
+// Count counts the rows matching the condition.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the query, nil matches all the rows.
+//
+// Returns:
+//
+//	count (int64): The number of the rows matching the condition.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) Count(ctx context.Context, condition *Condition) (count int64, err error) {
+	err = condition.apply(r.reader(ctx)).Count(&count).Error
+	return count, err
+}
+
+// Exists reports whether any row matches the condition.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation.
+//	condition (*Condition): The condition of the query, nil matches all the rows.
+//
+// Returns:
+//
+//	exist (bool): True if any row matches the condition.
+//	err (error): An error if the operation fails, otherwise nil.
+func (r *Repository[T]) Exists(ctx context.Context, condition *Condition) (exist bool, err error) {
+	var found []map[string]any
+	err = condition.apply(r.reader(ctx)).Select("1").Limit(1).Find(&found).Error
+	return len(found) > 0, err
+}
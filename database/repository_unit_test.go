@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type repositoryUser struct {
+	ID   int    `gorm:"column:id;primaryKey;autoIncrement"`
+	Name string `gorm:"column:name"`
+	Age  int    `gorm:"column:age"`
+}
+
+func (repositoryUser) TableName() string {
+	return "repository_users"
+}
+
+// 与 database-column 生成的列结构保持一致
+type repositoryuserCols struct {
+	ID   string
+	Name string
+	Age  string
+}
+
+var RepositoryUserCols = &repositoryuserCols{
+	ID:   "id",
+	Name: "name",
+	Age:  "age",
+}
+
+func newRepositoryTestDatabase(t *testing.T, name string, models ...any) DatabaseV2 {
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	// 共享缓存的内存数据库在最后一个连接关闭后才会释放
+	t.Cleanup(func() {
+		if sqlDb, dbErr := db.DB(); dbErr == nil {
+			_ = sqlDb.Close()
+		}
+	})
+	if err = db.Use(&Conventions{}); err != nil {
+		t.Fatalf("failed to register conventions: %v", err)
+	}
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return &BaseDatabaseImplementV2{Db: db}
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	users := NewRepository[repositoryUser](newRepositoryTestDatabase(t, "repository", &repositoryUser{}))
+
+	t.Run("Create", func(t *testing.T) {
+		alice := &repositoryUser{Name: "alice", Age: 18}
+		if err := users.Create(ctx, alice); err != nil {
+			t.Fatal(err)
+		}
+		if alice.ID == 0 {
+			t.Fatal("expected the primary key to be written back")
+		}
+
+		if err := users.Create(ctx, &repositoryUser{Name: "bob", Age: 20}, &repositoryUser{Name: "carol", Age: 25}, &repositoryUser{Name: "dave", Age: 30}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		user, err := users.Get(ctx, NewCondition().Eq(RepositoryUserCols.Name, "bob"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Age != 20 {
+			t.Fatalf("expected age 20, got %d", user.Age)
+		}
+
+		_, err = users.Get(ctx, NewCondition().Eq(RepositoryUserCols.Name, "nobody"))
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Fatalf("expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		result, err := users.List(ctx, NewCondition().Gte(RepositoryUserCols.Age, 20).OrderBy(RepositoryUserCols.Age, true).Limit(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 2 || result[0].Name != "dave" || result[1].Name != "carol" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+
+		result, err = users.List(ctx, NewCondition().OrderBy(RepositoryUserCols.ID, false).Offset(1).Limit(1).Select(RepositoryUserCols.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || result[0].Name != "bob" || result[0].Age != 0 {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+
+		result, err = users.List(ctx, NewCondition().Eq(RepositoryUserCols.Name, "alice").Or(NewCondition().Between(RepositoryUserCols.Age, 24, 26)).OrderBy(RepositoryUserCols.ID, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 2 || result[0].Name != "alice" || result[1].Name != "carol" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+
+		result, err = users.List(ctx, NewCondition().In(RepositoryUserCols.Name, "alice", "bob").NotIn(RepositoryUserCols.Age, 18).Like(RepositoryUserCols.Name, "b%"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || result[0].Name != "bob" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+
+		// 空的 IN 条件不匹配任何数据
+		result, err = users.List(ctx, NewCondition().In(RepositoryUserCols.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 0 {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("CountAndExists", func(t *testing.T) {
+		count, err := users.Count(ctx, NewCondition().Gt(RepositoryUserCols.Age, 18).Limit(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 3 {
+			t.Fatalf("expected count 3, got %d", count)
+		}
+
+		exist, err := users.Exists(ctx, NewCondition().Lt(RepositoryUserCols.Age, 18))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exist {
+			t.Fatal("expected no user younger than 18")
+		}
+
+		exist, err = users.Exists(ctx, NewCondition().Lte(RepositoryUserCols.Age, 18))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exist {
+			t.Fatal("expected a user of 18")
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		affected, err := users.Update(ctx, NewCondition().Eq(RepositoryUserCols.Name, "alice"), &repositoryUser{Age: 19})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if affected != 1 {
+			t.Fatalf("expected 1 affected row, got %d", affected)
+		}
+
+		// 指定字段时零值也会被更新
+		_, err = users.Update(ctx, NewCondition().Eq(RepositoryUserCols.Name, "alice"), &repositoryUser{}, RepositoryUserCols.Age)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alice, _ := users.Get(ctx, NewCondition().Eq(RepositoryUserCols.Name, "alice"))
+		if alice.Age != 0 {
+			t.Fatalf("expected age 0, got %d", alice.Age)
+		}
+
+		affected, err = users.UpdateValues(ctx, NewCondition().Ne(RepositoryUserCols.Name, "alice"), map[string]any{RepositoryUserCols.Age: 40})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if affected != 3 {
+			t.Fatalf("expected 3 affected rows, got %d", affected)
+		}
+
+		if _, err = users.UpdateValues(ctx, nil, map[string]any{RepositoryUserCols.Age: 1}); !errors.Is(err, ErrInvalidCondition) {
+			t.Fatalf("expected ErrInvalidCondition, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if _, err := users.Delete(ctx, NewCondition()); !errors.Is(err, ErrInvalidCondition) {
+			t.Fatalf("expected ErrInvalidCondition, got %v", err)
+		}
+
+		affected, err := users.Delete(ctx, NewCondition().Eq(RepositoryUserCols.Age, 40))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if affected != 3 {
+			t.Fatalf("expected 3 affected rows, got %d", affected)
+		}
+
+		count, err := users.Count(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("expected count 1, got %d", count)
+		}
+	})
+}
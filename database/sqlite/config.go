@@ -14,14 +14,23 @@ type Config struct {
 	MaxOpen       int    `yaml:"max_open,omitempty" json:"max_open,omitempty" xml:"max_open,omitempty"`
 	MaxLifeSecond int    `yaml:"max_life_second,omitempty" json:"max_life_second,omitempty" xml:"max_life_second,omitempty"`
 	TimeoutSecond int    `yaml:"timeout_second,omitempty" json:"timeout_second,omitempty" xml:"timeout_second,omitempty"`
+
+	// SlowThresholdMillisecond, SensitiveColumns and LogSampleRate configure the logging of the
+	// statements, the zero values mean the defaults of database.DBLogger.
+	SlowThresholdMillisecond int      `yaml:"slow_threshold_millisecond,omitempty" json:"slow_threshold_millisecond,omitempty" xml:"slow_threshold_millisecond,omitempty"`
+	SensitiveColumns         []string `yaml:"sensitive_columns,omitempty" json:"sensitive_columns,omitempty" xml:"sensitive_columns,omitempty"`
+	LogSampleRate            float64  `yaml:"log_sample_rate,omitempty" json:"log_sample_rate,omitempty" xml:"log_sample_rate,omitempty"`
 }
 
 func convertConfigToOptions(cfg Config) (opt database.Options) {
 	return database.Options{
-		DataSource: cfg.Database,
-		MaxIdle:    cfg.MaxIdle,
-		MaxOpen:    cfg.MaxOpen,
-		MaxLife:    time.Duration(cfg.MaxLifeSecond) * time.Second,
-		Timeout:    time.Duration(cfg.TimeoutSecond) * time.Second,
+		DataSource:       cfg.Database,
+		MaxIdle:          cfg.MaxIdle,
+		MaxOpen:          cfg.MaxOpen,
+		MaxLife:          time.Duration(cfg.MaxLifeSecond) * time.Second,
+		Timeout:          time.Duration(cfg.TimeoutSecond) * time.Second,
+		SlowThreshold:    time.Duration(cfg.SlowThresholdMillisecond) * time.Millisecond,
+		SensitiveColumns: cfg.SensitiveColumns,
+		SampleRate:       cfg.LogSampleRate,
 	}
 }
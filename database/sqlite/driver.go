@@ -49,7 +49,10 @@ func (s *sqliteDb) Init(options database.Options) error {
 			return err
 		}
 	}
-	db.Logger = database.NewDBLogger(options.Logger)
+	db.Logger = database.NewDBLoggerWithOptions(options)
+	if pluginErr := db.Use(&database.Conventions{}); pluginErr != nil {
+		return fmt.Errorf("register sqliteDb conventions error: %w", pluginErr)
+	}
 
 	// 设置数据库连接池
 	sqlDb, dbe := db.DB()
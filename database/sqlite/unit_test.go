@@ -3,6 +3,7 @@ package sqlite
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/alioth-center/infrastructure/trace"
 )
@@ -39,3 +40,16 @@ func TestSqliteDb(t *testing.T) {
 	t.Log(sqlite.GetAll(&values, ""))
 	t.Log(values)
 }
+
+func TestLoggingConfig(t *testing.T) {
+	opts := convertConfigToOptions(Config{
+		Database:                 "test.db",
+		SlowThresholdMillisecond: 50,
+		SensitiveColumns:         []string{"password"},
+		LogSampleRate:            0.5,
+	})
+
+	if opts.SlowThreshold != time.Millisecond*50 || len(opts.SensitiveColumns) != 1 || opts.SensitiveColumns[0] != "password" || opts.SampleRate != 0.5 {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
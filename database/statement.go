@@ -0,0 +1,240 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenIdent
+	tokenPlaceholder
+	tokenSymbol
+)
+
+var (
+	// resetKeywords start the clauses whose placeholders are not bound to a column.
+	resetKeywords = map[string]struct{}{
+		"SELECT": {}, "FROM": {}, "WHERE": {}, "LIMIT": {}, "OFFSET": {}, "RETURNING": {}, "FETCH": {},
+	}
+
+	// operatorKeywords are skipped when looking for the column of a placeholder.
+	operatorKeywords = map[string]struct{}{
+		"AND": {}, "OR": {}, "NOT": {}, "IN": {}, "IS": {}, "NULL": {}, "LIKE": {}, "ILIKE": {}, "BETWEEN": {},
+		"ESCAPE": {}, "AS": {}, "CASE": {}, "WHEN": {}, "THEN": {}, "ELSE": {}, "END": {}, "ANY": {}, "ALL": {},
+		"SOME": {}, "EXISTS": {}, "DISTINCT": {}, "TRUE": {}, "FALSE": {}, "COLLATE": {}, "BINARY": {}, "INTERVAL": {},
+	}
+)
+
+// scanTokens splits the sql into the tokens, the string literals and the comments are skipped, and
+// the quoted identifiers are unquoted.
+func scanTokens(sql string, yield func(kind tokenKind, text string) bool) {
+	isWord := func(c byte) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+	}
+
+	for i := 0; i < len(sql); {
+		c, next := sql[i], byte(0)
+		if i+1 < len(sql) {
+			next = sql[i+1]
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(sql, i)
+			if c != '\'' && !yield(tokenIdent, strings.ReplaceAll(sql[i+1:end-1], string([]byte{c, c}), string(c))) {
+				return
+			}
+			i = end
+		case c == '-' && next == '-':
+			if index := strings.IndexByte(sql[i:], '\n'); index >= 0 {
+				i += index + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && next == '*':
+			if index := strings.Index(sql[i+2:], "*/"); index >= 0 {
+				i += index + 4
+			} else {
+				i = len(sql)
+			}
+		case c == '?':
+			if !yield(tokenPlaceholder, "?") {
+				return
+			}
+			i++
+		case c == '$' && next >= '0' && next <= '9':
+			end := i + 1
+			for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+				end++
+			}
+			if !yield(tokenPlaceholder, sql[i:end]) {
+				return
+			}
+			i = end
+		case isWord(c):
+			end := i + 1
+			for end < len(sql) && isWord(sql[end]) {
+				end++
+			}
+			if !yield(tokenWord, sql[i:end]) {
+				return
+			}
+			i = end
+		default:
+			if !yield(tokenSymbol, sql[i:i+1]) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// skipQuoted returns the index after the quoted string starting at start, the doubled quotes and
+// the backslash escapes of the string literals are skipped.
+func skipQuoted(sql string, start int) int {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch {
+		case sql[i] == '\\' && quote == '\'':
+			i++
+		case sql[i] == quote && i+1 < len(sql) && sql[i+1] == quote:
+			i++
+		case sql[i] == quote:
+			return i + 1
+		}
+	}
+
+	return len(sql)
+}
+
+// parseStatement returns the operation of the sql, which is select, insert, update, delete or
+// other, and the table it operates on, the table is empty if it is not found.
+func parseStatement(sql string) (operation, table string) {
+	operation = "other"
+	var first, found, expectTable, qualified bool
+	scanTokens(sql, func(kind tokenKind, text string) bool {
+		isName := kind == tokenWord || kind == tokenIdent
+		switch {
+		case qualified:
+			// 带有 schema 的表名取最后一段
+			if isName {
+				table = text
+			}
+			return false
+		case table != "":
+			qualified = kind == tokenSymbol && text == "."
+			return qualified
+		case expectTable:
+			expectTable = false
+			if isName {
+				table = text
+			}
+			return true
+		case kind != tokenWord:
+			return true
+		}
+
+		upper := strings.ToUpper(text)
+		switch {
+		case !found && (upper == "SELECT" || upper == "INSERT" || upper == "UPDATE" || upper == "DELETE"):
+			found, operation, expectTable = true, strings.ToLower(upper), upper == "UPDATE"
+		case !found && !first && upper != "WITH":
+			return false
+		case found && ((upper == "FROM" && (operation == "select" || operation == "delete")) || (upper == "INTO" && operation == "insert")):
+			expectTable = true
+		}
+		first = true
+		return true
+	})
+
+	return operation, table
+}
+
+// placeholderColumns returns the columns bound to the placeholders of the sql, which are ? or $n.
+// The placeholders in the values of an insert are bound to the listed columns, the others are
+// bound to the last column before them, such as name = ? and id IN (?, ?). The column of an
+// unrecognized placeholder is empty.
+func placeholderColumns(sql string, count int) []string {
+	columns := make([]string, count)
+	var (
+		insert, inValues, collecting      bool
+		insertColumns                     []string
+		depth, position, index            int
+		column, previousColumn, lastToken string
+	)
+
+	scanTokens(sql, func(kind tokenKind, text string) bool {
+		defer func() { lastToken = text }()
+
+		switch kind {
+		case tokenWord, tokenIdent:
+			if kind == tokenWord {
+				upper := strings.ToUpper(text)
+				if upper == "INSERT" {
+					insert = true
+				}
+				if upper == "VALUES" {
+					inValues = insert
+				}
+				if _, reset := resetKeywords[upper]; reset || upper == "VALUES" {
+					column = ""
+					return true
+				}
+				if _, operator := operatorKeywords[upper]; operator {
+					return true
+				}
+			}
+			if collecting {
+				insertColumns = append(insertColumns, text)
+			} else {
+				previousColumn, column = column, text
+			}
+		case tokenSymbol:
+			switch text {
+			case "(":
+				depth++
+				if insert && !inValues && insertColumns == nil && depth == 1 {
+					collecting = true
+				} else if inValues && depth == 1 {
+					position = 0
+				} else if lastToken == column && column != "" {
+					// 函数名不是列名
+					column = previousColumn
+				}
+			case ")":
+				collecting = collecting && depth != 1
+				depth--
+			case ",":
+				if inValues && depth == 1 {
+					position++
+				}
+			}
+		case tokenPlaceholder:
+			i := index
+			if text == "?" {
+				index++
+			} else {
+				i, _ = strconv.Atoi(text[1:])
+				i--
+			}
+			if i < 0 || i >= count {
+				return true
+			}
+
+			if inValues && depth == 1 && position < len(insertColumns) {
+				columns[i] = insertColumns[position]
+			} else {
+				columns[i] = column
+			}
+		}
+
+		return true
+	})
+
+	return columns
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Stream executes the raw query and iterates the rows one by one without loading the entire result,
+// each row is scanned into a T, which is a struct or a map[string]any. The query goes to the read
+// replicas if they are configured. The iteration holds a connection until it ends, and ends with
+// the error if the query, the scanning or ctx fails.
+//
+// example:
+//
+//	Stream[User](ctx, db, "SELECT * FROM users WHERE created_at > ?", since)(func(user User, err error) bool {
+//		if err != nil {
+//			return false
+//		}
+//		return write(user) == nil
+//	})
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation, cancelling it stops the iteration.
+//	db (DatabaseV2): The database to query.
+//	sql (string): The raw SQL query.
+//	args (...any): The arguments of the query.
+//
+// Returns:
+//
+//	func(yield func(T, error) bool): The iterator of the rows, it is an iter.Seq2[T, error].
+func Stream[T any](ctx context.Context, db DatabaseV2, sql string, args ...any) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		streamRows(ctx, db.GetGormReader(ctx).Raw(sql, args...), yield)
+	}
+}
+
+// Stream iterates the rows matching the condition one by one without loading the entire result,
+// see Stream for the iteration.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation, cancelling it stops the iteration.
+//	condition (*Condition): The condition and the orders of the query, nil matches all the rows.
+//
+// Returns:
+//
+//	func(yield func(T, error) bool): The iterator of the rows, it is an iter.Seq2[T, error].
+func (r *Repository[T]) Stream(ctx context.Context, condition *Condition) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		streamRows(ctx, condition.applyQuery(r.reader(ctx)), yield)
+	}
+}
+
+// FindInBatches retrieves the rows matching the condition batch by batch with the keyset pagination
+// of ListAfter, and calls fn with each batch until all the rows are retrieved or fn fails. Unlike
+// Stream, no connection is held while fn is running.
+//
+// Parameters:
+//
+//	ctx (context.Context): The context for the database operation, cancelling it stops the retrieving.
+//	condition (*Condition): The condition and the orders of the query, nil matches all the rows.
+//	size (int): The number of the rows of a batch.
+//	fn (func(batch []T) error): The callback of each batch, its error stops the retrieving.
+//
+// Returns:
+//
+//	err (error): The error of the retrieving or fn, otherwise nil.
+func (r *Repository[T]) FindInBatches(ctx context.Context, condition *Condition, size int, fn func(batch []T) error) (err error) {
+	after := ""
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		page, listErr := r.ListAfter(ctx, condition, after, size)
+		if listErr != nil {
+			return listErr
+		}
+		if len(page.Items) > 0 {
+			if err = fn(page.Items); err != nil {
+				return err
+			}
+		}
+		if !page.HasMore {
+			return nil
+		}
+
+		after = page.NextCursor
+	}
+}
+
+func streamRows[T any](ctx context.Context, query *gorm.DB, yield func(T, error) bool) {
+	var zero T
+	rows, err := query.Rows()
+	if err != nil {
+		yield(zero, err)
+		return
+	}
+	defer rows.Close()
+
+	// 使用新的会话扫描，避免查询语句的状态影响扫描
+	scanner := query.Session(&gorm.Session{NewDB: true})
+	for rows.Next() {
+		var item T
+		if err = ctx.Err(); err == nil {
+			err = scanner.ScanRows(rows, &item)
+		}
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		if !yield(item, nil) {
+			return
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		yield(zero, err)
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	db := newRepositoryTestDatabase(t, "stream", &repositoryUser{})
+	users := NewRepository[repositoryUser](db)
+	rows := make([]repositoryUser, 10)
+	for i := range rows {
+		rows[i] = repositoryUser{ID: i + 1, Name: string(rune('a' + i)), Age: i % 3}
+	}
+	if _, err := db.BatchInsert(ctx, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	// 收集迭代的结果，遇到错误时停止
+	collect := func(seq func(yield func(repositoryUser, error) bool), limit int) (ids []int, err error) {
+		seq(func(user repositoryUser, iterErr error) bool {
+			if iterErr != nil {
+				err = iterErr
+				return false
+			}
+			ids = append(ids, user.ID)
+			return len(ids) < limit
+		})
+		return ids, err
+	}
+
+	t.Run("Raw", func(t *testing.T) {
+		ids, err := collect(Stream[repositoryUser](ctx, db, "SELECT * FROM repository_users WHERE age = ? ORDER BY id", 1), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids, []int{2, 5, 8}) {
+			t.Fatalf("unexpected ids: %v", ids)
+		}
+
+		if _, err = collect(Stream[repositoryUser](ctx, db, "SELECT * FROM missing"), 100); err == nil {
+			t.Fatal("expected an error of the missing table")
+		}
+	})
+
+	t.Run("Repository", func(t *testing.T) {
+		ids, err := collect(users.Stream(ctx, NewCondition().Gte(RepositoryUserCols.Age, 1).OrderBy(RepositoryUserCols.ID, true)), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids, []int{9, 8, 6, 5, 3, 2}) {
+			t.Fatalf("unexpected ids: %v", ids)
+		}
+
+		// 提前停止迭代后连接被释放
+		if ids, _ = collect(users.Stream(ctx, nil), 2); len(ids) != 2 {
+			t.Fatalf("expected 2 ids, got %v", ids)
+		}
+		if count, err := users.Count(ctx, nil); err != nil || count != 10 {
+			t.Fatalf("expected count 10, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		var ids []int
+		var err error
+		users.Stream(cancelled, nil)(func(user repositoryUser, iterErr error) bool {
+			if iterErr != nil {
+				err = iterErr
+				return false
+			}
+			ids = append(ids, user.ID)
+			cancel()
+			return true
+		})
+		if !errors.Is(err, context.Canceled) || len(ids) != 1 {
+			t.Fatalf("expected cancelled after 1 row, got %v, %v", ids, err)
+		}
+	})
+}
+
+func TestFindInBatches(t *testing.T) {
+	ctx := context.Background()
+	db := newRepositoryTestDatabase(t, "find_in_batches", &repositoryUser{})
+	users := NewRepository[repositoryUser](db)
+	rows := make([]repositoryUser, 7)
+	for i := range rows {
+		rows[i] = repositoryUser{ID: i + 1, Age: i % 2}
+	}
+	if _, err := db.BatchInsert(ctx, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes, ids []int
+	err := users.FindInBatches(ctx, NewCondition().OrderBy(RepositoryUserCols.Age, false), 3, func(batch []repositoryUser) error {
+		sizes = append(sizes, len(batch))
+		for _, user := range batch {
+			ids = append(ids, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sizes, []int{3, 3, 1}) || !reflect.DeepEqual(ids, []int{1, 3, 5, 7, 2, 4, 6}) {
+		t.Fatalf("unexpected batches: %v, %v", sizes, ids)
+	}
+
+	errStop := errors.New("stop")
+	batches := 0
+	err = users.FindInBatches(ctx, nil, 2, func(batch []repositoryUser) error {
+		batches++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || batches != 1 {
+		t.Fatalf("expected stopped after 1 batch, got %d, %v", batches, err)
+	}
+}
@@ -1,5 +1,444 @@
 package database
 
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alioth-center/infrastructure/utils/values"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxCachedTemplates limits the parsed templates cached by the executing, the templates are
+// expected to be constants, the dynamic templates beyond the limit are parsed every time.
+const maxCachedTemplates = 1024
+
+// RawSqlTemplate renders the SQL template by itself, the rendered SQL is executed as it is.
+//
+// Deprecated: the values substituted into the SQL are open to injection, implement
+// SqlTemplateParams, such as NamedParams, to bind the values as the parameters instead.
 type RawSqlTemplate interface {
 	ParseTemplate(tmpl string) (result string)
 }
+
+// SqlTemplateParams provides the values of the ${name} placeholders of the SQL templates, the
+// values are bound as the parameters of the statement instead of substituted into the SQL, see
+// ParseSqlTemplate for the syntax of the templates. The executing methods accept it as a
+// RawSqlTemplate, and bind the values when the RawSqlTemplate is also a SqlTemplateParams.
+type SqlTemplateParams interface {
+	RawSqlTemplate
+	TemplateParams() (params map[string]any)
+}
+
+// NamedParams is the SqlTemplateParams of the named values.
+//
+// example:
+//
+//	err := db.ExecuteRawSqlTemplateQuery(ctx, &users, "SELECT * FROM users WHERE id IN (${ids})", database.NamedParams{"ids": ids})
+type NamedParams map[string]any
+
+func (p NamedParams) TemplateParams() map[string]any {
+	return p
+}
+
+// ParseTemplate returns the template as it is, the values of NamedParams are bound by the
+// executing methods instead of substituted.
+func (p NamedParams) ParseTemplate(tmpl string) string {
+	return tmpl
+}
+
+type templateSegment struct {
+	text     string
+	param    string
+	optional []templateSegment
+}
+
+// SqlTemplate is a parsed SQL template, it is safe for concurrent use.
+type SqlTemplate struct {
+	template string
+	segments []templateSegment
+}
+
+// ParseSqlTemplate parses the SQL template, the template is plain SQL with the placeholders:
+//   - ${name}: bound as a parameter, a slice is expanded to the parameters of an IN list.
+//   - [[ ... ]]: an optional clause, which is dropped if any of its parameters is missing, nil or
+//     an empty slice. The optional clauses can not be nested and must contain a parameter.
+//
+// The placeholders in the quoted strings, including the dollar-quoted strings of PostgreSQL such
+// as $$...$$ and $tag$...$tag$, are rejected, and those in the comments are ignored.
+//
+// example:
+//
+//	SELECT * FROM users WHERE status = ${status} [[AND age >= ${min_age}]] [[AND id IN (${ids})]]
+//
+// Parameters:
+//
+//	template (string): The SQL template.
+//
+// Returns:
+//
+//	parsed (*SqlTemplate): The parsed template.
+//	err (error): A SqlTemplateError if the syntax of the template is invalid, otherwise nil.
+func ParseSqlTemplate(template string) (parsed *SqlTemplate, err error) {
+	var (
+		root, optional []templateSegment
+		text           strings.Builder
+		inOptional     bool
+		quote          byte
+		dollarQuote    string
+	)
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		if inOptional {
+			optional = append(optional, templateSegment{text: text.String()})
+		} else {
+			root = append(root, templateSegment{text: text.String()})
+		}
+		text.Reset()
+	}
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		next := byte(0)
+		if i+1 < len(template) {
+			next = template[i+1]
+		}
+
+		switch {
+		case dollarQuote != "":
+			if strings.HasPrefix(template[i:], dollarQuote) {
+				text.WriteString(dollarQuote)
+				i += len(dollarQuote) - 1
+				dollarQuote = ""
+				continue
+			}
+			// 美元符号引用的字符串内的占位符同样不会被绑定
+			if c == '$' && next == '{' {
+				return nil, NewSqlTemplateError(template, "placeholder in quoted string")
+			}
+			text.WriteByte(c)
+		case quote != 0:
+			// 引号内的占位符不会被绑定，直接拒绝
+			if c == '$' && next == '{' {
+				return nil, NewSqlTemplateError(template, "placeholder in quoted string")
+			}
+			if c == '\\' && next != 0 {
+				text.WriteByte(c)
+				c = next
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			text.WriteByte(c)
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			text.WriteByte(c)
+		case c == '$' && next != '{' && dollarQuoteTag(template, i) != "":
+			dollarQuote = dollarQuoteTag(template, i)
+			text.WriteString(dollarQuote)
+			i += len(dollarQuote) - 1
+		case c == '-' && next == '-', c == '/' && next == '*':
+			end, closing := len(template), "\n"
+			if c == '/' {
+				closing = "*/"
+			}
+			if index := strings.Index(template[i+2:], closing); index >= 0 {
+				end = i + 2 + index + len(closing)
+			}
+			text.WriteString(template[i:end])
+			i = end - 1
+		case c == '$' && next == '{':
+			end := strings.IndexByte(template[i+2:], '}')
+			if end < 0 {
+				return nil, NewSqlTemplateError(template, "unclosed placeholder")
+			}
+			name := template[i+2 : i+2+end]
+			if !validParamName(name) {
+				return nil, NewSqlTemplateError(template, values.BuildStrings("invalid parameter name: ", name))
+			}
+
+			flush()
+			if inOptional {
+				optional = append(optional, templateSegment{param: name})
+			} else {
+				root = append(root, templateSegment{param: name})
+			}
+			i += end + 2
+		case c == '[' && next == '[':
+			if inOptional {
+				return nil, NewSqlTemplateError(template, "nested optional clause")
+			}
+			flush()
+			inOptional = true
+			i++
+		case c == ']' && next == ']':
+			if !inOptional {
+				return nil, NewSqlTemplateError(template, "unopened optional clause")
+			}
+			flush()
+			if !containsParam(optional) {
+				return nil, NewSqlTemplateError(template, "optional clause without parameter")
+			}
+			root, optional, inOptional = append(root, templateSegment{optional: optional}), nil, false
+			i++
+		default:
+			text.WriteByte(c)
+		}
+	}
+
+	if quote != 0 || dollarQuote != "" {
+		return nil, NewSqlTemplateError(template, "unclosed quoted string")
+	}
+	if inOptional {
+		return nil, NewSqlTemplateError(template, "unclosed optional clause")
+	}
+	flush()
+
+	return &SqlTemplate{template: template, segments: root}, nil
+}
+
+// dollarQuoteTag returns the opening delimiter of the dollar-quoted string at i, such as $$ or
+// $tag$, or an empty string if it is not one. The positional parameters such as $1 and the
+// identifiers containing $ are not dollar quotes.
+func dollarQuoteTag(template string, i int) string {
+	if i > 0 && isIdentifierChar(template[i-1]) {
+		return ""
+	}
+
+	end := i + 1
+	for end < len(template) && isIdentifierChar(template[end]) {
+		end++
+	}
+	if end >= len(template) || template[end] != '$' || (end > i+1 && template[i+1] >= '0' && template[i+1] <= '9') {
+		return ""
+	}
+
+	return template[i : end+1]
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func validParamName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsParam(segments []templateSegment) bool {
+	for _, segment := range segments {
+		if segment.param != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bind renders the template to the SQL with the bind parameters of the dialect, which are $n for
+// postgres and ? for the others, and returns the parameters in the order of the SQL.
+//
+// Parameters:
+//
+//	dialect (string): The name of the gorm dialector, such as mysql, postgres and sqlite.
+//	params (map[string]any): The values of the placeholders.
+//
+// Returns:
+//
+//	rendered (string): The rendered SQL.
+//	args ([]any): The parameters of the SQL.
+//	err (error): A SqlTemplateError if a required parameter is not bound or a list is empty, otherwise nil.
+func (t *SqlTemplate) Bind(dialect string, params map[string]any) (rendered string, args []any, err error) {
+	var (
+		builder strings.Builder
+		missing []string
+	)
+
+	var render func(segments []templateSegment) error
+	render = func(segments []templateSegment) error {
+		for _, segment := range segments {
+			switch {
+			case segment.optional != nil:
+				if bindable(segment.optional, params) {
+					if renderErr := render(segment.optional); renderErr != nil {
+						return renderErr
+					}
+				}
+			case segment.param != "":
+				value, exist := params[segment.param]
+				if !exist {
+					if !slices.Contains(missing, segment.param) {
+						missing = append(missing, segment.param)
+					}
+					continue
+				}
+				if bindErr := t.bindParam(&builder, &args, dialect, segment.param, value); bindErr != nil {
+					return bindErr
+				}
+			default:
+				builder.WriteString(segment.text)
+			}
+		}
+
+		return nil
+	}
+
+	if err = render(t.segments); err != nil {
+		return "", nil, err
+	}
+	if len(missing) > 0 {
+		return "", nil, NewSqlTemplateError(t.template, values.BuildStrings("parameters not bound: ", strings.Join(missing, ", ")))
+	}
+
+	return builder.String(), args, nil
+}
+
+func (t *SqlTemplate) bindParam(builder *strings.Builder, args *[]any, dialect, name string, value any) error {
+	placeholder := func(arg any) {
+		*args = append(*args, arg)
+		if dialect == "postgres" {
+			builder.WriteByte('$')
+			builder.WriteString(strconv.Itoa(len(*args)))
+		} else {
+			builder.WriteByte('?')
+		}
+	}
+
+	list, isList := listOf(value)
+	if !isList {
+		placeholder(value)
+		return nil
+	}
+	if list.Len() == 0 {
+		return NewSqlTemplateError(t.template, values.BuildStrings("empty list of parameter: ", name))
+	}
+
+	for i := range list.Len() {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		placeholder(list.Index(i).Interface())
+	}
+
+	return nil
+}
+
+// listOf reports whether the value is expanded to an IN list, the bytes and the driver.Valuer are
+// bound as a single parameter.
+func listOf(value any) (reflect.Value, bool) {
+	if _, isValuer := value.(driver.Valuer); isValuer {
+		return reflect.Value{}, false
+	}
+
+	list := reflect.ValueOf(value)
+	switch list.Kind() {
+	case reflect.Slice:
+		return list, list.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return list, true
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// bindable reports whether all the parameters of the optional clause are present.
+func bindable(segments []templateSegment, params map[string]any) bool {
+	for _, segment := range segments {
+		if segment.param == "" {
+			continue
+		}
+
+		value, exist := params[segment.param]
+		if !exist || value == nil {
+			return false
+		}
+		switch reflected := reflect.ValueOf(value); reflected.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func:
+			if reflected.IsNil() {
+				return false
+			}
+		case reflect.Slice:
+			if list, isList := listOf(value); reflected.IsNil() || (isList && list.Len() == 0) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// boundSql is the rendered template, it writes the SQL and the parameters into the statement as
+// they are, so gorm does not parse the placeholders again.
+type boundSql struct {
+	sql  string
+	args []any
+}
+
+func (b boundSql) Build(builder clause.Builder) {
+	builder.WriteString(b.sql)
+	for _, arg := range b.args {
+		builder.AddVar(builder, sql.NamedArg{Value: arg})
+	}
+}
+
+var (
+	templateCache  sync.Map
+	templateCached atomic.Int64
+)
+
+func cachedSqlTemplate(template string) (*SqlTemplate, error) {
+	if cached, exist := templateCache.Load(template); exist {
+		return cached.(*SqlTemplate), nil
+	}
+
+	parsed, err := ParseSqlTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	if templateCached.Load() < maxCachedTemplates {
+		if _, loaded := templateCache.LoadOrStore(template, parsed); !loaded {
+			templateCached.Add(1)
+		}
+	}
+
+	return parsed, nil
+}
+
+// bindSqlTemplate renders the template with the parameters for the dialect of the session.
+func bindSqlTemplate(session *gorm.DB, template string, params SqlTemplateParams) (clause.Expression, error) {
+	parsed, err := cachedSqlTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	var named map[string]any
+	if params != nil {
+		named = params.TemplateParams()
+	}
+
+	rendered, args, err := parsed.Bind(session.Dialector.Name(), named)
+	if err != nil {
+		return nil, err
+	}
+
+	return boundSql{sql: rendered, args: args}, nil
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestParseSqlTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		reason   string
+	}{
+		{name: "UnclosedPlaceholder", template: "SELECT * FROM users WHERE id = ${id", reason: "unclosed placeholder"},
+		{name: "InvalidName", template: "SELECT * FROM users WHERE id = ${1id}", reason: "invalid parameter name: 1id"},
+		{name: "QuotedPlaceholder", template: "SELECT * FROM users WHERE name = '${name}'", reason: "placeholder in quoted string"},
+		{name: "UnclosedQuote", template: "SELECT * FROM users WHERE name = 'it\\'s", reason: "unclosed quoted string"},
+		{name: "DollarQuotedPlaceholder", template: "DO $$ BEGIN PERFORM ${name}; END $$", reason: "placeholder in quoted string"},
+		{name: "TaggedDollarQuotedPlaceholder", template: "CREATE FUNCTION f() RETURNS text AS $fn$ SELECT '${name}' $fn$ LANGUAGE sql", reason: "placeholder in quoted string"},
+		{name: "UnclosedDollarQuote", template: "SELECT $tag$ text $$ ${name}", reason: "placeholder in quoted string"},
+		{name: "UnclosedDollarQuoteEnd", template: "SELECT $tag$ text", reason: "unclosed quoted string"},
+		{name: "NestedOptional", template: "SELECT 1 [[AND a = ${a} [[AND b = ${b}]]]]", reason: "nested optional clause"},
+		{name: "UnopenedOptional", template: "SELECT 1 ]]", reason: "unopened optional clause"},
+		{name: "UnclosedOptional", template: "SELECT 1 [[AND a = ${a}", reason: "unclosed optional clause"},
+		{name: "OptionalWithoutParameter", template: "SELECT 1 [[AND a = 1]]", reason: "optional clause without parameter"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := ParseSqlTemplate(testCase.template)
+			templateErr := &SqlTemplateError{}
+			if !errors.As(err, &templateErr) || templateErr.Reason != testCase.reason {
+				t.Fatalf("expected reason %s, got %v", testCase.reason, err)
+			}
+		})
+	}
+}
+
+func TestSqlTemplateBind(t *testing.T) {
+	template, err := ParseSqlTemplate("SELECT * FROM users /* ${ignored} */ WHERE status = ${status} [[AND age >= ${age}]] [[AND id IN (${ids})]] AND name <> 'a\\'b' AND data = ${data} -- ${ignored}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		dialect  string
+		params   map[string]any
+		expected string
+		args     []any
+	}{
+		{
+			name:     "Mysql",
+			dialect:  "mysql",
+			params:   map[string]any{"status": "active", "age": 18, "ids": []int{1, 2, 3}, "data": []byte("x")},
+			expected: "SELECT * FROM users /* ${ignored} */ WHERE status = ? AND age >= ? AND id IN (?, ?, ?) AND name <> 'a\\'b' AND data = ? -- ${ignored}",
+			args:     []any{"active", 18, 1, 2, 3, []byte("x")},
+		},
+		{
+			name:     "PostgresDropped",
+			dialect:  "postgres",
+			params:   map[string]any{"status": "active", "age": (*int)(nil), "ids": []int{4, 5}, "data": nil},
+			expected: "SELECT * FROM users /* ${ignored} */ WHERE status = $1  AND id IN ($2, $3) AND name <> 'a\\'b' AND data = $4 -- ${ignored}",
+			args:     []any{"active", 4, 5, nil},
+		},
+		{
+			name:     "SqliteAllDropped",
+			dialect:  "sqlite",
+			params:   map[string]any{"status": "active", "ids": []int{}, "data": 1},
+			expected: "SELECT * FROM users /* ${ignored} */ WHERE status = ?   AND name <> 'a\\'b' AND data = ? -- ${ignored}",
+			args:     []any{"active", 1},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rendered, args, err := template.Bind(testCase.dialect, testCase.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rendered != testCase.expected || !reflect.DeepEqual(args, testCase.args) {
+				t.Fatalf("expected %s %v, got %s %v", testCase.expected, testCase.args, rendered, args)
+			}
+		})
+	}
+
+	t.Run("DollarQuote", func(t *testing.T) {
+		// 美元符号引用的内容原样保留，位置参数和含 $ 的标识符不是美元符号引用
+		quoted, parseErr := ParseSqlTemplate("SELECT $body$ it's $$ $body$, $1, a$b$c FROM t WHERE id = ${id} AND s = $$}{$$")
+		if parseErr != nil {
+			t.Fatal(parseErr)
+		}
+		rendered, args, bindErr := quoted.Bind("postgres", map[string]any{"id": 1})
+		if bindErr != nil || rendered != "SELECT $body$ it's $$ $body$, $1, a$b$c FROM t WHERE id = $1 AND s = $$}{$$" || !reflect.DeepEqual(args, []any{1}) {
+			t.Fatalf("unexpected binding: %s %v %v", rendered, args, bindErr)
+		}
+	})
+
+	t.Run("Unbound", func(t *testing.T) {
+		_, _, err := template.Bind("mysql", map[string]any{"age": 18})
+		templateErr := &SqlTemplateError{}
+		if !errors.As(err, &templateErr) || templateErr.Reason != "parameters not bound: status, data" {
+			t.Fatalf("expected the unbound parameters, got %v", err)
+		}
+
+		list, _ := ParseSqlTemplate("SELECT * FROM users WHERE id IN (${ids})")
+		if _, _, err = list.Bind("mysql", map[string]any{"ids": []int{}}); !errors.As(err, &templateErr) || templateErr.Reason != "empty list of parameter: ids" {
+			t.Fatalf("expected the empty list, got %v", err)
+		}
+	})
+}
+
+func TestExecuteRawSqlTemplate(t *testing.T) {
+	ctx := context.Background()
+	db := newRepositoryTestDatabase(t, "raw_sql_template", &repositoryUser{})
+
+	// 参数不会被拼接到 SQL 中
+	insert := "INSERT INTO repository_users (id, name, age) VALUES (${id}, ${name}, ${age})"
+	for i, name := range []string{"alice", "bob@example.com", "'); DROP TABLE repository_users; --"} {
+		if err := db.ExecuteRawSqlTemplate(ctx, insert, NamedParams{"id": i + 1, "name": name, "age": 20 + i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := "SELECT * FROM repository_users WHERE name <> '@' [[AND id IN (${ids})]] [[AND age >= ${min_age}]] ORDER BY id"
+	var users []repositoryUser
+	if err := db.ExecuteRawSqlTemplateQuery(ctx, &users, query, NamedParams{"ids": []int{1, 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].Name != "'); DROP TABLE repository_users; --" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	users = nil
+	if err := db.ExecuteRawSqlTemplateQuery(ctx, &users, query, NamedParams{"min_age": 21}); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != "bob@example.com" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	if err := db.ExecuteRawSqlTemplate(ctx, insert, NamedParams{"id": 4}); err == nil {
+		t.Fatal("expected an error of the unbound parameters")
+	}
+
+	// 自行渲染的模板仍然兼容
+	users = nil
+	legacy := legacySqlTemplate{"min_age": "22"}
+	if err := db.ExecuteRawSqlTemplateQuery(ctx, &users, "SELECT * FROM repository_users WHERE age >= ${min_age}", legacy); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != 3 {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+}
+
+type legacySqlTemplate map[string]string
+
+func (l legacySqlTemplate) ParseTemplate(tmpl string) string {
+	for name, value := range l {
+		tmpl = strings.ReplaceAll(tmpl, "${"+name+"}", value)
+	}
+
+	return tmpl
+}
+
+func TestBindSqlTemplateStatement(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=postgres dbname=test"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 绑定后的 SQL 不会被 gorm 再次解析 ? 和 @
+	bound, err := bindSqlTemplate(db, "SELECT * FROM users WHERE email LIKE '%@%' AND tags ? 'a' AND id IN (${ids})", NamedParams{"ids": []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := db.Raw("?", bound).Statement
+	if statement.SQL.String() != "SELECT * FROM users WHERE email LIKE '%@%' AND tags ? 'a' AND id IN ($1, $2)" || !reflect.DeepEqual(statement.Vars, []any{1, 2}) {
+		t.Fatalf("unexpected statement: %s %v", statement.SQL.String(), statement.Vars)
+	}
+}
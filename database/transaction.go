@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// transactionKey is the context key of the transaction opened on the database db, so the
+// transactions of different databases carried by the same context do not interfere.
+type transactionKey struct {
+	db *gorm.DB
+}
+
+type TransactionOption func(options *sql.TxOptions)
+
+// WithIsolationLevelOpts sets the isolation level of the transaction, the default level of the
+// driver is used by default.
+func WithIsolationLevelOpts(level sql.IsolationLevel) TransactionOption {
+	return func(options *sql.TxOptions) {
+		options.Isolation = level
+	}
+}
+
+// WithReadOnlyOpts opens a read-only transaction.
+func WithReadOnlyOpts() TransactionOption {
+	return func(options *sql.TxOptions) {
+		options.ReadOnly = true
+	}
+}
+
+func newTransactionOptions(opts []TransactionOption) *sql.TxOptions {
+	options := &sql.TxOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(options)
+		}
+	}
+
+	return options
+}
+
+// session returns the transaction carried by ctx if there is one, otherwise a new session of db,
+// both are bound to ctx and include the soft deleted rows if ctx is returned by WithDeleted.
+func session(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx != nil {
+		if tx, inTransaction := ctx.Value(transactionKey{db: db}).(*gorm.DB); inTransaction {
+			return unscoped(ctx, tx.WithContext(ctx))
+		}
+	}
+
+	return unscoped(ctx, db.WithContext(ctx))
+}
+
+func inTransaction(ctx context.Context, db *gorm.DB) bool {
+	if ctx == nil {
+		return false
+	}
+
+	_, in := ctx.Value(transactionKey{db: db}).(*gorm.DB)
+	return in
+}
+
+// withTransaction runs fn in a transaction of db, the transaction is carried by the context passed
+// to fn. If ctx already carries a transaction of db, fn runs in a savepoint of it and the options
+// are ignored, since the isolation level and the access mode can not be changed in a transaction.
+func withTransaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error, opts []TransactionOption) error {
+	return session(ctx, db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{db: db}, tx))
+	}, newTransactionOptions(opts))
+}
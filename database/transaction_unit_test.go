@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestWithTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := newRepositoryTestDatabase(t, "transaction", &repositoryUser{})
+	users := NewRepository[repositoryUser](db)
+	errRollback := errors.New("rollback")
+
+	t.Run("Commit", func(t *testing.T) {
+		err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := users.Create(ctx, &repositoryUser{Name: "alice", Age: 18}); err != nil {
+				return err
+			}
+
+			_, err := db.CreateSingleDataIfNotExist(ctx, &repositoryUser{Name: "bob", Age: 20})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := users.Count(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Fatalf("expected count 2, got %d", count)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			if _, err := users.UpdateValues(ctx, NewCondition().Eq(RepositoryUserCols.Name, "alice"), map[string]any{RepositoryUserCols.Age: 30}); err != nil {
+				return err
+			}
+
+			// 事务内可以读到未提交的修改
+			var alice repositoryUser
+			if err := db.GetDataBySingleCondition(ctx, &alice, RepositoryUserCols.Name, "alice"); err != nil {
+				return err
+			}
+			if alice.Age != 30 {
+				t.Errorf("expected age 30 in transaction, got %d", alice.Age)
+			}
+
+			return errRollback
+		}, WithIsolationLevelOpts(sql.LevelSerializable))
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expected errRollback, got %v", err)
+		}
+
+		alice, err := users.Get(ctx, NewCondition().Eq(RepositoryUserCols.Name, "alice"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alice.Age != 18 {
+			t.Fatalf("expected age 18 after rollback, got %d", alice.Age)
+		}
+	})
+
+	t.Run("Savepoint", func(t *testing.T) {
+		err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := users.Create(ctx, &repositoryUser{Name: "carol", Age: 25}); err != nil {
+				return err
+			}
+
+			// 嵌套事务回滚到保存点，不影响外层事务
+			nestedErr := db.WithTransaction(ctx, func(ctx context.Context) error {
+				if err := users.Create(ctx, &repositoryUser{Name: "dave", Age: 30}); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			if !errors.Is(nestedErr, errRollback) {
+				t.Errorf("expected errRollback, got %v", nestedErr)
+			}
+
+			return db.WithTransaction(ctx, func(ctx context.Context) error {
+				return users.Create(ctx, &repositoryUser{Name: "erin", Age: 35})
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := users.List(ctx, NewCondition().In(RepositoryUserCols.Name, "carol", "dave", "erin").OrderBy(RepositoryUserCols.ID, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 2 || result[0].Name != "carol" || result[1].Name != "erin" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		var count int64
+		err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			return db.GetGormCore(ctx).Model(&repositoryUser{}).Count(&count).Error
+		}, WithReadOnlyOpts())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 4 {
+			t.Fatalf("expected count 4, got %d", count)
+		}
+	})
+
+	t.Run("OtherDatabase", func(t *testing.T) {
+		// 其他数据库的事务不会被当前数据库使用
+		other := newRepositoryTestDatabase(t, "transaction_other", &repositoryUser{})
+		err := other.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := NewRepository[repositoryUser](other).Create(ctx, &repositoryUser{Name: "frank"}); err != nil {
+				return err
+			}
+			if exist, err := users.Exists(ctx, NewCondition().Eq(RepositoryUserCols.Name, "frank")); err != nil || exist {
+				t.Errorf("expected frank not in the database, exist: %v, error: %v", exist, err)
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expected errRollback, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RawEndPoint mounts a standard net/http handler, it is used for the handlers writing the response
+// by themselves, such as the metrics handlers.
+//
+// example:
+//
+//	engine.AddEndPoints(http.NewRawEndPoint(http.GET, http.NewRouter("/metrics"), metrics))
+type RawEndPoint struct {
+	method  Method
+	router  Router
+	handler http.Handler
+}
+
+func (ep *RawEndPoint) bindRouter(base *gin.RouterGroup, father Router) {
+	if base == nil || ep.router == nil || ep.handler == nil {
+		return
+	}
+
+	ep.router.Extend(father)
+	routerPath := ep.router.FullRouterPath()
+	if routerPath == "" {
+		routerPath = "/"
+	}
+
+	base.Handle(ep.method, routerPath, gin.WrapH(ep.handler))
+}
+
+func NewRawEndPoint(method Method, router Router, handler http.Handler) *RawEndPoint {
+	return &RawEndPoint{method: method, router: router, handler: handler}
+}
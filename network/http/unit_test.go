@@ -1059,3 +1059,26 @@ func TestEngine(t *testing.T) {
 	ctx.Request, _ = http.NewRequest("GET", "/", nil)
 	engine.defaultHandler(ctx)
 }
+
+func TestRawEndPoint(t *testing.T) {
+	engine := NewEngine("/base")
+	engine.AddEndPoints(NewRawEndPoint(GET, NewRouter("/metrics"), http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("raw"))
+	})))
+	engine.registerEndpoints()
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest(GET, "/base/metrics", nil)
+	engine.core.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "raw" {
+		t.Fatalf("unexpected response %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	request, _ = http.NewRequest(POST, "/base/metrics", nil)
+	engine.core.ServeHTTP(recorder, request)
+	if recorder.Code == http.StatusOK {
+		t.Fatal("raw endpoint should only serve the registered method")
+	}
+}
@@ -12,6 +12,9 @@ import (
 const (
 	// defaultTraceIDKey is the default key used to store the trace ID in the context.
 	defaultTraceIDKey = "trace_id"
+
+	// userIDKey is the key used to store the user ID in the context.
+	userIDKey = "user_id"
 )
 
 var traceIDKey = defaultTraceIDKey
@@ -123,6 +126,22 @@ func Context(ctx context.Context, traceID string) context.Context {
 	return context.WithValue(ctx, traceIDKey, traceID) // nolint
 }
 
+// WithUserID creates a new context with the specified user ID, the user ID identifies the operator of
+// the request, such as the audit columns of the database.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID) // nolint
+}
+
+// GetUserID retrieves the user ID from the context. If the context does not contain a user ID, it returns an empty string.
+func GetUserID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
 // GetClientIPFromPeer extracts the client IP address from a gRPC request context.
 // If the context does not originate from a gRPC request or the client IP cannot be determined, it returns an empty string.
 func GetClientIPFromPeer(ctx context.Context) (ip string) {
@@ -127,6 +127,16 @@ func TestContext(t *testing.T) {
 		}
 	})
 
+	t.Run("UserID", func(t *testing.T) {
+		ctx := WithUserID(NewContext(), "10086")
+		if GetUserID(ctx) != "10086" {
+			t.Error("user id is not equal")
+		}
+		if GetUserID(context.Background()) != "" {
+			t.Error("user id is not empty")
+		}
+	})
+
 	t.Run("NewContext", func(t *testing.T) {
 		ctx := NewContext()
 		tid := GetTid(ctx)
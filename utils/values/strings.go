@@ -386,6 +386,8 @@ func NewStringTemplateWithCustomSignals(template string, arguments map[string]st
 // then
 //
 //	SELECT * FROM user WHERE name = '\'; DROP TABLE users WHERE 1=1 --' AND age = 18
+//
+// Deprecated: 字符串替换存在注入风险，请使用 database.NamedParams 将参数绑定到 SQL 模板
 func NewRawSqlTemplate(template string, arguments any) *RawSqlTemplate {
 	// 如果args是map[string]string，调用NewRawSqlTemplateWithMap
 	if m, ok := arguments.(map[string]string); ok {
@@ -413,6 +415,8 @@ func NewRawSqlTemplate(template string, arguments any) *RawSqlTemplate {
 //	}
 //	result := NewRawSqlTemplateWithMap(template, arguments).Parse()
 //	fmt.Println(result) // SELECT * FROM user WHERE name = 'test'
+//
+// Deprecated: 字符串替换存在注入风险，请使用 database.NamedParams 将参数绑定到 SQL 模板
 func NewRawSqlTemplateWithMap(template string, arguments map[string]string) *RawSqlTemplate {
 	rtn := &RawSqlTemplate{
 		&StringTemplate{